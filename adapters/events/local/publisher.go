@@ -0,0 +1,50 @@
+// Package local publishes events in-process: they are logged and handed to
+// the subscribed handlers.
+package local
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// Handler receives the published events. The relay publishes an event again
+// when a handler fails, so handlers must tolerate duplicates.
+type Handler func(ctx context.Context, event entities.Event) error
+
+type Publisher struct {
+	logger *slog.Logger
+
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func New(logger *slog.Logger) *Publisher {
+	return &Publisher{logger: logger}
+}
+
+func (p *Publisher) Subscribe(h Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.handlers = append(p.handlers, h)
+}
+
+func (p *Publisher) Publish(ctx context.Context, event entities.Event) error {
+	p.logger.Info("event published",
+		"id", event.ID, "type", event.Type, "cliente_id", event.ClienteID, "occurred_at", event.OccurredAt)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, h := range p.handlers {
+		if err := h(ctx, event); err != nil {
+			return fmt.Errorf("handling %s event %s: %w", event.Type, event.ID, err)
+		}
+	}
+
+	return nil
+}
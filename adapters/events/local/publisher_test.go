@@ -0,0 +1,35 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+func TestPublisher(t *testing.T) {
+	p := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	event := entities.Event{ID: entities.NewID(), Type: entities.ClienteUpdated, ClienteID: entities.NewID()}
+
+	var got []entities.ID
+	p.Subscribe(func(ctx context.Context, e entities.Event) error {
+		got = append(got, e.ID)
+		return nil
+	})
+
+	if err := p.Publish(context.Background(), event); err != nil {
+		t.Fatalf("should not have return any error, got: %s", err)
+	}
+	if len(got) != 1 || got[0] != event.ID {
+		t.Errorf("should have handed the event to the handler, got: %v", got)
+	}
+
+	failure := errors.New("handler failure")
+	p.Subscribe(func(ctx context.Context, e entities.Event) error { return failure })
+	if err := p.Publish(context.Background(), event); !errors.Is(err, failure) {
+		t.Errorf("want: %s, got: %v", failure, err)
+	}
+}
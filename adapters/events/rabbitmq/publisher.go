@@ -0,0 +1,181 @@
+// Package rabbitmq publishes events to a RabbitMQ topic exchange, routed by
+// "clientes.<event type>".
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+var (
+	errNacked     = errors.New("message not confirmed by the broker")
+	errUnroutable = errors.New("message returned by the broker, no queue is bound for it")
+)
+
+type Config struct {
+	URL      string
+	Exchange string
+}
+
+// broker is the confirm mode channel messages are published on, the tests
+// replace it with a local stand-in.
+type broker interface {
+	publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error
+	close() error
+}
+
+// Publisher waits for the broker to confirm every message. After a failure it
+// connects again on the next Publish.
+type Publisher struct {
+	cfg  Config
+	dial func(Config) (broker, error)
+
+	mu     sync.Mutex
+	broker broker
+}
+
+func New(cfg Config) (*Publisher, error) {
+	p := &Publisher{cfg: cfg, dial: dial}
+
+	b, err := p.dial(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p.broker = b
+
+	return p, nil
+}
+
+func (p *Publisher) Publish(ctx context.Context, event entities.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.broker == nil {
+		b, err := p.dial(p.cfg)
+		if err != nil {
+			return err
+		}
+		p.broker = b
+	}
+
+	if err := p.broker.publish(ctx, p.cfg.Exchange, RoutingKey(event.Type), message(event)); err != nil {
+		_ = p.broker.close()
+		p.broker = nil
+		return fmt.Errorf("publishing %s event %s: %w", event.Type, event.ID, err)
+	}
+
+	return nil
+}
+
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.broker == nil {
+		return nil
+	}
+	err := p.broker.close()
+	p.broker = nil
+
+	return err
+}
+
+func RoutingKey(t entities.EventType) string {
+	return "clientes." + string(t)
+}
+
+// message is the persistent AMQP message of the event, identified by the
+// event id.
+func message(event entities.Event) amqp.Publishing {
+	return amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    event.ID.String(),
+		Type:         string(event.Type),
+		Timestamp:    event.OccurredAt,
+		Headers:      amqp.Table{"cliente_id": event.ClienteID.String()},
+		Body:         event.Payload,
+	}
+}
+
+type channel struct {
+	conn *amqp.Connection
+	ch   *amqp.Channel
+	// returns has the mandatory messages no queue took, the broker sends
+	// them back before confirming them
+	returns chan amqp.Return
+}
+
+func dial(cfg Config) (broker, error) {
+	conn, err := amqp.Dial(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to broker: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("opening channel: %w", err)
+	}
+	if err := ch.ExchangeDeclare(cfg.Exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("declaring exchange %s: %w", cfg.Exchange, err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("enabling publisher confirms: %w", err)
+	}
+
+	returns := ch.NotifyReturn(make(chan amqp.Return, 1))
+
+	return &channel{conn: conn, ch: ch, returns: returns}, nil
+}
+
+// publish sends msg as mandatory, so an event no queue is bound for fails
+// instead of being dropped.
+func (c *channel) publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	confirm, err := c.ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, true, false, msg)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errNacked
+	}
+	if returned(c.returns, msg.MessageId) {
+		return errUnroutable
+	}
+
+	return nil
+}
+
+// returned drains the returned messages and tells whether the one with id is
+// among them.
+func returned(returns <-chan amqp.Return, id string) bool {
+	found := false
+	for {
+		select {
+		case r, ok := <-returns:
+			if !ok {
+				return found
+			}
+			found = found || r.MessageId == id
+		default:
+			return found
+		}
+	}
+}
+
+func (c *channel) close() error {
+	return c.conn.Close()
+}
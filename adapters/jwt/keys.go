@@ -0,0 +1,320 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	minRSABits          = 2048
+	defaultKeysTTL      = 15 * time.Minute
+	minRefreshInterval  = time.Minute
+	maxJWKSBytes        = 1 << 20
+	defaultFetchTimeout = 10 * time.Second
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Key is a public key of a JWKS that signs tokens.
+type Key struct {
+	ID string
+	// Algorithm is RS256 or ES256, the only one the key verifies.
+	Algorithm string
+	Public    crypto.PublicKey
+}
+
+// KeySet finds the key a token was signed with.
+type KeySet interface {
+	// LookupKey returns the key with the id, or the only key of the set when
+	// the id is empty. It fails with ErrUnknownKey when there is none.
+	LookupKey(ctx context.Context, kid string) (Key, error)
+}
+
+// jwk is a JSON Web Key (RFC 7517) of an RSA or P-256 public key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// StaticKeys is a KeySet that never changes.
+type StaticKeys []Key
+
+// ParseJWKS reads the signing keys of a JWK Set. Keys for other uses or of
+// unsupported types are skipped.
+func ParseJWKS(data []byte) (StaticKeys, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("decoding jwks: %w", err)
+	}
+
+	var keys StaticKeys
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.key()
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no RS256 or ES256 signing key")
+	}
+
+	return keys, nil
+}
+
+func (ks StaticKeys) LookupKey(ctx context.Context, kid string) (Key, error) {
+	if kid == "" {
+		if len(ks) == 1 {
+			return ks[0], nil
+		}
+		return Key{}, fmt.Errorf("%w: token has no kid", ErrUnknownKey)
+	}
+
+	for _, k := range ks {
+		if k.ID == kid {
+			return k, nil
+		}
+	}
+
+	return Key{}, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+}
+
+var errUnsupportedKey = errors.New("unsupported key")
+
+func (k jwk) key() (Key, error) {
+	switch {
+	case k.Kty == "RSA" && (k.Alg == "" || k.Alg == RS256):
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return Key{}, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return Key{}, fmt.Errorf("exponent: %w", err)
+		}
+		if n.BitLen() < minRSABits {
+			return Key{}, fmt.Errorf("rsa key must have at least %d bits", minRSABits)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return Key{}, errors.New("invalid rsa exponent")
+		}
+
+		return Key{ID: k.Kid, Algorithm: RS256, Public: &rsa.PublicKey{N: n, E: int(e.Int64())}}, nil
+
+	case k.Kty == "EC" && k.Crv == "P-256" && (k.Alg == "" || k.Alg == ES256):
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != 32 {
+			return Key{}, errors.New("invalid x coordinate")
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil || len(y) != 32 {
+			return Key{}, errors.New("invalid y coordinate")
+		}
+		// rejects points that are not on the curve
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return Key{}, err
+		}
+
+		return Key{ID: k.Kid, Algorithm: ES256, Public: &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}}, nil
+	}
+
+	return Key{}, errUnsupportedKey
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// RemoteKeys is a KeySet fetched from a JWKS URL, kept for a while and
+// fetched again as soon as a token names a key it does not have, so keys can
+// be rotated. Only one fetch runs at a time, the lookups needing it wait for
+// it, and fetches are attempted at most once a minute: while the issuer is
+// down, the keys fetched last are still used.
+type RemoteKeys struct {
+	url    string
+	client *http.Client
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu          sync.Mutex
+	keys        StaticKeys
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// err is the error of the last fetch
+	err error
+	// fetching is closed when the fetch in flight, if any, is done
+	fetching chan struct{}
+}
+
+func NewRemoteKeys(url string) *RemoteKeys {
+	return &RemoteKeys{
+		url:    url,
+		client: &http.Client{Timeout: defaultFetchTimeout},
+		ttl:    defaultKeysTTL,
+		now:    time.Now,
+	}
+}
+
+func (rk *RemoteKeys) LookupKey(ctx context.Context, kid string) (Key, error) {
+	keys, err := rk.keySet(ctx, rk.ttl)
+	if err != nil {
+		return Key{}, err
+	}
+
+	key, err := keys.LookupKey(ctx, kid)
+	// a key rotated in, fetched at most once a minute so bogus kids cannot
+	// flood the issuer
+	if errors.Is(err, ErrUnknownKey) {
+		if keys, err = rk.keySet(ctx, 0); err != nil {
+			return Key{}, err
+		}
+		return keys.LookupKey(ctx, kid)
+	}
+
+	return key, err
+}
+
+// keySet returns the keys if they were fetched at most maxAge ago, fetching
+// them again otherwise. When the last fetch was attempted less than a minute
+// ago, the keys are returned as they are, and the error of that fetch only if
+// there are none.
+func (rk *RemoteKeys) keySet(ctx context.Context, maxAge time.Duration) (StaticKeys, error) {
+	rk.mu.Lock()
+	if rk.keys != nil && rk.now().Sub(rk.fetchedAt) <= maxAge {
+		defer rk.mu.Unlock()
+		return rk.keys, nil
+	}
+
+	done := rk.fetching
+	if done == nil && !rk.attemptedAt.IsZero() && rk.now().Sub(rk.attemptedAt) < minRefreshInterval {
+		defer rk.mu.Unlock()
+		return rk.current()
+	}
+
+	if done == nil {
+		done = make(chan struct{})
+		rk.fetching = done
+		rk.attemptedAt = rk.now()
+		// the fetch serves the other lookups too, so it does not end with
+		// the request that started it
+		go rk.refresh(context.WithoutCancel(ctx), done)
+	}
+	rk.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	rk.mu.Lock()
+	defer rk.mu.Unlock()
+
+	return rk.current()
+}
+
+// refresh fetches the keys, keeping the ones it has when it fails, and closes
+// done.
+func (rk *RemoteKeys) refresh(ctx context.Context, done chan struct{}) {
+	keys, err := rk.fetch(ctx)
+
+	rk.mu.Lock()
+	defer rk.mu.Unlock()
+
+	if err == nil {
+		rk.keys = keys
+		rk.fetchedAt = rk.now()
+	}
+	rk.err = err
+	rk.fetching = nil
+	close(done)
+}
+
+// current must be called with mu held.
+func (rk *RemoteKeys) current() (StaticKeys, error) {
+	if rk.keys == nil {
+		return nil, rk.err
+	}
+
+	return rk.keys, nil
+}
+
+func (rk *RemoteKeys) fetch(ctx context.Context) (StaticKeys, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rk.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := rk.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+	if err != nil {
+		return nil, fmt.Errorf("reading jwks: %w", err)
+	}
+
+	return ParseJWKS(data)
+}
+
+// LoadKeys returns the KeySet of source, a JWKS URL or the path of a JWKS
+// file.
+func LoadKeys(source string) (KeySet, error) {
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		return NewRemoteKeys(source), nil
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("reading jwks file: %w", err)
+	}
+
+	return ParseJWKS(data)
+}
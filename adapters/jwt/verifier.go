@@ -0,0 +1,202 @@
+// Package jwt verifies the RS256 and ES256 signed JSON Web Tokens clients
+// authenticate with, using the keys of a JWKS.
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+const (
+	RS256 = "RS256"
+	ES256 = "ES256"
+
+	defaultLeeway = time.Minute
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type Config struct {
+	// Issuer is the iss every token must have.
+	Issuer string
+	// Audience must be one of the aud of every token.
+	Audience string
+	// Leeway is the clock skew tolerated on exp, nbf and iat, one minute
+	// when zero.
+	Leeway time.Duration
+}
+
+type Verifier struct {
+	keys KeySet
+	cfg  Config
+	now  func() time.Time
+}
+
+func New(keys KeySet, cfg Config) *Verifier {
+	if cfg.Leeway == 0 {
+		cfg.Leeway = defaultLeeway
+	}
+
+	return &Verifier{keys: keys, cfg: cfg, now: time.Now}
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// audience is a single string or an array of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*a = audience{s}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// claims are the registered claims checked on every token, the roles and
+// scopes (RFC 8693, section 4.2) the principal is authorized by and the cpf of
+// the clientes.
+type claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+	IssuedAt  *float64 `json:"iat"`
+	Roles     []string `json:"roles"`
+	Scope     string   `json:"scope"`
+	CPF       string   `json:"cpf"`
+}
+
+// Verify checks the signature and claims of a compact serialized token and
+// returns who it was issued to. Errors wrap ErrInvalidToken.
+func (v *Verifier) Verify(ctx context.Context, token string) (entities.Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return entities.Principal{}, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return entities.Principal{}, fmt.Errorf("%w: header: %s", ErrInvalidToken, err)
+	}
+	if h.Alg != RS256 && h.Alg != ES256 {
+		return entities.Principal{}, fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, h.Alg)
+	}
+
+	key, err := v.keys.LookupKey(ctx, h.Kid)
+	if err != nil {
+		return entities.Principal{}, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+	// the key decides the algorithm, not the token
+	if key.Algorithm != h.Alg {
+		return entities.Principal{}, fmt.Errorf("%w: alg %s does not match key %q", ErrInvalidToken, h.Alg, key.ID)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return entities.Principal{}, fmt.Errorf("%w: signature: %s", ErrInvalidToken, err)
+	}
+	if !verifySignature(key, []byte(parts[0]+"."+parts[1]), sig) {
+		return entities.Principal{}, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return entities.Principal{}, fmt.Errorf("%w: claims: %s", ErrInvalidToken, err)
+	}
+	if err := v.validate(c); err != nil {
+		return entities.Principal{}, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+
+	return entities.Principal{
+		Subject: c.Subject,
+		Roles:   c.Roles,
+		Scopes:  strings.Fields(c.Scope),
+		CPF:     c.CPF,
+	}, nil
+}
+
+func (v *Verifier) validate(c claims) error {
+	now := v.now()
+
+	switch {
+	case c.ExpiresAt == nil:
+		return errors.New("no exp")
+	case now.After(numericDate(*c.ExpiresAt).Add(v.cfg.Leeway)):
+		return errors.New("expired")
+	case c.NotBefore != nil && now.Before(numericDate(*c.NotBefore).Add(-v.cfg.Leeway)):
+		return errors.New("not valid yet")
+	case c.IssuedAt != nil && now.Before(numericDate(*c.IssuedAt).Add(-v.cfg.Leeway)):
+		return errors.New("issued in the future")
+	case c.Issuer != v.cfg.Issuer:
+		return fmt.Errorf("unexpected issuer %q", c.Issuer)
+	case !slices.Contains(c.Audience, v.cfg.Audience):
+		return errors.New("not issued for this audience")
+	case c.Subject == "":
+		return errors.New("no sub")
+	}
+
+	return nil
+}
+
+func verifySignature(key Key, signed, sig []byte) bool {
+	digest := sha256.Sum256(signed)
+
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+	case *ecdsa.PublicKey:
+		// r and s, 32 bytes each (RFC 7518, section 3.4)
+		if len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, digest[:], r, s)
+	}
+
+	return false
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func numericDate(f float64) time.Time {
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9))
+}
@@ -0,0 +1,444 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://auth.pedeai.test"
+	testAudience = "pedeai-clientes"
+)
+
+var now = time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+type testKey struct {
+	id  string
+	alg string
+	key crypto.Signer
+}
+
+func newRSAKey(t *testing.T, id string) testKey {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating rsa key, error: %s", err)
+	}
+	return testKey{id: id, alg: RS256, key: k}
+}
+
+func newECKey(t *testing.T, id string) testKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ec key, error: %s", err)
+	}
+	return testKey{id: id, alg: ES256, key: k}
+}
+
+func (k testKey) jwk() map[string]string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch pub := k.key.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA", "kid": k.id, "use": "sig", "alg": RS256,
+			"n": b64(pub.N.Bytes()), "e": b64(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		return map[string]string{
+			"kty": "EC", "kid": k.id, "use": "sig", "crv": "P-256",
+			"x": b64(pub.X.FillBytes(make([]byte, 32))), "y": b64(pub.Y.FillBytes(make([]byte, 32))),
+		}
+	}
+	return nil
+}
+
+func jwks(t *testing.T, keys ...testKey) []byte {
+	t.Helper()
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for _, k := range keys {
+		set.Keys = append(set.Keys, k.jwk())
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("encoding jwks, error: %s", err)
+	}
+	return data
+}
+
+// sign makes a token with the header alg and the claims, signed with k.
+func sign(t *testing.T, k testKey, alg string, claims map[string]any) string {
+	t.Helper()
+	enc := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("encoding token, error: %s", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signed := enc(map[string]string{"alg": alg, "kid": k.id, "typ": "JWT"}) + "." + enc(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch key := k.key.(type) {
+	case *rsa.PrivateKey:
+		s, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("signing token, error: %s", err)
+		}
+		sig = s
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatalf("signing token, error: %s", err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss": testIssuer,
+		"aud": testAudience,
+		"sub": "cliente-app",
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+}
+
+func with(claims map[string]any, key string, value any) map[string]any {
+	claims[key] = value
+	if value == nil {
+		delete(claims, key)
+	}
+	return claims
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa-1")
+	ecKey := newECKey(t, "ec-1")
+	unknownKey := newRSAKey(t, "rsa-1")
+
+	keys, err := ParseJWKS(jwks(t, rsaKey, ecKey))
+	if err != nil {
+		t.Fatalf("parsing jwks, error: %s", err)
+	}
+	v := New(keys, Config{Issuer: testIssuer, Audience: testAudience})
+	v.now = func() time.Time { return now }
+
+	t.Run("valid tokens", func(t *testing.T) {
+		for _, k := range []testKey{rsaKey, ecKey} {
+			p, err := v.Verify(context.Background(), sign(t, k, k.alg, validClaims()))
+			if err != nil {
+				t.Fatalf("%s: should not have return any error, got: %s", k.alg, err)
+			}
+			if p.Subject != "cliente-app" {
+				t.Errorf("%s: want subject cliente-app, got: %q", k.alg, p.Subject)
+			}
+		}
+	})
+
+	t.Run("roles and scopes", func(t *testing.T) {
+		claims := with(validClaims(), "roles", []string{"totem"})
+		token := sign(t, rsaKey, RS256, with(claims, "scope", "openid clientes"))
+
+		p, err := v.Verify(context.Background(), token)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if !slices.Equal(p.Roles, []string{"totem"}) || !slices.Equal(p.Scopes, []string{"openid", "clientes"}) {
+			t.Errorf("want role totem and scopes openid and clientes, got: %v and %v", p.Roles, p.Scopes)
+		}
+	})
+
+	t.Run("audience array", func(t *testing.T) {
+		token := sign(t, rsaKey, RS256, with(validClaims(), "aud", []string{"other", testAudience}))
+		if _, err := v.Verify(context.Background(), token); err != nil {
+			t.Errorf("should not have return any error, got: %s", err)
+		}
+	})
+
+	t.Run("expired within the leeway", func(t *testing.T) {
+		token := sign(t, rsaKey, RS256, with(validClaims(), "exp", now.Add(-30*time.Second).Unix()))
+		if _, err := v.Verify(context.Background(), token); err != nil {
+			t.Errorf("should not have return any error, got: %s", err)
+		}
+	})
+
+	hs256 := strings.Split(sign(t, rsaKey, "HS256", validClaims()), ".")
+	none := sign(t, rsaKey, "none", validClaims())
+	none = none[:strings.LastIndex(none, ".")+1]
+	tampered := strings.Split(sign(t, rsaKey, RS256, validClaims()), ".")
+	tampered[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"` + testIssuer + `","aud":"` + testAudience + `","sub":"admin","exp":9999999999}`))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"malformed", "not-a-token"},
+		{"expired", sign(t, rsaKey, RS256, with(validClaims(), "exp", now.Add(-2*time.Minute).Unix()))},
+		{"no exp", sign(t, rsaKey, RS256, with(validClaims(), "exp", nil))},
+		{"not valid yet", sign(t, rsaKey, RS256, with(validClaims(), "nbf", now.Add(5*time.Minute).Unix()))},
+		{"issued in the future", sign(t, rsaKey, RS256, with(validClaims(), "iat", now.Add(5*time.Minute).Unix()))},
+		{"wrong issuer", sign(t, rsaKey, RS256, with(validClaims(), "iss", "https://evil.test"))},
+		{"wrong audience", sign(t, rsaKey, RS256, with(validClaims(), "aud", "other"))},
+		{"no subject", sign(t, rsaKey, RS256, with(validClaims(), "sub", nil))},
+		{"alg none", none},
+		{"alg HS256", strings.Join(hs256, ".")},
+		{"alg does not match key", sign(t, ecKey, RS256, validClaims())},
+		{"unknown key", sign(t, newECKey(t, "ec-2"), ES256, validClaims())},
+		{"signed with another key", sign(t, unknownKey, RS256, validClaims())},
+		{"tampered claims", strings.Join(tampered, ".")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tt.token)
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("should have return %v, got: %v", ErrInvalidToken, err)
+			}
+		})
+	}
+
+	t.Run("configured leeway", func(t *testing.T) {
+		strict := New(keys, Config{Issuer: testIssuer, Audience: testAudience, Leeway: time.Second})
+		strict.now = func() time.Time { return now }
+
+		token := sign(t, rsaKey, RS256, with(validClaims(), "exp", now.Add(-30*time.Second).Unix()))
+		if _, err := strict.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("should have return %v, got: %v", ErrInvalidToken, err)
+		}
+	})
+}
+
+func TestParseJWKS(t *testing.T) {
+	t.Run("skips keys it does not verify with", func(t *testing.T) {
+		data, _ := json.Marshal(map[string]any{"keys": []any{
+			map[string]string{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+			map[string]string{"kty": "RSA", "kid": "enc", "use": "enc"},
+			newECKey(t, "ec-1").jwk(),
+		}})
+		keys, err := ParseJWKS(data)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if len(keys) != 1 || keys[0].ID != "ec-1" {
+			t.Errorf("want only key ec-1, got: %+v", keys)
+		}
+	})
+
+	t.Run("rejects short rsa keys", func(t *testing.T) {
+		k, err := rsa.GenerateKey(rand.Reader, 1024)
+		if err != nil {
+			t.Fatalf("generating rsa key, error: %s", err)
+		}
+		if _, err := ParseJWKS(jwks(t, testKey{id: "short", alg: RS256, key: k})); err == nil {
+			t.Error("should have return an error")
+		}
+	})
+
+	t.Run("rejects points off the curve", func(t *testing.T) {
+		jwk := newECKey(t, "ec-1").jwk()
+		jwk["y"] = jwk["x"]
+		data, _ := json.Marshal(map[string]any{"keys": []any{jwk}})
+		if _, err := ParseJWKS(data); err == nil {
+			t.Error("should have return an error")
+		}
+	})
+}
+
+func TestLoadKeys(t *testing.T) {
+	k := newECKey(t, "ec-1")
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		if err := os.WriteFile(path, jwks(t, k), 0o600); err != nil {
+			t.Fatalf("writing jwks, error: %s", err)
+		}
+
+		keys, err := LoadKeys(path)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if _, err := keys.LookupKey(context.Background(), "ec-1"); err != nil {
+			t.Errorf("should have found key ec-1, got: %s", err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := LoadKeys(filepath.Join(t.TempDir(), "jwks.json")); err == nil {
+			t.Error("should have return an error")
+		}
+	})
+
+	t.Run("url", func(t *testing.T) {
+		keys, err := LoadKeys("https://auth.pedeai.test/.well-known/jwks.json")
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if _, ok := keys.(*RemoteKeys); !ok {
+			t.Errorf("want remote keys, got: %T", keys)
+		}
+	})
+}
+
+func TestRemoteKeys(t *testing.T) {
+	first, second := newRSAKey(t, "rsa-1"), newECKey(t, "ec-2")
+
+	var (
+		served  atomic.Value
+		fetches atomic.Int32
+	)
+	served.Store(jwks(t, first))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write(served.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	clock := now
+	rk := NewRemoteKeys(srv.URL)
+	rk.now = func() time.Time { return clock }
+	v := New(rk, Config{Issuer: testIssuer, Audience: testAudience})
+	v.now = func() time.Time { return now }
+
+	if _, err := v.Verify(context.Background(), sign(t, first, RS256, validClaims())); err != nil {
+		t.Fatalf("should not have return any error, got: %s", err)
+	}
+	if _, err := v.Verify(context.Background(), sign(t, first, RS256, validClaims())); err != nil {
+		t.Fatalf("should not have return any error, got: %s", err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("should have fetched the keys once, got: %d", n)
+	}
+
+	// the issuer rotates its key
+	served.Store(jwks(t, second))
+	rotated := sign(t, second, ES256, validClaims())
+
+	if _, err := v.Verify(context.Background(), rotated); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("should not have fetched the keys again so soon, got: %v", err)
+	}
+
+	clock = clock.Add(2 * minRefreshInterval)
+	if _, err := v.Verify(context.Background(), rotated); err != nil {
+		t.Errorf("should have fetched the rotated key, got: %s", err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("should have fetched the keys twice, got: %d", n)
+	}
+}
+
+func TestRemoteKeysOutage(t *testing.T) {
+	key := newRSAKey(t, "rsa-1")
+
+	var (
+		down    atomic.Bool
+		fetches atomic.Int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(jwks(t, key))
+	}))
+	defer srv.Close()
+
+	clock := now
+	rk := NewRemoteKeys(srv.URL)
+	rk.now = func() time.Time { return clock }
+
+	if _, err := rk.LookupKey(context.Background(), "rsa-1"); err != nil {
+		t.Fatalf("should not have return any error, got: %s", err)
+	}
+
+	// the keys expire while the issuer is down
+	down.Store(true)
+	clock = clock.Add(defaultKeysTTL + time.Second)
+	for range 3 {
+		if _, err := rk.LookupKey(context.Background(), "rsa-1"); err != nil {
+			t.Fatalf("should have kept the cached keys, got: %s", err)
+		}
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("should have backed off after the failed fetch, got %d fetches", n)
+	}
+
+	down.Store(false)
+	clock = clock.Add(minRefreshInterval)
+	if _, err := rk.LookupKey(context.Background(), "rsa-1"); err != nil {
+		t.Fatalf("should not have return any error, got: %s", err)
+	}
+	if n := fetches.Load(); n != 3 {
+		t.Errorf("should have fetched again after the backoff, got %d fetches", n)
+	}
+	if !rk.fetchedAt.Equal(clock) {
+		t.Errorf("should have refreshed the keys at %s, got: %s", clock, rk.fetchedAt)
+	}
+}
+
+func TestRemoteKeysSingleFlight(t *testing.T) {
+	key := newRSAKey(t, "rsa-1")
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		w.Write(jwks(t, key))
+	}))
+	defer srv.Close()
+
+	rk := NewRemoteKeys(srv.URL)
+
+	const lookups = 10
+	errs := make(chan error, lookups)
+	for range lookups {
+		go func() {
+			_, err := rk.LookupKey(context.Background(), "rsa-1")
+			errs <- err
+		}()
+	}
+
+	// a lookup waiting for the fetch gives up with its request
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := rk.LookupKey(ctx, "rsa-1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want: %s, got: %v", context.DeadlineExceeded, err)
+	}
+
+	close(release)
+	for range lookups {
+		if err := <-errs; err != nil {
+			t.Errorf("should not have return any error, got: %s", err)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("should have fetched the keys once, got: %d", n)
+	}
+}
@@ -0,0 +1,164 @@
+// Package email sends the notifications as plain text e-mails through an SMTP
+// server, upgrading the connection with STARTTLS when the server offers it.
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+const defaultTimeout = 10 * time.Second
+
+var errHeaderInjection = errors.New("header must not have line breaks")
+
+type Config struct {
+	// Addr is the host:port of the SMTP server.
+	Addr string
+	// Username and Password authenticate with PLAIN, which is only sent over
+	// TLS or to localhost. No authentication when Username is empty.
+	Username string
+	Password string
+	// From is the sender address, optionally with a display name.
+	From string
+	// Timeout bounds the delivery of each message, ten seconds when zero.
+	Timeout time.Duration
+}
+
+type Notifier struct {
+	cfg  Config
+	host string
+	from *mail.Address
+	now  func() time.Time
+}
+
+func New(cfg Config) (*Notifier, error) {
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("smtp address: %w", err)
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("sender address: %w", err)
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	return &Notifier{cfg: cfg, host: host, from: from, now: time.Now}, nil
+}
+
+func (n *Notifier) Notify(ctx context.Context, notification entities.Notification) error {
+	to, err := mail.ParseAddress(notification.To)
+	if err != nil {
+		return fmt.Errorf("recipient address: %w", err)
+	}
+	msg, err := n.message(to, notification)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("connecting to smtp server: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("greeting smtp server: %w", err)
+	}
+	defer c.Close()
+
+	if err := n.send(c, to, msg); err != nil {
+		return fmt.Errorf("sending e-mail: %w", err)
+	}
+
+	return nil
+}
+
+func (n *Notifier) send(c *smtp.Client, to *mail.Address, msg []byte) error {
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: n.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+	if n.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(n.from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// message is the RFC 5322 message of the notification, its body quoted
+// printable so any UTF-8 text goes through.
+func (n *Notifier) message(to *mail.Address, notification entities.Notification) ([]byte, error) {
+	if strings.ContainsAny(notification.Subject, "\r\n") {
+		return nil, fmt.Errorf("subject: %w", errHeaderInjection)
+	}
+
+	var buf bytes.Buffer
+	header := [][2]string{
+		{"From", n.from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", notification.Subject)},
+		{"Date", n.now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", `text/plain; charset="utf-8"`},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, h := range header {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h[0], h[1])
+	}
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(notification.Body)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
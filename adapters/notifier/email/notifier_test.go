@@ -0,0 +1,175 @@
+package email
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// fakeSMTP is a local SMTP server accepting one message per connection. It
+// rejects the recipients in reject.
+type fakeSMTP struct {
+	ln     net.Listener
+	reject string
+
+	commands []string
+	data     []byte
+	done     chan struct{}
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening, got error: %s", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeSMTP{ln: ln, done: make(chan struct{})}
+	go s.serve()
+
+	return s
+}
+
+func (s *fakeSMTP) serve() {
+	defer close(s.done)
+
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 fake ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		s.commands = append(s.commands, line)
+
+		verb := strings.ToUpper(strings.Fields(line)[0])
+		switch {
+		case verb == "EHLO":
+			tp.PrintfLine("250-fake")
+			tp.PrintfLine("250 AUTH PLAIN")
+		case verb == "AUTH":
+			tp.PrintfLine("235 authenticated")
+		case verb == "RCPT" && s.reject != "" && strings.Contains(line, s.reject):
+			tp.PrintfLine("550 no such user")
+		case verb == "DATA":
+			tp.PrintfLine("354 go ahead")
+			if s.data, err = tp.ReadDotBytes(); err != nil {
+				return
+			}
+			tp.PrintfLine("250 queued")
+		case verb == "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	notification := entities.Notification{
+		To:      "fulano@email.com",
+		Subject: "Seu código de verificação",
+		Body:    "Olá, Fulano!\n\nUse o código 123456.\n",
+	}
+
+	t.Run("send", func(t *testing.T) {
+		srv := newFakeSMTP(t)
+		n, err := New(Config{Addr: srv.ln.Addr().String(), Username: "user", Password: "pass", From: "PedeAí <noreply@pedeai.com>"})
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		if err := n.Notify(context.Background(), notification); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		<-srv.done
+
+		auth := "AUTH PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00user\x00pass"))
+		for _, want := range []string{auth, "MAIL FROM:<noreply@pedeai.com>", "RCPT TO:<fulano@email.com>", "QUIT"} {
+			if !slices.Contains(srv.commands, want) {
+				t.Errorf("should have sent %q, got: %q", want, srv.commands)
+			}
+		}
+
+		msg, err := mail.ReadMessage(strings.NewReader(string(srv.data)))
+		if err != nil {
+			t.Fatalf("parsing message, got error: %s", err)
+		}
+		subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+		if err != nil || subject != notification.Subject {
+			t.Errorf("want subject: %q, got: %q (%v)", notification.Subject, subject, err)
+		}
+		if to := msg.Header.Get("To"); to != "<fulano@email.com>" {
+			t.Errorf("want to: <fulano@email.com>, got: %q", to)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		if err != nil {
+			t.Fatalf("decoding body, got error: %s", err)
+		}
+		if got := strings.ReplaceAll(string(body), "\r\n", "\n"); got != notification.Body {
+			t.Errorf("want body: %q, got: %q", notification.Body, got)
+		}
+	})
+
+	t.Run("recipient rejected", func(t *testing.T) {
+		srv := newFakeSMTP(t)
+		srv.reject = "fulano@email.com"
+		n, err := New(Config{Addr: srv.ln.Addr().String(), From: "noreply@pedeai.com"})
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		var smtpErr *textproto.Error
+		if err := n.Notify(context.Background(), notification); !errors.As(err, &smtpErr) || smtpErr.Code != 550 {
+			t.Errorf("should have return the server error, got: %v", err)
+		}
+	})
+
+	t.Run("header injection", func(t *testing.T) {
+		n, err := New(Config{Addr: "127.0.0.1:25", From: "noreply@pedeai.com"})
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		bad := notification
+		bad.Subject = "Oi\r\nBcc: todos@email.com"
+		if err := n.Notify(context.Background(), bad); !errors.Is(err, errHeaderInjection) {
+			t.Errorf("should have return errHeaderInjection, got: %v", err)
+		}
+
+		bad = notification
+		bad.To = "fulano@email.com\r\nBcc: todos@email.com"
+		if err := n.Notify(context.Background(), bad); err == nil {
+			t.Error("should have rejected the recipient")
+		}
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		if _, err := New(Config{Addr: "smtp.email.com", From: "noreply@pedeai.com"}); err == nil {
+			t.Error("should have rejected an address without port")
+		}
+		if _, err := New(Config{Addr: "smtp.email.com:587", From: "pedeai"}); err == nil {
+			t.Error("should have rejected an invalid sender")
+		}
+	})
+}
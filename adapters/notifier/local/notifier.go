@@ -0,0 +1,27 @@
+// Package local logs the notifications instead of sending them, for
+// development.
+package local
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+type Notifier struct {
+	logger *slog.Logger
+}
+
+func New(logger *slog.Logger) *Notifier {
+	return &Notifier{logger: logger}
+}
+
+// Notify logs the whole message, verification codes included, so it must not
+// be used in production.
+func (n *Notifier) Notify(ctx context.Context, notification entities.Notification) error {
+	n.logger.InfoContext(ctx, "notification sent",
+		"to", notification.To, "subject", notification.Subject, "body", notification.Body)
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+func TestNotifier(t *testing.T) {
+	var out bytes.Buffer
+	n := New(slog.New(slog.NewTextHandler(&out, nil)))
+
+	err := n.Notify(context.Background(), entities.Notification{To: "fulano@email.com", Subject: "Código", Body: "123456"})
+	if err != nil {
+		t.Fatalf("should not have return any error, got: %s", err)
+	}
+	for _, want := range []string{"to=fulano@email.com", "subject=Código", "body=123456"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("should have logged %q, got: %s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// recordChange appends the audit entry for the change from before to after.
+// It must be called with the write lock held.
+func (r *Repository) recordChange(ctx context.Context, action entities.AuditAction, before, after *entities.Cliente) error {
+	entry := entities.NewAuditEntry(ctx, action, before, after)
+	entry.RecordedAt = r.now().UTC()
+
+	r.history[entry.ClienteID] = append(r.history[entry.ClienteID], entry)
+
+	return nil
+}
+
+// redact mirrors the RedactAuditEntries query. It must be called with the
+// write lock held.
+func (r *Repository) redact(id entities.ID) {
+	for i, entry := range r.history[id] {
+		entry.Changes = entities.RedactChanges(entry.Changes)
+		r.history[id][i] = entry
+	}
+}
+
+func (r *Repository) History(ctx context.Context, id entities.ID) ([]entities.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.history[id]), nil
+}
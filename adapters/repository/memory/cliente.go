@@ -0,0 +1,430 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+func (r *Repository) Create(ctx context.Context, cliente entities.Cliente) error {
+	if err := r.writable(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clientes[cliente.Id()]; ok {
+		return entityErr.ErrClienteAlreadyExistsForID
+	}
+	if err := r.checkUnique(cliente); err != nil {
+		return err
+	}
+
+	now := r.now().UTC()
+	stored, err := withAudit(cliente, now, now, time.Time{}, 1)
+	if err != nil {
+		return err
+	}
+
+	if err := r.recordChange(ctx, entities.AuditCreated, nil, &stored); err != nil {
+		return err
+	}
+	r.clientes[cliente.Id()] = record{cliente: stored, createdAt: now}
+
+	return nil
+}
+
+func (r *Repository) List(ctx context.Context, opts entities.ListOptions) (entities.ClientePage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var after *position
+	if opts.After != nil {
+		after = &position{id: opts.After.ID}
+		switch opts.Sort {
+		case entities.SortByCreated:
+			t, err := time.Parse(time.RFC3339Nano, opts.After.Key)
+			if err != nil {
+				return entities.ClientePage{}, fmt.Errorf("%w: malformed cursor", entityErr.ErrInvalidListOptions)
+			}
+			after.createdAt = t
+		case entities.SortByName:
+			after.name = opts.After.Key
+		default:
+			after.cpf = opts.After.Key
+		}
+	}
+
+	var records []record
+	for _, rec := range r.clientes {
+		if opts.Active != nil && rec.cliente.Active() != *opts.Active {
+			continue
+		}
+		if opts.NamePrefix != "" && !strings.HasPrefix(strings.ToLower(rec.cliente.Name()), strings.ToLower(opts.NamePrefix)) {
+			continue
+		}
+		if after != nil && compare(rec.position(), *after, opts.Sort) <= 0 {
+			continue
+		}
+		records = append(records, rec)
+	}
+
+	slices.SortFunc(records, func(a, b record) int {
+		return compare(a.position(), b.position(), opts.Sort)
+	})
+
+	var page entities.ClientePage
+	if len(records) > opts.Limit {
+		records = records[:opts.Limit]
+		last := records[len(records)-1]
+		page.Next = &entities.Cursor{Sort: opts.Sort, Key: sortKey(last, opts.Sort), ID: last.cliente.Id()}
+	}
+
+	for _, rec := range records {
+		c := rec.cliente
+		page.Clientes = append(page.Clientes, &c)
+	}
+
+	return page, nil
+}
+
+func (r *Repository) GetClienteById(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rec, ok := r.clientes[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+
+	c := rec.cliente
+	return &c, nil
+}
+
+func (r *Repository) GetClienteByCPF(ctx context.Context, cpf string) (*entities.Cliente, error) {
+	return r.find(func(c entities.Cliente) bool { return c.CPF() == cpf })
+}
+
+func (r *Repository) GetClienteByEmail(ctx context.Context, email string) (*entities.Cliente, error) {
+	return r.find(func(c entities.Cliente) bool { return strings.EqualFold(c.Email(), email) })
+}
+
+func (r *Repository) Update(ctx context.Context, cliente entities.Cliente) (*entities.Cliente, error) {
+	if err := r.writable(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, err := r.current(cliente)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkUnique(cliente); err != nil {
+		return nil, err
+	}
+
+	deletedAt := rec.cliente.DeletedAt()
+	if cliente.Active() {
+		deletedAt = time.Time{}
+	}
+	var verifiedAt time.Time
+	if strings.EqualFold(cliente.Email(), rec.cliente.Email()) {
+		verifiedAt = rec.cliente.EmailVerifiedAt()
+	}
+
+	stored, err := withAudit(cliente, rec.createdAt, r.now().UTC(), deletedAt, cliente.Version()+1,
+		entities.WithEmailVerified(verifiedAt))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.recordChange(ctx, entities.AuditUpdated, &rec.cliente, &stored); err != nil {
+		return nil, err
+	}
+	rec.cliente = stored
+	r.clientes[cliente.Id()] = rec
+
+	return &stored, nil
+}
+
+func (r *Repository) Anonymize(ctx context.Context, cliente entities.Cliente) (*entities.Cliente, error) {
+	if err := r.writable(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, err := r.current(cliente)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkUnique(cliente); err != nil {
+		return nil, err
+	}
+
+	stored, err := withAudit(cliente, rec.createdAt, r.now().UTC(), rec.cliente.DeletedAt(), cliente.Version()+1,
+		entities.WithAnonymization(cliente.AnonymizedAt(), cliente.AnonymizationReason()))
+	if err != nil {
+		return nil, err
+	}
+
+	r.redact(cliente.Id())
+	if err := r.redactEvents(cliente.Id()); err != nil {
+		return nil, err
+	}
+	if err := r.redactDeliveries(cliente.Id()); err != nil {
+		return nil, err
+	}
+	delete(r.verifications, cliente.Id())
+	if err := r.recordChange(ctx, entities.AuditAnonymized, &rec.cliente, &stored); err != nil {
+		return nil, err
+	}
+	rec.cliente = stored
+	r.clientes[cliente.Id()] = rec
+
+	return &stored, nil
+}
+
+// current returns the record cliente is meant to replace, checking it can
+// still be changed. It must be called with the write lock held.
+func (r *Repository) current(cliente entities.Cliente) (record, error) {
+	rec, ok := r.clientes[cliente.Id()]
+	if !ok {
+		return record{}, entityErr.ErrNotFound
+	}
+	if rec.cliente.Anonymized() {
+		return record{}, entityErr.ErrClienteAnonymized
+	}
+	if rec.cliente.Version() != cliente.Version() {
+		return record{}, entityErr.ErrConcurrentModification
+	}
+
+	return rec, nil
+}
+
+func (r *Repository) VerifyEmail(ctx context.Context, id entities.ID, email string, at time.Time) (*entities.Cliente, error) {
+	if err := r.writable(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.clientes[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	if rec.cliente.Anonymized() {
+		return nil, entityErr.ErrClienteAnonymized
+	}
+	if !strings.EqualFold(rec.cliente.Email(), email) {
+		return nil, entityErr.ErrConcurrentModification
+	}
+
+	stored, err := withAudit(rec.cliente, rec.createdAt, r.now().UTC(), rec.cliente.DeletedAt(), rec.cliente.Version()+1,
+		entities.WithEmailVerified(at))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.recordChange(ctx, entities.AuditUpdated, &rec.cliente, &stored); err != nil {
+		return nil, err
+	}
+	rec.cliente = stored
+	r.clientes[id] = rec
+
+	return &stored, nil
+}
+
+func (r *Repository) Deactivate(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	return r.setActive(ctx, id, false)
+}
+
+func (r *Repository) Reactivate(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	return r.setActive(ctx, id, true)
+}
+
+// setActive mirrors the DeactivateCliente and ReactivateCliente queries, an
+// already removed cliente keeps its first removal time.
+func (r *Repository) setActive(ctx context.Context, id entities.ID, active bool) (*entities.Cliente, error) {
+	if err := r.writable(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.clientes[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	if active && rec.cliente.Anonymized() {
+		return nil, entityErr.ErrClienteAnonymized
+	}
+
+	now := r.now().UTC()
+	var deletedAt time.Time
+	if !active {
+		deletedAt = rec.cliente.DeletedAt()
+		if deletedAt.IsZero() {
+			deletedAt = now
+		}
+	}
+
+	c := entities.Restore(id, rec.cliente.Name(), rec.cliente.CPF(), rec.cliente.Email(), active,
+		entities.WithTimestamps(rec.createdAt, now),
+		entities.WithDeletedAt(deletedAt),
+		entities.WithVersion(rec.cliente.Version()+1),
+		entities.WithAnonymization(rec.cliente.AnonymizedAt(), rec.cliente.AnonymizationReason()),
+		entities.WithEmailVerified(rec.cliente.EmailVerifiedAt()),
+	)
+
+	action := entities.AuditDeactivated
+	if active {
+		action = entities.AuditReactivated
+	}
+	if err := r.recordChange(ctx, action, &rec.cliente, c); err != nil {
+		return nil, err
+	}
+	rec.cliente = *c
+	r.clientes[id] = rec
+
+	return c, nil
+}
+
+func (r *Repository) Remove(ctx context.Context, id entities.ID) error {
+	if err := r.writable(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.clientes[id]
+	if !ok {
+		return entityErr.ErrNotFound
+	}
+
+	// the history outlives the cliente, as do the events and their webhook
+	// deliveries until pruned, without the personal data purged
+	r.redact(id)
+	if err := r.redactEvents(id); err != nil {
+		return err
+	}
+	if err := r.redactDeliveries(id); err != nil {
+		return err
+	}
+	if err := r.recordChange(ctx, entities.AuditDeleted, &rec.cliente, nil); err != nil {
+		return err
+	}
+	delete(r.clientes, id)
+	delete(r.consents, id)
+	delete(r.verifications, id)
+
+	return nil
+}
+
+func (r *Repository) find(match func(entities.Cliente) bool) (*entities.Cliente, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rec := range r.clientes {
+		if match(rec.cliente) {
+			c := rec.cliente
+			return &c, nil
+		}
+	}
+
+	return nil, entityErr.ErrNotFound
+}
+
+// checkUnique must be called with the write lock held.
+func (r *Repository) checkUnique(cliente entities.Cliente) error {
+	for id, rec := range r.clientes {
+		if id == cliente.Id() {
+			continue
+		}
+		if rec.cliente.CPF() == cliente.CPF() {
+			return entityErr.ErrClienteAlreadyExistsForCPF
+		}
+		if strings.EqualFold(rec.cliente.Email(), cliente.Email()) {
+			return entityErr.ErrClienteAlreadyExistsForEmail
+		}
+	}
+
+	return nil
+}
+
+// withAudit returns a copy of c carrying the bookkeeping kept by the
+// repository, opts add to it.
+func withAudit(c entities.Cliente, createdAt, updatedAt, deletedAt time.Time, version int, opts ...entities.Option) (entities.Cliente, error) {
+	opts = append([]entities.Option{
+		entities.WithTimestamps(createdAt, updatedAt),
+		entities.WithDeletedAt(deletedAt),
+		entities.WithVersion(version),
+	}, opts...)
+
+	stored, err := entities.New(c.Id(), c.Name(), c.CPF(), c.Email(), c.Active(), opts...)
+	if err != nil {
+		return entities.Cliente{}, err
+	}
+
+	return *stored, nil
+}
+
+// position is where a record sits in a listing, a cursor decodes to one.
+type position struct {
+	name      string
+	cpf       string
+	createdAt time.Time
+	id        entities.ID
+}
+
+func (rec record) position() position {
+	return position{
+		name:      rec.cliente.Name(),
+		cpf:       rec.cliente.CPF(),
+		createdAt: rec.createdAt,
+		id:        rec.cliente.Id(),
+	}
+}
+
+// compare orders positions like the PostgreSQL keyset queries: by the sort
+// field, then by id.
+func compare(a, b position, sort entities.SortField) int {
+	var c int
+	switch sort {
+	case entities.SortByName:
+		c = strings.Compare(a.name, b.name)
+	case entities.SortByCreated:
+		c = a.createdAt.Compare(b.createdAt)
+	default:
+		c = strings.Compare(a.cpf, b.cpf)
+	}
+	if c != 0 {
+		return c
+	}
+
+	return bytes.Compare(a.id[:], b.id[:])
+}
+
+func sortKey(rec record, sort entities.SortField) string {
+	switch sort {
+	case entities.SortByName:
+		return rec.cliente.Name()
+	case entities.SortByCreated:
+		return rec.createdAt.Format(time.RFC3339Nano)
+	default:
+		return rec.cliente.CPF()
+	}
+}
@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+func (r *Repository) AppendConsent(ctx context.Context, record entities.ConsentRecord) (entities.ConsentRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clientes[record.ClienteID]; !ok {
+		return entities.ConsentRecord{}, entityErr.ErrNotFound
+	}
+
+	record.RecordedAt = r.now().UTC()
+	r.consents[record.ClienteID] = append(r.consents[record.ClienteID], record)
+
+	return record, nil
+}
+
+func (r *Repository) ConsentHistory(ctx context.Context, clienteID entities.ID) ([]entities.ConsentRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.consents[clienteID]), nil
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+func (r *Repository) ReserveKey(ctx context.Context, rec entities.IdempotencyRecord) (*entities.IdempotencyRecord, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.idempotency[idempotencyKey{rec.Subject, rec.Key}]
+	if ok && stored.ExpiresAt.After(rec.CreatedAt) && (stored.Completed() || stored.LockedUntil.After(rec.CreatedAt)) {
+		return &stored, false, nil
+	}
+
+	rec.StatusCode, rec.Header, rec.Body = 0, nil, nil
+	r.idempotency[idempotencyKey{rec.Subject, rec.Key}] = rec
+
+	return &rec, true, nil
+}
+
+func (r *Repository) CompleteKey(ctx context.Context, rec entities.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.idempotency[idempotencyKey{rec.Subject, rec.Key}]
+	if !ok {
+		return entityErr.ErrNotFound
+	}
+
+	stored.StatusCode = rec.StatusCode
+	stored.Header = maps.Clone(rec.Header)
+	stored.Body = append([]byte(nil), rec.Body...)
+	r.idempotency[idempotencyKey{rec.Subject, rec.Key}] = stored
+
+	return nil
+}
+
+func (r *Repository) ReleaseKey(ctx context.Context, subject, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.idempotency, idempotencyKey{subject, key})
+
+	return nil
+}
+
+func (r *Repository) DeleteExpiredKeys(ctx context.Context, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	maps.DeleteFunc(r.idempotency, func(_ idempotencyKey, rec entities.IdempotencyRecord) bool {
+		return !rec.ExpiresAt.After(now)
+	})
+
+	return nil
+}
+
+// idempotencyKey identifies a key like the primary key of idempotency_keys.
+type idempotencyKey struct {
+	subject string
+	key     string
+}
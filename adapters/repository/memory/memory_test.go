@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/repository/repositorytest"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+)
+
+func TestRepository(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) ports.Repository {
+		return New()
+	})
+}
+
+func TestConsentRepository(t *testing.T) {
+	repositorytest.RunConsents(t, func(t *testing.T) repositorytest.ConsentRepository {
+		return New()
+	})
+}
+
+func TestOutbox(t *testing.T) {
+	repositorytest.RunOutbox(t, func(t *testing.T) repositorytest.OutboxRepository {
+		return New()
+	})
+}
+
+func TestWebhookRepository(t *testing.T) {
+	repositorytest.RunWebhooks(t, func(t *testing.T) repositorytest.WebhookRepository {
+		return New()
+	})
+}
+
+func TestIdempotencyStore(t *testing.T) {
+	repositorytest.RunIdempotency(t, func(t *testing.T) ports.IdempotencyStore {
+		return New()
+	})
+}
+
+func TestVerificationStore(t *testing.T) {
+	repositorytest.RunVerification(t, func(t *testing.T) repositorytest.VerificationRepository {
+		return New()
+	})
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+func (r *Repository) WriteEvent(ctx context.Context, event entities.Event) error {
+	if err := r.writable(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event.OccurredAt = r.now().UTC()
+	r.outbox = append(r.outbox, outboxEvent{event: event, pending: entities.EventSinks()})
+
+	return nil
+}
+
+// PublishPending lets one caller claim the events of a sink at a time, the
+// others find them all claimed. The repository is not locked while
+// publishing, so publish may use it.
+func (r *Repository) PublishPending(ctx context.Context, sink entities.EventSink, limit int, publish func(ctx context.Context, event entities.Event) error) (int, error) {
+	if !r.claimSink(sink) {
+		return 0, nil
+	}
+	defer r.releaseSink(sink)
+
+	var events []entities.Event
+	r.mu.RLock()
+	for _, e := range r.outbox {
+		if len(events) == limit {
+			break
+		}
+		if slices.Contains(e.pending, sink) {
+			events = append(events, e.event)
+		}
+	}
+	r.mu.RUnlock()
+
+	for i, event := range events {
+		if err := publish(ctx, event); err != nil {
+			return i, err
+		}
+		r.markPublished(event.ID, sink)
+	}
+
+	return len(events), nil
+}
+
+func (r *Repository) claimSink(sink entities.EventSink) bool {
+	r.claim.Lock()
+	defer r.claim.Unlock()
+
+	if r.claimed[sink] {
+		return false
+	}
+	if r.claimed == nil {
+		r.claimed = make(map[entities.EventSink]bool)
+	}
+	r.claimed[sink] = true
+
+	return true
+}
+
+func (r *Repository) releaseSink(sink entities.EventSink) {
+	r.claim.Lock()
+	defer r.claim.Unlock()
+
+	delete(r.claimed, sink)
+}
+
+func (r *Repository) markPublished(id entities.ID, sink entities.EventSink) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, e := range r.outbox {
+		if e.event.ID == id {
+			r.outbox[i].pending = slices.DeleteFunc(slices.Clone(e.pending), func(s entities.EventSink) bool {
+				return s == sink
+			})
+		}
+	}
+}
+
+func (r *Repository) DeletePublished(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := len(r.outbox)
+	r.outbox = slices.DeleteFunc(r.outbox, func(e outboxEvent) bool {
+		return len(e.pending) == 0 && e.event.OccurredAt.Before(before)
+	})
+
+	return n - len(r.outbox), nil
+}
+
+// redactEvents mirrors the RedactOutboxEvents query. It must be called with
+// the write lock held.
+func (r *Repository) redactEvents(id entities.ID) error {
+	for i, e := range r.outbox {
+		if e.event.ClienteID != id {
+			continue
+		}
+		payload, err := entities.RedactEventPayload(e.event.Payload)
+		if err != nil {
+			return err
+		}
+		r.outbox[i].event.Payload = payload
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+type record struct {
+	cliente   entities.Cliente
+	createdAt time.Time
+}
+
+// outboxEvent is an event of the outbox and the sinks it is still pending
+// for. pending is replaced, never changed in place, as transactions share it.
+type outboxEvent struct {
+	event   entities.Event
+	pending []entities.EventSink
+}
+
+// rwLocker is a sync.RWMutex, or noLock in a transaction.
+type rwLocker interface {
+	Lock()
+	Unlock()
+	RLock()
+	RUnlock()
+}
+
+// Repository keeps clientes in memory with the same semantics as the
+// PostgreSQL adapter. It is safe for concurrent use.
+type Repository struct {
+	mu          rwLocker
+	clientes    map[entities.ID]record
+	consents    map[entities.ID][]entities.ConsentRecord
+	history     map[entities.ID][]entities.AuditEntry
+	outbox      []outboxEvent
+	webhooks    map[entities.ID]entities.WebhookSubscription
+	deliveries  map[entities.ID]entities.WebhookDelivery
+	idempotency map[idempotencyKey]entities.IdempotencyRecord
+	// verifications has the last verification code of each cliente
+	verifications map[entities.ID]entities.VerificationCode
+	// claim guards claimed, the sinks a caller is publishing the outbox to
+	claim   sync.Mutex
+	claimed map[entities.EventSink]bool
+	// readOnly refuses the writes of a read-only transaction
+	readOnly bool
+	now      func() time.Time
+}
+
+func New() *Repository {
+	return &Repository{
+		mu:            &sync.RWMutex{},
+		clientes:      make(map[entities.ID]record),
+		consents:      make(map[entities.ID][]entities.ConsentRecord),
+		history:       make(map[entities.ID][]entities.AuditEntry),
+		webhooks:      make(map[entities.ID]entities.WebhookSubscription),
+		deliveries:    make(map[entities.ID]entities.WebhookDelivery),
+		idempotency:   make(map[idempotencyKey]entities.IdempotencyRecord),
+		verifications: make(map[entities.ID]entities.VerificationCode),
+		now:           time.Now,
+	}
+}
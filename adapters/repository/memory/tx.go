@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+)
+
+// errReadOnly is what writes fail with in a read-only transaction, like the
+// read_only_sql_transaction error of PostgreSQL.
+var errReadOnly = errors.New("cannot write in a read-only transaction")
+
+// noLock is the lock of a transaction, the repository it came from is
+// already locked for it.
+type noLock struct{}
+
+func (noLock) Lock()    {}
+func (noLock) Unlock()  {}
+func (noLock) RLock()   {}
+func (noLock) RUnlock() {}
+
+// WithinTx holds the write lock while fn runs on a copy of the repository,
+// kept only when fn succeeds. Transactions are therefore serializable
+// whatever the isolation, ReadOnly refuses their writes.
+func (r *Repository) WithinTx(ctx context.Context, fn func(repo ports.Repository) error, opts ...ports.TxOption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := r.snapshot()
+	// a nested transaction keeps the options of the outer one
+	if _, nested := r.mu.(noLock); !nested {
+		tx.readOnly = ports.NewTxOptions(opts...).ReadOnly
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	r.clientes = tx.clientes
+	r.consents = tx.consents
+	r.history = tx.history
+	r.outbox = tx.outbox
+	r.webhooks = tx.webhooks
+	r.deliveries = tx.deliveries
+	r.idempotency = tx.idempotency
+	r.verifications = tx.verifications
+
+	return nil
+}
+
+func (r *Repository) snapshot() *Repository {
+	tx := &Repository{
+		mu:            noLock{},
+		clientes:      maps.Clone(r.clientes),
+		consents:      make(map[entities.ID][]entities.ConsentRecord, len(r.consents)),
+		history:       make(map[entities.ID][]entities.AuditEntry, len(r.history)),
+		outbox:        slices.Clone(r.outbox),
+		webhooks:      maps.Clone(r.webhooks),
+		deliveries:    maps.Clone(r.deliveries),
+		idempotency:   maps.Clone(r.idempotency),
+		verifications: maps.Clone(r.verifications),
+		readOnly:      r.readOnly,
+		now:           r.now,
+	}
+	for id, records := range r.consents {
+		tx.consents[id] = slices.Clone(records)
+	}
+	// redact rewrites the entries in place
+	for id, entries := range r.history {
+		tx.history[id] = slices.Clone(entries)
+	}
+
+	return tx
+}
+
+// writable fails the writes of a read-only transaction.
+func (r *Repository) writable() error {
+	if r.readOnly {
+		return errReadOnly
+	}
+
+	return nil
+}
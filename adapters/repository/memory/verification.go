@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+func (r *Repository) SaveVerificationCode(ctx context.Context, code entities.VerificationCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clientes[code.ClienteID]; !ok {
+		return entityErr.ErrNotFound
+	}
+	r.verifications[code.ClienteID] = code
+
+	return nil
+}
+
+func (r *Repository) GetVerificationCode(ctx context.Context, clienteID entities.ID) (*entities.VerificationCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	code, ok := r.verifications[clienteID]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+
+	return &code, nil
+}
+
+func (r *Repository) AddVerificationAttempt(ctx context.Context, clienteID entities.ID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code, ok := r.verifications[clienteID]
+	if !ok {
+		return 0, entityErr.ErrNotFound
+	}
+	code.Attempts++
+	r.verifications[clienteID] = code
+
+	return code.Attempts, nil
+}
+
+func (r *Repository) DeleteVerificationCode(ctx context.Context, clienteID entities.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.verifications, clienteID)
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+func (r *Repository) CreateSubscription(ctx context.Context, sub entities.WebhookSubscription) (entities.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub.Events = slices.Clone(sub.Events)
+	sub.CreatedAt = r.now().UTC()
+	r.webhooks[sub.ID] = sub
+
+	return sub, nil
+}
+
+func (r *Repository) ListSubscriptions(ctx context.Context) ([]entities.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.SortedFunc(maps.Values(r.webhooks), func(a, b entities.WebhookSubscription) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), slices.Compare(a.ID[:], b.ID[:]))
+	}), nil
+}
+
+func (r *Repository) GetSubscription(ctx context.Context, id entities.ID) (*entities.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sub, ok := r.webhooks[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+
+	return &sub, nil
+}
+
+func (r *Repository) DeleteSubscription(ctx context.Context, id entities.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.webhooks[id]; !ok {
+		return entityErr.ErrNotFound
+	}
+
+	delete(r.webhooks, id)
+	maps.DeleteFunc(r.deliveries, func(_ entities.ID, d entities.WebhookDelivery) bool {
+		return d.SubscriptionID == id
+	})
+
+	return nil
+}
+
+func (r *Repository) EnqueueDelivery(ctx context.Context, d entities.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.webhooks[d.SubscriptionID]; !ok {
+		return entityErr.ErrNotFound
+	}
+	for _, other := range r.deliveries {
+		if other.SubscriptionID == d.SubscriptionID && other.EventID == d.EventID {
+			return nil
+		}
+	}
+
+	d.CreatedAt = r.now().UTC()
+	r.deliveries[d.ID] = d
+
+	return nil
+}
+
+func (r *Repository) ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]entities.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []entities.WebhookDelivery
+	for _, d := range r.deliveries {
+		if d.Status == entities.DeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	slices.SortFunc(due, func(a, b entities.WebhookDelivery) int {
+		return cmp.Or(a.NextAttemptAt.Compare(b.NextAttemptAt), slices.Compare(a.ID[:], b.ID[:]))
+	})
+
+	due = due[:min(limit, len(due))]
+	for i := range due {
+		due[i].NextAttemptAt = until
+		r.deliveries[due[i].ID] = due[i]
+	}
+
+	return due, nil
+}
+
+func (r *Repository) GetDelivery(ctx context.Context, id entities.ID) (*entities.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.deliveries[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+
+	return &d, nil
+}
+
+func (r *Repository) UpdateDelivery(ctx context.Context, d entities.WebhookDelivery, attempts int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.deliveries[d.ID]
+	if !ok {
+		return entityErr.ErrNotFound
+	}
+	if stored.Attempts != attempts {
+		return entityErr.ErrConcurrentModification
+	}
+
+	stored.Status = d.Status
+	stored.Attempts = d.Attempts
+	stored.NextAttemptAt = d.NextAttemptAt
+	stored.LastStatusCode = d.LastStatusCode
+	stored.LastError = d.LastError
+	stored.DeliveredAt = d.DeliveredAt
+	r.deliveries[d.ID] = stored
+
+	return nil
+}
+
+func (r *Repository) ListDeliveries(ctx context.Context, subscriptionID entities.ID, status entities.DeliveryStatus) ([]entities.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := []entities.WebhookDelivery{}
+	for _, d := range r.deliveries {
+		if d.SubscriptionID == subscriptionID && (status == "" || d.Status == status) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	slices.SortFunc(deliveries, func(a, b entities.WebhookDelivery) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), slices.Compare(a.ID[:], b.ID[:]))
+	})
+
+	return deliveries, nil
+}
+
+func (r *Repository) DeleteFinishedDeliveries(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := len(r.deliveries)
+	maps.DeleteFunc(r.deliveries, func(_ entities.ID, d entities.WebhookDelivery) bool {
+		return d.Status != entities.DeliveryPending && d.CreatedAt.Before(before)
+	})
+
+	return n - len(r.deliveries), nil
+}
+
+// redactDeliveries mirrors the RedactWebhookDeliveries query. It must be
+// called with the write lock held.
+func (r *Repository) redactDeliveries(id entities.ID) error {
+	for deliveryID, d := range r.deliveries {
+		payload, ok, err := entities.RedactDeliveryPayload(d.Payload, id)
+		if err != nil {
+			return err
+		}
+		if ok {
+			d.Payload = payload
+			r.deliveries[deliveryID] = d
+		}
+	}
+
+	return nil
+}
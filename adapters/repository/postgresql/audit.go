@@ -0,0 +1,75 @@
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/repository/postgresql/db"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// auditChange is how a FieldChange is kept in the changes jsonb column.
+type auditChange struct {
+	Field    string `json:"field"`
+	Before   string `json:"before,omitempty"`
+	After    string `json:"after,omitempty"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// recordChange appends the audit entry for the change from before to after.
+func recordChange(ctx context.Context, q *db.Queries, action entities.AuditAction, before, after *entities.Cliente) error {
+	entry := entities.NewAuditEntry(ctx, action, before, after)
+
+	changes := make([]auditChange, 0, len(entry.Changes))
+	for _, c := range entry.Changes {
+		changes = append(changes, auditChange(c))
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("encoding audit changes: %w", err)
+	}
+
+	err = q.CreateAuditEntry(ctx, db.CreateAuditEntryParams{
+		ClienteID: pgtype.UUID{Bytes: entry.ClienteID, Valid: true},
+		Action:    string(entry.Action),
+		Changes:   data,
+		Actor:     entry.Actor,
+		RequestID: entry.RequestID,
+	})
+	if err != nil {
+		return fmt.Errorf("recording %s audit of cliente %s: %w", action, entry.ClienteID, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) History(ctx context.Context, id entities.ID) ([]entities.AuditEntry, error) {
+	rows, err := r.db.ListAuditEntries(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("listing history of cliente %s: %w", id, err)
+	}
+
+	history := make([]entities.AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		var changes []auditChange
+		if err := json.Unmarshal(row.Changes, &changes); err != nil {
+			return nil, fmt.Errorf("decoding audit changes of cliente %s: %w", id, err)
+		}
+
+		entry := entities.AuditEntry{
+			ClienteID:  row.ClienteID.Bytes,
+			Action:     entities.AuditAction(row.Action),
+			Actor:      row.Actor,
+			RequestID:  row.RequestID,
+			RecordedAt: row.RecordedAt.Time,
+		}
+		for _, c := range changes {
+			entry.Changes = append(entry.Changes, entities.FieldChange(c))
+		}
+		history = append(history, entry)
+	}
+
+	return history, nil
+}
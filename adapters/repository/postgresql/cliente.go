@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/repository/postgresql/db"
 	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
@@ -12,52 +14,121 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func (r *Repository) Create(cliente entities.Cliente) error {
-	_, err := r.db.CreateCliente(
-		context.Background(),
-		db.CreateClienteParams{
-			ID:    pgtype.UUID{Bytes: cliente.Id(), Valid: true},
-			Nome:  pgtype.Text{String: cliente.Name(), Valid: true},
-			Cpf:   pgtype.Text{String: cliente.CPF(), Valid: true},
-			Email: pgtype.Text{String: cliente.Email(), Valid: true},
-			Ativo: cliente.Active(),
-		},
+func (r *Repository) Create(ctx context.Context, cliente entities.Cliente) error {
+	return r.inTx(ctx, func(q *db.Queries) error {
+		row, err := q.CreateCliente(
+			ctx,
+			db.CreateClienteParams{
+				ID:    pgtype.UUID{Bytes: cliente.Id(), Valid: true},
+				Nome:  pgtype.Text{String: cliente.Name(), Valid: true},
+				Cpf:   pgtype.Text{String: cliente.CPF(), Valid: true},
+				Email: pgtype.Text{String: cliente.Email(), Valid: true},
+				Ativo: cliente.Active(),
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("db creating cliente: %w", translateError(err))
+		}
+
+		return recordChange(ctx, q, entities.AuditCreated, nil, clienteFromDB(row))
+	})
+}
+
+func (r *Repository) List(ctx context.Context, opts entities.ListOptions) (entities.ClientePage, error) {
+	var (
+		active     pgtype.Bool
+		namePrefix pgtype.Text
+		afterID    pgtype.UUID
+		afterKey   string
 	)
-	if err != nil {
-		return fmt.Errorf("db creating cliente: %w", err)
+	if opts.Active != nil {
+		active = pgtype.Bool{Bool: *opts.Active, Valid: true}
+	}
+	if opts.NamePrefix != "" {
+		namePrefix = pgtype.Text{String: escapeLike(opts.NamePrefix), Valid: true}
+	}
+	if opts.After != nil {
+		afterID = pgtype.UUID{Bytes: opts.After.ID, Valid: true}
+		afterKey = opts.After.Key
 	}
 
-	return nil
-}
+	// one extra row tells whether there is a next page
+	rowLimit := int32(opts.Limit + 1)
 
-func (r *Repository) List() ([]*entities.Cliente, error) {
-	clientes, err := r.db.ListCliente(context.Background())
+	var (
+		clientes []db.Cliente
+		err      error
+	)
+	switch opts.Sort {
+	case entities.SortByName:
+		clientes, err = r.db.ListClientesByName(ctx, db.ListClientesByNameParams{
+			Active:     active,
+			NamePrefix: namePrefix,
+			AfterID:    afterID,
+			AfterKey:   pgtype.Text{String: afterKey, Valid: afterID.Valid},
+			RowLimit:   rowLimit,
+		})
+	case entities.SortByCreated:
+		var after pgtype.Timestamptz
+		if afterID.Valid {
+			t, perr := time.Parse(time.RFC3339Nano, afterKey)
+			if perr != nil {
+				return entities.ClientePage{}, fmt.Errorf("%w: malformed cursor", entityErr.ErrInvalidListOptions)
+			}
+			after = pgtype.Timestamptz{Time: t, Valid: true}
+		}
+		clientes, err = r.db.ListClientesByCreated(ctx, db.ListClientesByCreatedParams{
+			Active:     active,
+			NamePrefix: namePrefix,
+			AfterID:    afterID,
+			AfterKey:   after,
+			RowLimit:   rowLimit,
+		})
+	default:
+		clientes, err = r.db.ListClientesByCPF(ctx, db.ListClientesByCPFParams{
+			Active:     active,
+			NamePrefix: namePrefix,
+			AfterID:    afterID,
+			AfterKey:   pgtype.Text{String: afterKey, Valid: afterID.Valid},
+			RowLimit:   rowLimit,
+		})
+	}
 	if err != nil {
-		return nil, err
+		return entities.ClientePage{}, fmt.Errorf("db listing clientes: %w", err)
 	}
 
-	var clientesOut []*entities.Cliente
-	for _, cliente := range clientes {
-		c, err := entities.New(
-			cliente.ID.Bytes,
-			cliente.Nome.String,
-			cliente.Cpf.String,
-			cliente.Email.String,
-			cliente.Ativo,
-		)
-		if err != nil {
-			return nil, err
+	var page entities.ClientePage
+	for i, cliente := range clientes {
+		if i == opts.Limit {
+			last := clientes[i-1]
+			page.Next = &entities.Cursor{Sort: opts.Sort, Key: sortKey(last, opts.Sort), ID: last.ID.Bytes}
+			break
 		}
 
-		clientesOut = append(clientesOut, c)
+		page.Clientes = append(page.Clientes, clienteFromDB(cliente))
 	}
 
-	return clientesOut, nil
+	return page, nil
+}
 
+func sortKey(c db.Cliente, sort entities.SortField) string {
+	switch sort {
+	case entities.SortByName:
+		return c.Nome.String
+	case entities.SortByCreated:
+		return c.CreatedAt.Time.UTC().Format(time.RFC3339Nano)
+	default:
+		return c.Cpf.String
+	}
 }
 
-func (r *Repository) GetClienteById(id entities.ID) (*entities.Cliente, error) {
-	c, err := r.db.GetClienteById(context.Background(), pgtype.UUID{Bytes: id, Valid: true})
+// escapeLike makes the LIKE wildcards of s match literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (r *Repository) GetClienteById(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	c, err := r.db.GetClienteById(ctx, pgtype.UUID{Bytes: id, Valid: true})
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, entityErr.ErrNotFound
 	}
@@ -65,22 +136,23 @@ func (r *Repository) GetClienteById(id entities.ID) (*entities.Cliente, error) {
 		return nil, err
 	}
 
-	cOut, err := entities.New(
-		c.ID.Bytes,
-		c.Nome.String,
-		c.Cpf.String,
-		c.Email.String,
-		c.Ativo,
-	)
+	return clienteFromDB(c), nil
+}
+
+func (r *Repository) GetClienteByCPF(ctx context.Context, cpf string) (*entities.Cliente, error) {
+	c, err := r.db.GetClienteByCPF(ctx, pgtype.Text{String: cpf, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entityErr.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return cOut, nil
+	return clienteFromDB(c), nil
 }
 
-func (r *Repository) GetClienteByCPF(cpf string) (*entities.Cliente, error) {
-	c, err := r.db.GetClienteByCPF(context.Background(), pgtype.Text{String: cpf, Valid: true})
+func (r *Repository) GetClienteByEmail(ctx context.Context, email string) (*entities.Cliente, error) {
+	c, err := r.db.GetClienteByEmail(ctx, email)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, entityErr.ErrNotFound
 	}
@@ -88,63 +160,239 @@ func (r *Repository) GetClienteByCPF(cpf string) (*entities.Cliente, error) {
 		return nil, err
 	}
 
-	cOut, err := entities.New(
-		c.ID.Bytes,
-		c.Nome.String,
-		c.Cpf.String,
-		c.Email.String,
-		c.Ativo,
-	)
+	return clienteFromDB(c), nil
+}
+
+func (r *Repository) Update(ctx context.Context, cliente entities.Cliente) (*entities.Cliente, error) {
+	var updated *entities.Cliente
+	err := r.inTx(ctx, func(q *db.Queries) error {
+		before, err := lockCliente(ctx, q, cliente.Id())
+		if err != nil {
+			return err
+		}
+
+		row, err := q.UpdateCliente(ctx, db.UpdateClienteParams{
+			ID:      pgtype.UUID{Bytes: cliente.Id(), Valid: true},
+			Nome:    pgtype.Text{String: cliente.Name(), Valid: true},
+			Cpf:     pgtype.Text{String: cliente.CPF(), Valid: true},
+			Email:   pgtype.Text{String: cliente.Email(), Valid: true},
+			Ativo:   cliente.Active(),
+			Version: int32(cliente.Version()),
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notUpdated(before)
+		}
+		if err != nil {
+			return fmt.Errorf("updating cliente %s in dabatabse: %w", cliente.Id(), translateError(err))
+		}
+
+		updated = clienteFromDB(row)
+
+		return recordChange(ctx, q, entities.AuditUpdated, before, updated)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return cOut, nil
+	return updated, nil
 }
 
-func (r *Repository) GetClienteByEmail(email string) (*entities.Cliente, error) {
-	c, err := r.db.GetClienteByEmail(context.Background(), pgtype.Text{String: email, Valid: true})
-	if errors.Is(err, pgx.ErrNoRows) {
-		return nil, entityErr.ErrNotFound
-	}
+func (r *Repository) Anonymize(ctx context.Context, cliente entities.Cliente) (*entities.Cliente, error) {
+	var anonymized *entities.Cliente
+	err := r.inTx(ctx, func(q *db.Queries) error {
+		before, err := lockCliente(ctx, q, cliente.Id())
+		if err != nil {
+			return err
+		}
+
+		row, err := q.AnonymizeCliente(ctx, db.AnonymizeClienteParams{
+			ID:                  pgtype.UUID{Bytes: cliente.Id(), Valid: true},
+			Nome:                pgtype.Text{String: cliente.Name(), Valid: true},
+			Cpf:                 pgtype.Text{String: cliente.CPF(), Valid: true},
+			Email:               pgtype.Text{String: cliente.Email(), Valid: true},
+			AnonymizedAt:        pgtype.Timestamptz{Time: cliente.AnonymizedAt(), Valid: true},
+			AnonymizationReason: pgtype.Text{String: cliente.AnonymizationReason(), Valid: true},
+			Version:             int32(cliente.Version()),
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notUpdated(before)
+		}
+		if err != nil {
+			return fmt.Errorf("anonymizing cliente %s in database: %w", cliente.Id(), translateError(err))
+		}
+
+		// the history, the events, their webhook deliveries and the pending
+		// e-mail verification must not keep what the anonymization erased
+		if err := q.RedactAuditEntries(ctx, pgtype.UUID{Bytes: cliente.Id(), Valid: true}); err != nil {
+			return fmt.Errorf("redacting history of cliente %s: %w", cliente.Id(), err)
+		}
+		if err := q.RedactOutboxEvents(ctx, pgtype.UUID{Bytes: cliente.Id(), Valid: true}); err != nil {
+			return fmt.Errorf("redacting events of cliente %s: %w", cliente.Id(), err)
+		}
+		if err := q.RedactWebhookDeliveries(ctx, cliente.Id().String()); err != nil {
+			return fmt.Errorf("redacting webhook deliveries of cliente %s: %w", cliente.Id(), err)
+		}
+		if err := q.DeleteEmailVerification(ctx, pgtype.UUID{Bytes: cliente.Id(), Valid: true}); err != nil {
+			return fmt.Errorf("deleting e-mail verification of cliente %s: %w", cliente.Id(), err)
+		}
+
+		anonymized = clienteFromDB(row)
+
+		return recordChange(ctx, q, entities.AuditAnonymized, before, anonymized)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	cOut, err := entities.New(
-		c.ID.Bytes,
-		c.Nome.String,
-		c.Cpf.String,
-		c.Email.String,
-		c.Ativo,
-	)
+	return anonymized, nil
+}
+
+func (r *Repository) VerifyEmail(ctx context.Context, id entities.ID, email string, at time.Time) (*entities.Cliente, error) {
+	var verified *entities.Cliente
+	err := r.inTx(ctx, func(q *db.Queries) error {
+		before, err := lockCliente(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		row, err := q.VerifyClienteEmail(ctx, db.VerifyClienteEmailParams{
+			ID:         pgtype.UUID{Bytes: id, Valid: true},
+			VerifiedAt: timestamptz(at),
+			Email:      email,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			// anonymized, or the e-mail changed since the code was sent
+			return notUpdated(before)
+		}
+		if err != nil {
+			return fmt.Errorf("verifying e-mail of cliente %s in database: %w", id, err)
+		}
+
+		verified = clienteFromDB(row)
+
+		return recordChange(ctx, q, entities.AuditUpdated, before, verified)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return cOut, nil
+	return verified, nil
 }
 
-func (r *Repository) Update(cliente entities.Cliente) error {
-	err := r.db.UpdateCliente(context.Background(), db.UpdateClienteParams{
-		ID:    pgtype.UUID{Bytes: cliente.Id(), Valid: true},
-		Nome:  pgtype.Text{String: cliente.Name(), Valid: true},
-		Cpf:   pgtype.Text{String: cliente.CPF(), Valid: true},
-		Email: pgtype.Text{String: cliente.Email(), Valid: true},
-		Ativo: cliente.Active(),
+// lockCliente reads the cliente for update, so its audit entry is diffed
+// against the state the mutation replaces.
+func lockCliente(ctx context.Context, q *db.Queries, id entities.ID) (*entities.Cliente, error) {
+	c, err := q.GetClienteByIdForUpdate(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entityErr.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("locking cliente %s: %w", id, err)
+	}
+
+	return clienteFromDB(c), nil
+}
+
+// notUpdated tells why a conditional update of the locked cliente matched no
+// row: it was anonymized or its version moved on.
+func notUpdated(locked *entities.Cliente) error {
+	if locked.Anonymized() {
+		return entityErr.ErrClienteAnonymized
+	}
+
+	return entityErr.ErrConcurrentModification
+}
+
+func (r *Repository) Deactivate(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	var deactivated *entities.Cliente
+	err := r.inTx(ctx, func(q *db.Queries) error {
+		before, err := lockCliente(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		row, err := q.DeactivateCliente(ctx, pgtype.UUID{Bytes: id, Valid: true})
+		if err != nil {
+			return fmt.Errorf("deactivating cliente %s in database: %w", id, err)
+		}
+
+		deactivated = clienteFromDB(row)
+
+		return recordChange(ctx, q, entities.AuditDeactivated, before, deactivated)
 	})
 	if err != nil {
-		return fmt.Errorf("updating cliente %s in dabatabse: %w", cliente.Id(), err)
+		return nil, err
 	}
 
-	return nil
+	return deactivated, nil
 }
 
-func (r *Repository) Remove(id entities.ID) error {
-	err := r.db.DeleteCliente(context.Background(), pgtype.UUID{Bytes: id, Valid: true})
+func (r *Repository) Reactivate(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	var reactivated *entities.Cliente
+	err := r.inTx(ctx, func(q *db.Queries) error {
+		before, err := lockCliente(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		row, err := q.ReactivateCliente(ctx, pgtype.UUID{Bytes: id, Valid: true})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return notUpdated(before)
+		}
+		if err != nil {
+			return fmt.Errorf("reactivating cliente %s in database: %w", id, err)
+		}
+
+		reactivated = clienteFromDB(row)
+
+		return recordChange(ctx, q, entities.AuditReactivated, before, reactivated)
+	})
 	if err != nil {
-		return fmt.Errorf("removing cliente %s in database: %w", id, err)
+		return nil, err
 	}
 
-	return nil
+	return reactivated, nil
+}
+
+func (r *Repository) Remove(ctx context.Context, id entities.ID) error {
+	return r.inTx(ctx, func(q *db.Queries) error {
+		before, err := lockCliente(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.DeleteCliente(ctx, pgtype.UUID{Bytes: id, Valid: true}); err != nil {
+			return fmt.Errorf("removing cliente %s in database: %w", id, err)
+		}
+
+		// the history outlives the cliente, as do the events and their webhook
+		// deliveries until pruned, without the personal data purged
+		if err := q.RedactAuditEntries(ctx, pgtype.UUID{Bytes: id, Valid: true}); err != nil {
+			return fmt.Errorf("redacting history of cliente %s: %w", id, err)
+		}
+		if err := q.RedactOutboxEvents(ctx, pgtype.UUID{Bytes: id, Valid: true}); err != nil {
+			return fmt.Errorf("redacting events of cliente %s: %w", id, err)
+		}
+		if err := q.RedactWebhookDeliveries(ctx, id.String()); err != nil {
+			return fmt.Errorf("redacting webhook deliveries of cliente %s: %w", id, err)
+		}
+
+		return recordChange(ctx, q, entities.AuditDeleted, before, nil)
+	})
+}
+
+// clienteFromDB restores the stored row as it is, see entities.Restore.
+func clienteFromDB(c db.Cliente) *entities.Cliente {
+	return entities.Restore(
+		c.ID.Bytes,
+		c.Nome.String,
+		c.Cpf.String,
+		c.Email.String,
+		c.Ativo,
+		entities.WithTimestamps(c.CreatedAt.Time, c.UpdatedAt.Time),
+		entities.WithVersion(int(c.Version)),
+		entities.WithDeletedAt(c.DeletedAt.Time),
+		entities.WithAnonymization(c.AnonymizedAt.Time, c.AnonymizationReason.String),
+		entities.WithEmailVerified(c.EmailVerifiedAt.Time),
+	)
 }
@@ -0,0 +1,50 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/repository/postgresql/db"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (r *Repository) AppendConsent(ctx context.Context, record entities.ConsentRecord) (entities.ConsentRecord, error) {
+	c, err := r.db.CreateConsent(ctx, db.CreateConsentParams{
+		ClienteID:     pgtype.UUID{Bytes: record.ClienteID, Valid: true},
+		Purpose:       string(record.Purpose),
+		Granted:       record.Granted,
+		Channel:       string(record.Channel),
+		PolicyVersion: record.PolicyVersion,
+	})
+	if err != nil {
+		return entities.ConsentRecord{}, fmt.Errorf("appending consent of cliente %s: %w", record.ClienteID, translateError(err))
+	}
+
+	return consentFromDB(c), nil
+}
+
+func (r *Repository) ConsentHistory(ctx context.Context, clienteID entities.ID) ([]entities.ConsentRecord, error) {
+	consents, err := r.db.ListConsents(ctx, pgtype.UUID{Bytes: clienteID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("listing consents of cliente %s: %w", clienteID, err)
+	}
+
+	history := make([]entities.ConsentRecord, 0, len(consents))
+	for _, c := range consents {
+		history = append(history, consentFromDB(c))
+	}
+
+	return history, nil
+}
+
+func consentFromDB(c db.Consent) entities.ConsentRecord {
+	return entities.ConsentRecord{
+		ClienteID:     c.ClienteID.Bytes,
+		Purpose:       entities.ConsentPurpose(c.Purpose),
+		Granted:       c.Granted,
+		Channel:       entities.ConsentChannel(c.Channel),
+		PolicyVersion: c.PolicyVersion,
+		RecordedAt:    c.RecordedAt.Time,
+	}
+}
@@ -9,9 +9,94 @@ import (
 )
 
 type Cliente struct {
-	Ativo bool
-	ID    pgtype.UUID
-	Cpf   pgtype.Text
-	Email pgtype.Text
-	Nome  pgtype.Text
+	Ativo               bool
+	ID                  pgtype.UUID
+	Cpf                 pgtype.Text
+	Email               pgtype.Text
+	Nome                pgtype.Text
+	CreatedAt           pgtype.Timestamptz
+	UpdatedAt           pgtype.Timestamptz
+	Version             int32
+	DeletedAt           pgtype.Timestamptz
+	AnonymizedAt        pgtype.Timestamptz
+	AnonymizationReason pgtype.Text
+	EmailVerifiedAt     pgtype.Timestamptz
+}
+
+type Consent struct {
+	ID            int64
+	ClienteID     pgtype.UUID
+	Purpose       string
+	Granted       bool
+	Channel       string
+	PolicyVersion string
+	RecordedAt    pgtype.Timestamptz
+}
+
+type ClienteAudit struct {
+	ID         int64
+	ClienteID  pgtype.UUID
+	Action     string
+	Changes    []byte
+	Actor      string
+	RequestID  string
+	RecordedAt pgtype.Timestamptz
+}
+
+type Outbox struct {
+	Seq        int64
+	ID         pgtype.UUID
+	Type       string
+	ClienteID  pgtype.UUID
+	Payload    []byte
+	OccurredAt pgtype.Timestamptz
+}
+
+type OutboxPending struct {
+	Seq  int64
+	Sink string
+}
+
+type WebhookSubscription struct {
+	ID        pgtype.UUID
+	Url       string
+	Events    []string
+	Secret    string
+	CreatedAt pgtype.Timestamptz
+}
+
+type WebhookDelivery struct {
+	ID             pgtype.UUID
+	SubscriptionID pgtype.UUID
+	EventID        pgtype.UUID
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int32
+	NextAttemptAt  pgtype.Timestamptz
+	LastStatusCode int32
+	LastError      string
+	CreatedAt      pgtype.Timestamptz
+	DeliveredAt    pgtype.Timestamptz
+}
+
+type IdempotencyKey struct {
+	Key         string
+	RequestHash string
+	StatusCode  int32
+	Header      []byte
+	Body        []byte
+	CreatedAt   pgtype.Timestamptz
+	ExpiresAt   pgtype.Timestamptz
+	LockedUntil pgtype.Timestamptz
+	Subject     string
+}
+
+type EmailVerification struct {
+	ClienteID pgtype.UUID
+	Email     string
+	CodeHash  string
+	Attempts  int32
+	CreatedAt pgtype.Timestamptz
+	ExpiresAt pgtype.Timestamptz
 }
@@ -11,11 +11,223 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addEmailVerificationAttempt = `-- name: AddEmailVerificationAttempt :one
+UPDATE email_verifications SET attempts = attempts + 1
+WHERE cliente_id = $1
+RETURNING attempts
+`
+
+func (q *Queries) AddEmailVerificationAttempt(ctx context.Context, clienteID pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, addEmailVerificationAttempt, clienteID)
+	var attempts int32
+	err := row.Scan(&attempts)
+	return attempts, err
+}
+
+const anonymizeCliente = `-- name: AnonymizeCliente :one
+UPDATE clientes SET
+(nome, cpf, email, ativo, email_verified_at, anonymized_at, anonymization_reason, updated_at, version) = ($2, $3, $4, false, NULL, $5, $6, now(), version + 1)
+WHERE id = $1 AND version = $7 AND anonymized_at IS NULL
+RETURNING ativo, id, cpf, email, nome, created_at, updated_at, version, deleted_at, anonymized_at, anonymization_reason, email_verified_at
+`
+
+type AnonymizeClienteParams struct {
+	ID                  pgtype.UUID
+	Nome                pgtype.Text
+	Cpf                 pgtype.Text
+	Email               pgtype.Text
+	AnonymizedAt        pgtype.Timestamptz
+	AnonymizationReason pgtype.Text
+	Version             int32
+}
+
+func (q *Queries) AnonymizeCliente(ctx context.Context, arg AnonymizeClienteParams) (Cliente, error) {
+	row := q.db.QueryRow(ctx, anonymizeCliente,
+		arg.ID,
+		arg.Nome,
+		arg.Cpf,
+		arg.Email,
+		arg.AnonymizedAt,
+		arg.AnonymizationReason,
+		arg.Version,
+	)
+	var i Cliente
+	err := row.Scan(
+		&i.Ativo,
+		&i.ID,
+		&i.Cpf,
+		&i.Email,
+		&i.Nome,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.AnonymizationReason,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const claimDueWebhookDeliveries = `-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries SET next_attempt_at = $1
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= $2
+    ORDER BY next_attempt_at, id
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at
+`
+
+type ClaimDueWebhookDeliveriesParams struct {
+	Until    pgtype.Timestamptz
+	Now      pgtype.Timestamptz
+	RowLimit int32
+}
+
+func (q *Queries) ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, claimDueWebhookDeliveries,
+		arg.Until,
+		arg.Now,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimPendingOutboxEvents = `-- name: ClaimPendingOutboxEvents :many
+SELECT o.seq, o.id, o.type, o.cliente_id, o.payload, o.occurred_at FROM outbox_pending p JOIN outbox o ON o.seq = p.seq
+WHERE p.sink = $1
+ORDER BY p.seq
+LIMIT $2
+FOR UPDATE OF p SKIP LOCKED
+`
+
+type ClaimPendingOutboxEventsParams struct {
+	Sink     string
+	RowLimit int32
+}
+
+func (q *Queries) ClaimPendingOutboxEvents(ctx context.Context, arg ClaimPendingOutboxEventsParams) ([]Outbox, error) {
+	rows, err := q.db.Query(ctx, claimPendingOutboxEvents,
+		arg.Sink,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Outbox
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.Seq,
+			&i.ID,
+			&i.Type,
+			&i.ClienteID,
+			&i.Payload,
+			&i.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :execrows
+UPDATE idempotency_keys SET
+(status_code, header, body) = ($3, $4, $5)
+WHERE subject = $1 AND key = $2
+`
+
+type CompleteIdempotencyKeyParams struct {
+	Subject    string
+	Key        string
+	StatusCode int32
+	Header     []byte
+	Body       []byte
+}
+
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, completeIdempotencyKey,
+		arg.Subject,
+		arg.Key,
+		arg.StatusCode,
+		arg.Header,
+		arg.Body,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createAuditEntry = `-- name: CreateAuditEntry :exec
+
+INSERT INTO cliente_audit
+(cliente_id, action, changes, actor, request_id)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateAuditEntryParams struct {
+	ClienteID pgtype.UUID
+	Action    string
+	Changes   []byte
+	Actor     string
+	RequestID string
+}
+
+// ----------------------------------------------
+// Audit
+func (q *Queries) CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) error {
+	_, err := q.db.Exec(ctx, createAuditEntry,
+		arg.ClienteID,
+		arg.Action,
+		arg.Changes,
+		arg.Actor,
+		arg.RequestID,
+	)
+	return err
+}
+
 const createCliente = `-- name: CreateCliente :one
 INSERT INTO  clientes
 (id, nome, cpf, email, ativo)
 VALUES ($1, $2, $3, $4, $5)
-RETURNING ativo, id, cpf, email, nome
+RETURNING ativo, id, cpf, email, nome, created_at, updated_at, version, deleted_at, anonymized_at, anonymization_reason, email_verified_at
 `
 
 type CreateClienteParams struct {
@@ -41,6 +253,189 @@ func (q *Queries) CreateCliente(ctx context.Context, arg CreateClienteParams) (C
 		&i.Cpf,
 		&i.Email,
 		&i.Nome,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.AnonymizationReason,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const createConsent = `-- name: CreateConsent :one
+
+INSERT INTO consents
+(cliente_id, purpose, granted, channel, policy_version)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, cliente_id, purpose, granted, channel, policy_version, recorded_at
+`
+
+type CreateConsentParams struct {
+	ClienteID     pgtype.UUID
+	Purpose       string
+	Granted       bool
+	Channel       string
+	PolicyVersion string
+}
+
+// ----------------------------------------------
+// Consents
+func (q *Queries) CreateConsent(ctx context.Context, arg CreateConsentParams) (Consent, error) {
+	row := q.db.QueryRow(ctx, createConsent,
+		arg.ClienteID,
+		arg.Purpose,
+		arg.Granted,
+		arg.Channel,
+		arg.PolicyVersion,
+	)
+	var i Consent
+	err := row.Scan(
+		&i.ID,
+		&i.ClienteID,
+		&i.Purpose,
+		&i.Granted,
+		&i.Channel,
+		&i.PolicyVersion,
+		&i.RecordedAt,
+	)
+	return i, err
+}
+
+const createOutboxEvent = `-- name: CreateOutboxEvent :exec
+
+INSERT INTO outbox
+(id, type, cliente_id, payload)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateOutboxEventParams struct {
+	ID        pgtype.UUID
+	Type      string
+	ClienteID pgtype.UUID
+	Payload   []byte
+}
+
+// ----------------------------------------------
+// Outbox
+func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) error {
+	_, err := q.db.Exec(ctx, createOutboxEvent,
+		arg.ID,
+		arg.Type,
+		arg.ClienteID,
+		arg.Payload,
+	)
+	return err
+}
+
+const createOutboxPending = `-- name: CreateOutboxPending :exec
+INSERT INTO outbox_pending
+(seq, sink)
+SELECT seq, unnest($1::text[]) FROM outbox WHERE id = $2
+`
+
+type CreateOutboxPendingParams struct {
+	Sinks []string
+	ID    pgtype.UUID
+}
+
+func (q *Queries) CreateOutboxPending(ctx context.Context, arg CreateOutboxPendingParams) error {
+	_, err := q.db.Exec(ctx, createOutboxPending,
+		arg.Sinks,
+		arg.ID,
+	)
+	return err
+}
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries
+(id, subscription_id, event_id, event_type, payload, status, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (subscription_id, event_id) DO NOTHING
+`
+
+type CreateWebhookDeliveryParams struct {
+	ID             pgtype.UUID
+	SubscriptionID pgtype.UUID
+	EventID        pgtype.UUID
+	EventType      string
+	Payload        []byte
+	Status         string
+	NextAttemptAt  pgtype.Timestamptz
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error {
+	_, err := q.db.Exec(ctx, createWebhookDelivery,
+		arg.ID,
+		arg.SubscriptionID,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+		arg.Status,
+		arg.NextAttemptAt,
+	)
+	return err
+}
+
+const createWebhookSubscription = `-- name: CreateWebhookSubscription :one
+
+INSERT INTO webhook_subscriptions
+(id, url, events, secret)
+VALUES ($1, $2, $3, $4)
+RETURNING id, url, events, secret, created_at
+`
+
+type CreateWebhookSubscriptionParams struct {
+	ID     pgtype.UUID
+	Url    string
+	Events []string
+	Secret string
+}
+
+// ----------------------------------------------
+// Webhooks
+func (q *Queries) CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error) {
+	row := q.db.QueryRow(ctx, createWebhookSubscription,
+		arg.ID,
+		arg.Url,
+		arg.Events,
+		arg.Secret,
+	)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Events,
+		&i.Secret,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deactivateCliente = `-- name: DeactivateCliente :one
+UPDATE clientes SET
+(ativo, deleted_at, updated_at, version) = (false, coalesce(deleted_at, now()), now(), version + 1)
+WHERE id = $1
+RETURNING ativo, id, cpf, email, nome, created_at, updated_at, version, deleted_at, anonymized_at, anonymization_reason, email_verified_at
+`
+
+func (q *Queries) DeactivateCliente(ctx context.Context, id pgtype.UUID) (Cliente, error) {
+	row := q.db.QueryRow(ctx, deactivateCliente, id)
+	var i Cliente
+	err := row.Scan(
+		&i.Ativo,
+		&i.ID,
+		&i.Cpf,
+		&i.Email,
+		&i.Nome,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.AnonymizationReason,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -54,17 +449,121 @@ func (q *Queries) DeleteAllCliente(ctx context.Context) error {
 	return err
 }
 
-const deleteCliente = `-- name: DeleteCliente :exec
+const deleteAllIdempotencyKeys = `-- name: DeleteAllIdempotencyKeys :exec
+DELETE FROM idempotency_keys
+`
+
+func (q *Queries) DeleteAllIdempotencyKeys(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteAllIdempotencyKeys)
+	return err
+}
+
+const deleteAllOutboxEvents = `-- name: DeleteAllOutboxEvents :exec
+DELETE FROM outbox
+`
+
+func (q *Queries) DeleteAllOutboxEvents(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteAllOutboxEvents)
+	return err
+}
+
+const deleteAllWebhookSubscriptions = `-- name: DeleteAllWebhookSubscriptions :exec
+DELETE FROM webhook_subscriptions
+`
+
+func (q *Queries) DeleteAllWebhookSubscriptions(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteAllWebhookSubscriptions)
+	return err
+}
+
+const deleteCliente = `-- name: DeleteCliente :execrows
 DELETE FROM clientes WHERE id = $1
 `
 
-func (q *Queries) DeleteCliente(ctx context.Context, id pgtype.UUID) error {
-	_, err := q.db.Exec(ctx, deleteCliente, id)
+func (q *Queries) DeleteCliente(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteCliente, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteEmailVerification = `-- name: DeleteEmailVerification :exec
+DELETE FROM email_verifications WHERE cliente_id = $1
+`
+
+func (q *Queries) DeleteEmailVerification(ctx context.Context, clienteID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteEmailVerification, clienteID)
+	return err
+}
+
+const deleteExpiredIdempotencyKeys = `-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys WHERE expires_at <= $1
+`
+
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context, now pgtype.Timestamptz) error {
+	_, err := q.db.Exec(ctx, deleteExpiredIdempotencyKeys, now)
+	return err
+}
+
+const deleteFinishedWebhookDeliveries = `-- name: DeleteFinishedWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE status <> 'pending' AND created_at < $1
+`
+
+func (q *Queries) DeleteFinishedWebhookDeliveries(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteFinishedWebhookDeliveries, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteIdempotencyKey = `-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE subject = $1 AND key = $2
+`
+
+type DeleteIdempotencyKeyParams struct {
+	Subject string
+	Key     string
+}
+
+func (q *Queries) DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, deleteIdempotencyKey,
+		arg.Subject,
+		arg.Key,
+	)
 	return err
 }
 
+const deletePublishedOutboxEvents = `-- name: DeletePublishedOutboxEvents :execrows
+DELETE FROM outbox o
+WHERE occurred_at < $1
+  AND NOT EXISTS (SELECT 1 FROM outbox_pending p WHERE p.seq = o.seq)
+`
+
+func (q *Queries) DeletePublishedOutboxEvents(ctx context.Context, occurredAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePublishedOutboxEvents, occurredAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteWebhookSubscription = `-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions WHERE id = $1
+`
+
+func (q *Queries) DeleteWebhookSubscription(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebhookSubscription, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getClienteByCPF = `-- name: GetClienteByCPF :one
-SELECT ativo, id, cpf, email, nome FROM clientes WHERE cpf = $1 LIMIT 1
+SELECT ativo, id, cpf, email, nome, created_at, updated_at, version, deleted_at, anonymized_at, anonymization_reason, email_verified_at FROM clientes WHERE cpf = $1 LIMIT 1
 `
 
 func (q *Queries) GetClienteByCPF(ctx context.Context, cpf pgtype.Text) (Cliente, error) {
@@ -76,15 +575,22 @@ func (q *Queries) GetClienteByCPF(ctx context.Context, cpf pgtype.Text) (Cliente
 		&i.Cpf,
 		&i.Email,
 		&i.Nome,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.AnonymizationReason,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getClienteByEmail = `-- name: GetClienteByEmail :one
-SELECT ativo, id, cpf, email, nome FROM clientes WHERE email = $1 LIMIT 1
+SELECT ativo, id, cpf, email, nome, created_at, updated_at, version, deleted_at, anonymized_at, anonymization_reason, email_verified_at FROM clientes WHERE lower(email) = lower($1::text) LIMIT 1
 `
 
-func (q *Queries) GetClienteByEmail(ctx context.Context, email pgtype.Text) (Cliente, error) {
+func (q *Queries) GetClienteByEmail(ctx context.Context, email string) (Cliente, error) {
 	row := q.db.QueryRow(ctx, getClienteByEmail, email)
 	var i Cliente
 	err := row.Scan(
@@ -93,13 +599,20 @@ func (q *Queries) GetClienteByEmail(ctx context.Context, email pgtype.Text) (Cli
 		&i.Cpf,
 		&i.Email,
 		&i.Nome,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.AnonymizationReason,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getClienteById = `-- name: GetClienteById :one
 
-SELECT ativo, id, cpf, email, nome FROM clientes WHERE id = $1 LIMIT 1
+SELECT ativo, id, cpf, email, nome, created_at, updated_at, version, deleted_at, anonymized_at, anonymization_reason, email_verified_at FROM clientes WHERE id = $1 LIMIT 1
 `
 
 // ----------------------------------------------
@@ -113,61 +626,706 @@ func (q *Queries) GetClienteById(ctx context.Context, id pgtype.UUID) (Cliente,
 		&i.Cpf,
 		&i.Email,
 		&i.Nome,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.AnonymizationReason,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
-const listCliente = `-- name: ListCliente :many
-SELECT ativo, id, cpf, email, nome FROM clientes ORDER BY cpf
+const getClienteByIdForUpdate = `-- name: GetClienteByIdForUpdate :one
+SELECT ativo, id, cpf, email, nome, created_at, updated_at, version, deleted_at, anonymized_at, anonymization_reason, email_verified_at FROM clientes WHERE id = $1 LIMIT 1 FOR UPDATE
 `
 
-func (q *Queries) ListCliente(ctx context.Context) ([]Cliente, error) {
-	rows, err := q.db.Query(ctx, listCliente)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Cliente
-	for rows.Next() {
-		var i Cliente
-		if err := rows.Scan(
-			&i.Ativo,
-			&i.ID,
-			&i.Cpf,
-			&i.Email,
-			&i.Nome,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) GetClienteByIdForUpdate(ctx context.Context, id pgtype.UUID) (Cliente, error) {
+	row := q.db.QueryRow(ctx, getClienteByIdForUpdate, id)
+	var i Cliente
+	err := row.Scan(
+		&i.Ativo,
+		&i.ID,
+		&i.Cpf,
+		&i.Email,
+		&i.Nome,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.AnonymizationReason,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
 }
 
-const updateCliente = `-- name: UpdateCliente :exec
-UPDATE clientes SET
-(nome, cpf, email, ativo) = ($2, $3, $4, $5)
-WHERE id = $1
+const getEmailVerification = `-- name: GetEmailVerification :one
+SELECT cliente_id, email, code_hash, attempts, created_at, expires_at FROM email_verifications WHERE cliente_id = $1
 `
 
-type UpdateClienteParams struct {
-	ID    pgtype.UUID
-	Nome  pgtype.Text
-	Cpf   pgtype.Text
-	Email pgtype.Text
-	Ativo bool
+func (q *Queries) GetEmailVerification(ctx context.Context, clienteID pgtype.UUID) (EmailVerification, error) {
+	row := q.db.QueryRow(ctx, getEmailVerification, clienteID)
+	var i EmailVerification
+	err := row.Scan(
+		&i.ClienteID,
+		&i.Email,
+		&i.CodeHash,
+		&i.Attempts,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT key, request_hash, status_code, header, body, created_at, expires_at, locked_until, subject FROM idempotency_keys WHERE subject = $1 AND key = $2
+`
+
+type GetIdempotencyKeyParams struct {
+	Subject string
+	Key     string
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, getIdempotencyKey,
+		arg.Subject,
+		arg.Key,
+	)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Key,
+		&i.RequestHash,
+		&i.StatusCode,
+		&i.Header,
+		&i.Body,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LockedUntil,
+		&i.Subject,
+	)
+	return i, err
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at FROM webhook_deliveries WHERE id = $1
+`
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id pgtype.UUID) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, getWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
+	return i, err
+}
+
+const getWebhookSubscription = `-- name: GetWebhookSubscription :one
+SELECT id, url, events, secret, created_at FROM webhook_subscriptions WHERE id = $1
+`
+
+func (q *Queries) GetWebhookSubscription(ctx context.Context, id pgtype.UUID) (WebhookSubscription, error) {
+	row := q.db.QueryRow(ctx, getWebhookSubscription, id)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Events,
+		&i.Secret,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAuditEntries = `-- name: ListAuditEntries :many
+SELECT id, cliente_id, action, changes, actor, request_id, recorded_at FROM cliente_audit WHERE cliente_id = $1 ORDER BY id
+`
+
+func (q *Queries) ListAuditEntries(ctx context.Context, clienteID pgtype.UUID) ([]ClienteAudit, error) {
+	rows, err := q.db.Query(ctx, listAuditEntries, clienteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClienteAudit
+	for rows.Next() {
+		var i ClienteAudit
+		if err := rows.Scan(
+			&i.ID,
+			&i.ClienteID,
+			&i.Action,
+			&i.Changes,
+			&i.Actor,
+			&i.RequestID,
+			&i.RecordedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClientesByCPF = `-- name: ListClientesByCPF :many
+SELECT ativo, id, cpf, email, nome, created_at, updated_at, version, deleted_at, anonymized_at, anonymization_reason, email_verified_at FROM clientes
+WHERE ($1::boolean IS NULL OR ativo = $1)
+  AND ($2::text IS NULL OR nome ILIKE ($2 || '%'))
+  AND ($3::uuid IS NULL OR (cpf, id) > ($4::text, $3))
+ORDER BY cpf, id
+LIMIT $5
+`
+
+type ListClientesByCPFParams struct {
+	Active     pgtype.Bool
+	NamePrefix pgtype.Text
+	AfterID    pgtype.UUID
+	AfterKey   pgtype.Text
+	RowLimit   int32
+}
+
+func (q *Queries) ListClientesByCPF(ctx context.Context, arg ListClientesByCPFParams) ([]Cliente, error) {
+	rows, err := q.db.Query(ctx, listClientesByCPF,
+		arg.Active,
+		arg.NamePrefix,
+		arg.AfterID,
+		arg.AfterKey,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Cliente
+	for rows.Next() {
+		var i Cliente
+		if err := rows.Scan(
+			&i.Ativo,
+			&i.ID,
+			&i.Cpf,
+			&i.Email,
+			&i.Nome,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.AnonymizedAt,
+			&i.AnonymizationReason,
+			&i.EmailVerifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClientesByCreated = `-- name: ListClientesByCreated :many
+SELECT ativo, id, cpf, email, nome, created_at, updated_at, version, deleted_at, anonymized_at, anonymization_reason, email_verified_at FROM clientes
+WHERE ($1::boolean IS NULL OR ativo = $1)
+  AND ($2::text IS NULL OR nome ILIKE ($2 || '%'))
+  AND ($3::uuid IS NULL OR (created_at, id) > ($4::timestamptz, $3))
+ORDER BY created_at, id
+LIMIT $5
+`
+
+type ListClientesByCreatedParams struct {
+	Active     pgtype.Bool
+	NamePrefix pgtype.Text
+	AfterID    pgtype.UUID
+	AfterKey   pgtype.Timestamptz
+	RowLimit   int32
 }
 
-func (q *Queries) UpdateCliente(ctx context.Context, arg UpdateClienteParams) error {
-	_, err := q.db.Exec(ctx, updateCliente,
+func (q *Queries) ListClientesByCreated(ctx context.Context, arg ListClientesByCreatedParams) ([]Cliente, error) {
+	rows, err := q.db.Query(ctx, listClientesByCreated,
+		arg.Active,
+		arg.NamePrefix,
+		arg.AfterID,
+		arg.AfterKey,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Cliente
+	for rows.Next() {
+		var i Cliente
+		if err := rows.Scan(
+			&i.Ativo,
+			&i.ID,
+			&i.Cpf,
+			&i.Email,
+			&i.Nome,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.AnonymizedAt,
+			&i.AnonymizationReason,
+			&i.EmailVerifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClientesByName = `-- name: ListClientesByName :many
+SELECT ativo, id, cpf, email, nome, created_at, updated_at, version, deleted_at, anonymized_at, anonymization_reason, email_verified_at FROM clientes
+WHERE ($1::boolean IS NULL OR ativo = $1)
+  AND ($2::text IS NULL OR nome ILIKE ($2 || '%'))
+  AND ($3::uuid IS NULL OR (nome, id) > ($4::text, $3))
+ORDER BY nome, id
+LIMIT $5
+`
+
+type ListClientesByNameParams struct {
+	Active     pgtype.Bool
+	NamePrefix pgtype.Text
+	AfterID    pgtype.UUID
+	AfterKey   pgtype.Text
+	RowLimit   int32
+}
+
+func (q *Queries) ListClientesByName(ctx context.Context, arg ListClientesByNameParams) ([]Cliente, error) {
+	rows, err := q.db.Query(ctx, listClientesByName,
+		arg.Active,
+		arg.NamePrefix,
+		arg.AfterID,
+		arg.AfterKey,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Cliente
+	for rows.Next() {
+		var i Cliente
+		if err := rows.Scan(
+			&i.Ativo,
+			&i.ID,
+			&i.Cpf,
+			&i.Email,
+			&i.Nome,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.AnonymizedAt,
+			&i.AnonymizationReason,
+			&i.EmailVerifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listConsents = `-- name: ListConsents :many
+SELECT id, cliente_id, purpose, granted, channel, policy_version, recorded_at FROM consents WHERE cliente_id = $1 ORDER BY id
+`
+
+func (q *Queries) ListConsents(ctx context.Context, clienteID pgtype.UUID) ([]Consent, error) {
+	rows, err := q.db.Query(ctx, listConsents, clienteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Consent
+	for rows.Next() {
+		var i Consent
+		if err := rows.Scan(
+			&i.ID,
+			&i.ClienteID,
+			&i.Purpose,
+			&i.Granted,
+			&i.Channel,
+			&i.PolicyVersion,
+			&i.RecordedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at FROM webhook_deliveries
+WHERE subscription_id = $1 AND ($2::text IS NULL OR status = $2)
+ORDER BY created_at DESC, id
+`
+
+type ListWebhookDeliveriesParams struct {
+	SubscriptionID pgtype.UUID
+	Status         pgtype.Text
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveries,
+		arg.SubscriptionID,
+		arg.Status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookSubscriptions = `-- name: ListWebhookSubscriptions :many
+SELECT id, url, events, secret, created_at FROM webhook_subscriptions ORDER BY created_at, id
+`
+
+func (q *Queries) ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	rows, err := q.db.Query(ctx, listWebhookSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookSubscription
+	for rows.Next() {
+		var i WebhookSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Events,
+			&i.Secret,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOutboxEventPublished = `-- name: MarkOutboxEventPublished :exec
+DELETE FROM outbox_pending WHERE seq = $1 AND sink = $2
+`
+
+type MarkOutboxEventPublishedParams struct {
+	Seq  int64
+	Sink string
+}
+
+func (q *Queries) MarkOutboxEventPublished(ctx context.Context, arg MarkOutboxEventPublishedParams) error {
+	_, err := q.db.Exec(ctx, markOutboxEventPublished,
+		arg.Seq,
+		arg.Sink,
+	)
+	return err
+}
+
+const reactivateCliente = `-- name: ReactivateCliente :one
+UPDATE clientes SET
+(ativo, deleted_at, updated_at, version) = (true, NULL, now(), version + 1)
+WHERE id = $1 AND anonymized_at IS NULL
+RETURNING ativo, id, cpf, email, nome, created_at, updated_at, version, deleted_at, anonymized_at, anonymization_reason, email_verified_at
+`
+
+func (q *Queries) ReactivateCliente(ctx context.Context, id pgtype.UUID) (Cliente, error) {
+	row := q.db.QueryRow(ctx, reactivateCliente, id)
+	var i Cliente
+	err := row.Scan(
+		&i.Ativo,
+		&i.ID,
+		&i.Cpf,
+		&i.Email,
+		&i.Nome,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.AnonymizationReason,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const redactAuditEntries = `-- name: RedactAuditEntries :exec
+UPDATE cliente_audit SET changes = (
+    SELECT coalesce(jsonb_agg(
+        CASE WHEN c->>'field' IN ('name', 'cpf', 'email')
+        THEN jsonb_build_object('field', c->'field', 'redacted', true)
+        ELSE c END ORDER BY n), '[]'::jsonb)
+    FROM jsonb_array_elements(changes) WITH ORDINALITY AS t(c, n)
+)
+WHERE cliente_id = $1
+`
+
+func (q *Queries) RedactAuditEntries(ctx context.Context, clienteID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, redactAuditEntries, clienteID)
+	return err
+}
+
+const redactOutboxEvents = `-- name: RedactOutboxEvents :exec
+UPDATE outbox SET payload = payload - '{name,cpf,email}'::text[]
+WHERE cliente_id = $1
+`
+
+func (q *Queries) RedactOutboxEvents(ctx context.Context, clienteID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, redactOutboxEvents, clienteID)
+	return err
+}
+
+const redactWebhookDeliveries = `-- name: RedactWebhookDeliveries :exec
+UPDATE webhook_deliveries SET payload = jsonb_set(payload, '{data}', (payload -> 'data') - '{name,cpf,email}'::text[])
+WHERE payload #>> '{data,id}' = $1::text
+`
+
+func (q *Queries) RedactWebhookDeliveries(ctx context.Context, clienteID string) error {
+	_, err := q.db.Exec(ctx, redactWebhookDeliveries, clienteID)
+	return err
+}
+
+const reserveIdempotencyKey = `-- name: ReserveIdempotencyKey :one
+
+INSERT INTO idempotency_keys
+(subject, key, request_hash, created_at, expires_at, locked_until)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (subject, key) DO UPDATE SET
+(request_hash, status_code, header, body, created_at, expires_at, locked_until) = (EXCLUDED.request_hash, 0, NULL, NULL, EXCLUDED.created_at, EXCLUDED.expires_at, EXCLUDED.locked_until)
+WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+   OR (idempotency_keys.status_code = 0 AND idempotency_keys.locked_until <= EXCLUDED.created_at)
+RETURNING key, request_hash, status_code, header, body, created_at, expires_at, locked_until, subject
+`
+
+type ReserveIdempotencyKeyParams struct {
+	Subject     string
+	Key         string
+	RequestHash string
+	CreatedAt   pgtype.Timestamptz
+	ExpiresAt   pgtype.Timestamptz
+	LockedUntil pgtype.Timestamptz
+}
+
+// ----------------------------------------------
+// Idempotency keys
+func (q *Queries) ReserveIdempotencyKey(ctx context.Context, arg ReserveIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, reserveIdempotencyKey,
+		arg.Subject,
+		arg.Key,
+		arg.RequestHash,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+		arg.LockedUntil,
+	)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Key,
+		&i.RequestHash,
+		&i.StatusCode,
+		&i.Header,
+		&i.Body,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LockedUntil,
+		&i.Subject,
+	)
+	return i, err
+}
+
+const saveEmailVerification = `-- name: SaveEmailVerification :exec
+
+INSERT INTO email_verifications
+(cliente_id, email, code_hash, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (cliente_id) DO UPDATE SET
+(email, code_hash, attempts, created_at, expires_at) = (EXCLUDED.email, EXCLUDED.code_hash, 0, EXCLUDED.created_at, EXCLUDED.expires_at)
+`
+
+type SaveEmailVerificationParams struct {
+	ClienteID pgtype.UUID
+	Email     string
+	CodeHash  string
+	CreatedAt pgtype.Timestamptz
+	ExpiresAt pgtype.Timestamptz
+}
+
+// ----------------------------------------------
+// E-mail verifications
+func (q *Queries) SaveEmailVerification(ctx context.Context, arg SaveEmailVerificationParams) error {
+	_, err := q.db.Exec(ctx, saveEmailVerification,
+		arg.ClienteID,
+		arg.Email,
+		arg.CodeHash,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const updateCliente = `-- name: UpdateCliente :one
+UPDATE clientes SET
+(nome, cpf, email, ativo, deleted_at, email_verified_at, updated_at, version) = ($2, $3, $4, $5, CASE WHEN $5 THEN NULL ELSE deleted_at END, CASE WHEN lower(email) = lower($4) THEN email_verified_at END, now(), version + 1)
+WHERE id = $1 AND version = $6 AND anonymized_at IS NULL
+RETURNING ativo, id, cpf, email, nome, created_at, updated_at, version, deleted_at, anonymized_at, anonymization_reason, email_verified_at
+`
+
+type UpdateClienteParams struct {
+	ID      pgtype.UUID
+	Nome    pgtype.Text
+	Cpf     pgtype.Text
+	Email   pgtype.Text
+	Ativo   bool
+	Version int32
+}
+
+func (q *Queries) UpdateCliente(ctx context.Context, arg UpdateClienteParams) (Cliente, error) {
+	row := q.db.QueryRow(ctx, updateCliente,
 		arg.ID,
 		arg.Nome,
 		arg.Cpf,
 		arg.Email,
 		arg.Ativo,
+		arg.Version,
 	)
-	return err
+	var i Cliente
+	err := row.Scan(
+		&i.Ativo,
+		&i.ID,
+		&i.Cpf,
+		&i.Email,
+		&i.Nome,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.AnonymizationReason,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const updateWebhookDelivery = `-- name: UpdateWebhookDelivery :execrows
+UPDATE webhook_deliveries SET
+(status, attempts, next_attempt_at, last_status_code, last_error, delivered_at) =
+($1, $2, $3, $4, $5, $6)
+WHERE id = $7 AND attempts = $8
+`
+
+type UpdateWebhookDeliveryParams struct {
+	Status           string
+	Attempts         int32
+	NextAttemptAt    pgtype.Timestamptz
+	LastStatusCode   int32
+	LastError        string
+	DeliveredAt      pgtype.Timestamptz
+	ID               pgtype.UUID
+	ExpectedAttempts int32
+}
+
+func (q *Queries) UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateWebhookDelivery,
+		arg.Status,
+		arg.Attempts,
+		arg.NextAttemptAt,
+		arg.LastStatusCode,
+		arg.LastError,
+		arg.DeliveredAt,
+		arg.ID,
+		arg.ExpectedAttempts,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const verifyClienteEmail = `-- name: VerifyClienteEmail :one
+UPDATE clientes SET
+(email_verified_at, updated_at, version) = ($2, now(), version + 1)
+WHERE id = $1 AND lower(email) = lower($3::text) AND anonymized_at IS NULL
+RETURNING ativo, id, cpf, email, nome, created_at, updated_at, version, deleted_at, anonymized_at, anonymization_reason, email_verified_at
+`
+
+type VerifyClienteEmailParams struct {
+	ID         pgtype.UUID
+	VerifiedAt pgtype.Timestamptz
+	Email      string
+}
+
+func (q *Queries) VerifyClienteEmail(ctx context.Context, arg VerifyClienteEmailParams) (Cliente, error) {
+	row := q.db.QueryRow(ctx, verifyClienteEmail,
+		arg.ID,
+		arg.VerifiedAt,
+		arg.Email,
+	)
+	var i Cliente
+	err := row.Scan(
+		&i.Ativo,
+		&i.ID,
+		&i.Cpf,
+		&i.Email,
+		&i.Nome,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.AnonymizedAt,
+		&i.AnonymizationReason,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
 }
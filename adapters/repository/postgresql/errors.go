@@ -0,0 +1,42 @@
+package postgresql
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// constraintErrors maps the unique and foreign key constraints to the domain
+// error reported when one of them is violated.
+var constraintErrors = map[string]error{
+	"clientes_pkey":            entityErr.ErrClienteAlreadyExistsForID,
+	"clientes_cpf_key":         entityErr.ErrClienteAlreadyExistsForCPF,
+	"clientes_email_key":       entityErr.ErrClienteAlreadyExistsForEmail,
+	"consents_cliente_id_fkey": entityErr.ErrNotFound,
+
+	"email_verifications_cliente_id_fkey": entityErr.ErrNotFound,
+
+	"webhook_deliveries_subscription_id_fkey": entityErr.ErrNotFound,
+}
+
+// translateError turns constraint violations into domain errors, anything
+// else is returned untouched.
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || (pgErr.Code != uniqueViolation && pgErr.Code != foreignKeyViolation) {
+		return err
+	}
+
+	if domainErr, ok := constraintErrors[pgErr.ConstraintName]; ok {
+		return domainErr
+	}
+
+	return err
+}
@@ -0,0 +1,108 @@
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/repository/postgresql/db"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *Repository) ReserveKey(ctx context.Context, rec entities.IdempotencyRecord) (*entities.IdempotencyRecord, bool, error) {
+	// the stored key may be released between the insert and the read, then
+	// the insert is tried again
+	for range 3 {
+		row, err := r.db.ReserveIdempotencyKey(ctx, db.ReserveIdempotencyKeyParams{
+			Subject:     rec.Subject,
+			Key:         rec.Key,
+			RequestHash: rec.RequestHash,
+			CreatedAt:   timestamptz(rec.CreatedAt),
+			ExpiresAt:   timestamptz(rec.ExpiresAt),
+			LockedUntil: timestamptz(rec.LockedUntil),
+		})
+		if err == nil {
+			reserved, err := idempotencyRecordFromDB(row)
+			return reserved, true, err
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, fmt.Errorf("reserving idempotency key: %w", err)
+		}
+
+		row, err = r.db.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{Subject: rec.Subject, Key: rec.Key})
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("getting idempotency key: %w", err)
+		}
+
+		stored, err := idempotencyRecordFromDB(row)
+		return stored, false, err
+	}
+
+	return nil, false, entityErr.ErrIdempotencyKeyInProgress
+}
+
+func (r *Repository) CompleteKey(ctx context.Context, rec entities.IdempotencyRecord) error {
+	header, err := json.Marshal(rec.Header)
+	if err != nil {
+		return fmt.Errorf("encoding response header: %w", err)
+	}
+
+	rows, err := r.db.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+		Subject:    rec.Subject,
+		Key:        rec.Key,
+		StatusCode: int32(rec.StatusCode),
+		Header:     header,
+		Body:       rec.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("completing idempotency key: %w", err)
+	}
+	if rows == 0 {
+		return entityErr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) ReleaseKey(ctx context.Context, subject, key string) error {
+	if err := r.db.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{Subject: subject, Key: key}); err != nil {
+		return fmt.Errorf("releasing idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteExpiredKeys(ctx context.Context, now time.Time) error {
+	if err := r.db.DeleteExpiredIdempotencyKeys(ctx, timestamptz(now)); err != nil {
+		return fmt.Errorf("deleting expired idempotency keys: %w", err)
+	}
+
+	return nil
+}
+
+func idempotencyRecordFromDB(k db.IdempotencyKey) (*entities.IdempotencyRecord, error) {
+	rec := entities.IdempotencyRecord{
+		Subject:     k.Subject,
+		Key:         k.Key,
+		RequestHash: k.RequestHash,
+		StatusCode:  int(k.StatusCode),
+		Body:        k.Body,
+		CreatedAt:   k.CreatedAt.Time,
+		ExpiresAt:   k.ExpiresAt.Time,
+		LockedUntil: k.LockedUntil.Time,
+	}
+	if k.Header != nil {
+		if err := json.Unmarshal(k.Header, &rec.Header); err != nil {
+			return nil, fmt.Errorf("decoding response header: %w", err)
+		}
+	}
+
+	return &rec, nil
+}
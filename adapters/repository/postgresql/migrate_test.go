@@ -0,0 +1,58 @@
+package postgresql
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("embedded migrations", func(t *testing.T) {
+		migrations, err := loadMigrations(migrationsFS)
+		if err != nil {
+			t.Fatalf("should not return any error, got: %s", err)
+		}
+		if len(migrations) == 0 {
+			t.Fatal("should have found migrations")
+		}
+
+		for i, mig := range migrations {
+			if mig.version != int64(i+1) {
+				t.Errorf("migrations should be numbered without gaps, want: %d, got: %d", i+1, mig.version)
+			}
+		}
+	})
+
+	t.Run("missing down migration", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"migrations/0001_create.up.sql": {Data: []byte("SELECT 1")},
+		}
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Error("should have return error")
+		}
+	})
+
+	t.Run("malformed name", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"migrations/create.sql": {Data: []byte("SELECT 1")},
+		}
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Error("should have return error")
+		}
+	})
+
+	t.Run("ordered by version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"migrations/0010_b.up.sql":   {Data: []byte("SELECT 10")},
+			"migrations/0010_b.down.sql": {Data: []byte("SELECT -10")},
+			"migrations/0002_a.up.sql":   {Data: []byte("SELECT 2")},
+			"migrations/0002_a.down.sql": {Data: []byte("SELECT -2")},
+		}
+		migrations, err := loadMigrations(fsys)
+		if err != nil {
+			t.Fatalf("should not return any error, got: %s", err)
+		}
+		if migrations[0].version != 2 || migrations[1].version != 10 {
+			t.Errorf("should be ordered by version, got: %d, %d", migrations[0].version, migrations[1].version)
+		}
+	})
+}
@@ -0,0 +1 @@
+ALTER TABLE "public"."clientes" DROP COLUMN IF EXISTS "created_at";
@@ -0,0 +1,2 @@
+ALTER TABLE "public"."clientes"
+    ADD COLUMN IF NOT EXISTS "created_at" timestamp with time zone DEFAULT now() NOT NULL;
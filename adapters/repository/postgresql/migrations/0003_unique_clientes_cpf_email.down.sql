@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "public"."clientes_email_key";
+DROP INDEX IF EXISTS "public"."clientes_cpf_key";
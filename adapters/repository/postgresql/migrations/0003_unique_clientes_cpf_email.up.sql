@@ -0,0 +1,32 @@
+-- clientes registered twice before the cpf and e-mail were unique are not
+-- merged here, orders in other services may point to any of them: the
+-- conflicts are listed and the migration stops until they are merged
+DO $$
+DECLARE
+    conflicts text;
+BEGIN
+    SELECT string_agg(format('%s %s: %s', "field", "value", "ids"), E'\n')
+    INTO conflicts
+    FROM (
+        SELECT 'cpf' AS "field", "cpf" AS "value", string_agg("id"::text, ', ' ORDER BY "created_at", "id") AS "ids"
+        FROM "public"."clientes"
+        WHERE "cpf" IS NOT NULL
+        GROUP BY "cpf"
+        HAVING count(*) > 1
+        UNION ALL
+        SELECT 'email', lower("email"), string_agg("id"::text, ', ' ORDER BY "created_at", "id")
+        FROM "public"."clientes"
+        WHERE "email" IS NOT NULL
+        GROUP BY lower("email")
+        HAVING count(*) > 1
+    ) AS "duplicates";
+
+    IF conflicts IS NOT NULL THEN
+        RAISE EXCEPTION E'clientes share a cpf or e-mail, merge them before migrating:\n%', conflicts
+            USING HINT = 'keep the first cliente of each line and point the orders of the others to it';
+    END IF;
+END
+$$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "clientes_cpf_key" ON "public"."clientes" USING btree ("cpf");
+CREATE UNIQUE INDEX IF NOT EXISTS "clientes_email_key" ON "public"."clientes" USING btree (lower("email"));
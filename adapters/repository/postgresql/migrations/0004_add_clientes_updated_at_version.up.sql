@@ -0,0 +1,3 @@
+ALTER TABLE "public"."clientes"
+    ADD COLUMN IF NOT EXISTS "updated_at" timestamp with time zone DEFAULT now() NOT NULL,
+    ADD COLUMN IF NOT EXISTS "version" integer DEFAULT 1 NOT NULL;
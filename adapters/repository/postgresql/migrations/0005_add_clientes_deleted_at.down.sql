@@ -0,0 +1,2 @@
+ALTER TABLE "public"."clientes"
+    DROP COLUMN IF EXISTS "deleted_at";
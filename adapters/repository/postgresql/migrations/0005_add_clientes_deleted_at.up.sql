@@ -0,0 +1,2 @@
+ALTER TABLE "public"."clientes"
+    ADD COLUMN IF NOT EXISTS "deleted_at" timestamp with time zone;
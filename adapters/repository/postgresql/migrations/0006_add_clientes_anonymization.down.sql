@@ -0,0 +1,3 @@
+ALTER TABLE "public"."clientes"
+    DROP COLUMN IF EXISTS "anonymization_reason",
+    DROP COLUMN IF EXISTS "anonymized_at";
@@ -0,0 +1,3 @@
+ALTER TABLE "public"."clientes"
+    ADD COLUMN IF NOT EXISTS "anonymized_at" timestamp with time zone,
+    ADD COLUMN IF NOT EXISTS "anonymization_reason" text;
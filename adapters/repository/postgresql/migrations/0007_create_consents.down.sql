@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "public"."consents";
+DROP FUNCTION IF EXISTS "public"."consents_append_only"();
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS "public"."consents" (
+    "id" bigint GENERATED ALWAYS AS IDENTITY,
+    "cliente_id" uuid NOT NULL,
+    "purpose" character varying(64) NOT NULL,
+    "granted" boolean NOT NULL,
+    "channel" character varying(64) NOT NULL,
+    "policy_version" character varying(64) NOT NULL,
+    "recorded_at" timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT "consents_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "consents_cliente_id_fkey" FOREIGN KEY ("cliente_id") REFERENCES "public"."clientes" ("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "consents_cliente_id_idx" ON "public"."consents" USING btree ("cliente_id", "id");
+
+-- the history is append-only, rows only go away with their cliente
+CREATE OR REPLACE FUNCTION "public"."consents_append_only"() RETURNS trigger
+    LANGUAGE plpgsql AS $$
+BEGIN
+    RAISE EXCEPTION 'consents are append-only';
+END;
+$$;
+CREATE TRIGGER "consents_append_only" BEFORE UPDATE ON "public"."consents"
+    FOR EACH ROW EXECUTE FUNCTION "public"."consents_append_only"();
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "public"."cliente_audit";
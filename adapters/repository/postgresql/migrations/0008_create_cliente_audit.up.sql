@@ -0,0 +1,12 @@
+-- no foreign key, the history outlives a purged cliente
+CREATE TABLE IF NOT EXISTS "public"."cliente_audit" (
+    "id" bigint GENERATED ALWAYS AS IDENTITY,
+    "cliente_id" uuid NOT NULL,
+    "action" character varying(32) NOT NULL,
+    "changes" jsonb NOT NULL,
+    "actor" character varying(255) NOT NULL,
+    "request_id" character varying(255) NOT NULL,
+    "recorded_at" timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT "cliente_audit_pkey" PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "cliente_audit_cliente_id_idx" ON "public"."cliente_audit" USING btree ("cliente_id", "id");
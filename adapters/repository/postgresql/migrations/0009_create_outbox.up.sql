@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "public"."outbox" (
+    "seq" bigint GENERATED ALWAYS AS IDENTITY,
+    "id" uuid NOT NULL,
+    "type" character varying(64) NOT NULL,
+    "cliente_id" uuid NOT NULL,
+    "payload" jsonb NOT NULL,
+    "occurred_at" timestamp with time zone DEFAULT now() NOT NULL,
+    "published_at" timestamp with time zone,
+    CONSTRAINT "outbox_pkey" PRIMARY KEY ("seq"),
+    CONSTRAINT "outbox_id_key" UNIQUE ("id")
+);
+CREATE INDEX IF NOT EXISTS "outbox_pending_idx" ON "public"."outbox" USING btree ("seq") WHERE "published_at" IS NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "public"."webhook_deliveries";
+DROP TABLE IF EXISTS "public"."webhook_subscriptions";
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS "public"."webhook_subscriptions" (
+    "id" uuid NOT NULL,
+    "url" text NOT NULL,
+    "events" text[] NOT NULL,
+    "secret" text NOT NULL,
+    "created_at" timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT "webhook_subscriptions_pkey" PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "public"."webhook_deliveries" (
+    "id" uuid NOT NULL,
+    "subscription_id" uuid NOT NULL,
+    "event_id" uuid NOT NULL,
+    "event_type" character varying(64) NOT NULL,
+    "payload" jsonb NOT NULL,
+    "status" character varying(16) NOT NULL,
+    "attempts" integer DEFAULT 0 NOT NULL,
+    "next_attempt_at" timestamp with time zone,
+    "last_status_code" integer DEFAULT 0 NOT NULL,
+    "last_error" text DEFAULT '' NOT NULL,
+    "created_at" timestamp with time zone DEFAULT now() NOT NULL,
+    "delivered_at" timestamp with time zone,
+    CONSTRAINT "webhook_deliveries_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "webhook_deliveries_subscription_id_event_id_key" UNIQUE ("subscription_id", "event_id"),
+    CONSTRAINT "webhook_deliveries_subscription_id_fkey" FOREIGN KEY ("subscription_id") REFERENCES "public"."webhook_subscriptions" ("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "webhook_deliveries_due_idx" ON "public"."webhook_deliveries" USING btree ("next_attempt_at") WHERE "status" = 'pending';
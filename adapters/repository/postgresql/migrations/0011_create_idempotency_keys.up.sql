@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "public"."idempotency_keys" (
+    "key" text NOT NULL,
+    "request_hash" text NOT NULL,
+    "status_code" integer DEFAULT 0 NOT NULL,
+    "header" jsonb,
+    "body" bytea,
+    "created_at" timestamp with time zone NOT NULL,
+    "expires_at" timestamp with time zone NOT NULL,
+    CONSTRAINT "idempotency_keys_pkey" PRIMARY KEY ("key")
+);
+CREATE INDEX IF NOT EXISTS "idempotency_keys_expires_at_idx" ON "public"."idempotency_keys" USING btree ("expires_at");
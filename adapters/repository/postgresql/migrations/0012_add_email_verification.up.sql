@@ -0,0 +1,13 @@
+ALTER TABLE "public"."clientes"
+    ADD COLUMN IF NOT EXISTS "email_verified_at" timestamp with time zone;
+
+CREATE TABLE IF NOT EXISTS "public"."email_verifications" (
+    "cliente_id" uuid NOT NULL,
+    "email" text NOT NULL,
+    "code_hash" text NOT NULL,
+    "attempts" integer DEFAULT 0 NOT NULL,
+    "created_at" timestamp with time zone NOT NULL,
+    "expires_at" timestamp with time zone NOT NULL,
+    CONSTRAINT "email_verifications_pkey" PRIMARY KEY ("cliente_id"),
+    CONSTRAINT "email_verifications_cliente_id_fkey" FOREIGN KEY ("cliente_id") REFERENCES "public"."clientes" ("id") ON DELETE CASCADE
+);
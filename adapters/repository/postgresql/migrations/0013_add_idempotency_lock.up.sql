@@ -0,0 +1,2 @@
+ALTER TABLE "public"."idempotency_keys"
+    ADD COLUMN IF NOT EXISTS "locked_until" timestamp with time zone;
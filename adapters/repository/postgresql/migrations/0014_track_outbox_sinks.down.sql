@@ -0,0 +1,7 @@
+ALTER TABLE "public"."outbox"
+    ADD COLUMN IF NOT EXISTS "published_at" timestamp with time zone;
+UPDATE "public"."outbox" SET "published_at" = "occurred_at"
+WHERE NOT EXISTS (SELECT 1 FROM "public"."outbox_pending" WHERE "outbox_pending"."seq" = "outbox"."seq");
+CREATE INDEX IF NOT EXISTS "outbox_pending_idx" ON "public"."outbox" USING btree ("seq") WHERE "published_at" IS NULL;
+
+DROP TABLE IF EXISTS "public"."outbox_pending";
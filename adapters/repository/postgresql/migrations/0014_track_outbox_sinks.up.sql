@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "public"."outbox_pending" (
+    "seq" bigint NOT NULL,
+    "sink" character varying(64) NOT NULL,
+    CONSTRAINT "outbox_pending_pkey" PRIMARY KEY ("seq", "sink"),
+    CONSTRAINT "outbox_pending_seq_fkey" FOREIGN KEY ("seq") REFERENCES "public"."outbox" ("seq") ON DELETE CASCADE
+);
+
+-- the events not published yet are pending for every sink
+INSERT INTO "public"."outbox_pending" ("seq", "sink")
+SELECT "seq", "sink" FROM "public"."outbox", unnest(ARRAY['broker', 'webhooks']) AS "sink"
+WHERE "published_at" IS NULL;
+
+DROP INDEX IF EXISTS "public"."outbox_pending_idx";
+ALTER TABLE "public"."outbox"
+    DROP COLUMN IF EXISTS "published_at";
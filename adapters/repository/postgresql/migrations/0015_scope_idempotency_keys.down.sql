@@ -0,0 +1,10 @@
+-- a key taken by several principals keeps one of them
+DELETE FROM "public"."idempotency_keys" AS "k"
+USING "public"."idempotency_keys" AS "other"
+WHERE "k"."key" = "other"."key" AND "k"."subject" > "other"."subject";
+
+ALTER TABLE "public"."idempotency_keys"
+    DROP CONSTRAINT IF EXISTS "idempotency_keys_pkey",
+    ADD CONSTRAINT "idempotency_keys_pkey" PRIMARY KEY ("key");
+ALTER TABLE "public"."idempotency_keys"
+    DROP COLUMN IF EXISTS "subject";
@@ -0,0 +1,6 @@
+-- the keys taken so far were made by any principal, none is known
+ALTER TABLE "public"."idempotency_keys"
+    ADD COLUMN IF NOT EXISTS "subject" text DEFAULT '' NOT NULL;
+ALTER TABLE "public"."idempotency_keys"
+    DROP CONSTRAINT IF EXISTS "idempotency_keys_pkey",
+    ADD CONSTRAINT "idempotency_keys_pkey" PRIMARY KEY ("subject", "key");
@@ -0,0 +1,100 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/repository/postgresql/db"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (r *Repository) WriteEvent(ctx context.Context, event entities.Event) error {
+	return r.inTx(ctx, func(q *db.Queries) error {
+		err := q.CreateOutboxEvent(ctx, db.CreateOutboxEventParams{
+			ID:        pgtype.UUID{Bytes: event.ID, Valid: true},
+			Type:      string(event.Type),
+			ClienteID: pgtype.UUID{Bytes: event.ClienteID, Valid: true},
+			Payload:   event.Payload,
+		})
+		if err != nil {
+			return fmt.Errorf("writing %s event of cliente %s to the outbox: %w", event.Type, event.ClienteID, err)
+		}
+		err = q.CreateOutboxPending(ctx, db.CreateOutboxPendingParams{
+			Sinks: sinkNames(entities.EventSinks()),
+			ID:    pgtype.UUID{Bytes: event.ID, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("queueing %s event of cliente %s to its sinks: %w", event.Type, event.ClienteID, err)
+		}
+
+		return nil
+	})
+}
+
+// PublishPending locks the rows pending for sink it claims until the
+// transaction marking them published commits, the other replicas skip them
+// meanwhile. The rows of the other sinks are not locked.
+func (r *Repository) PublishPending(ctx context.Context, sink entities.EventSink, limit int, publish func(ctx context.Context, event entities.Event) error) (int, error) {
+	var (
+		published  int
+		publishErr error
+	)
+	err := r.inTx(ctx, func(q *db.Queries) error {
+		rows, err := q.ClaimPendingOutboxEvents(ctx, db.ClaimPendingOutboxEventsParams{
+			Sink:     string(sink),
+			RowLimit: int32(limit),
+		})
+		if err != nil {
+			return fmt.Errorf("claiming outbox events pending for %s: %w", sink, err)
+		}
+
+		for _, row := range rows {
+			// the events published so far are still committed
+			if publishErr = publish(ctx, eventFromDB(row)); publishErr != nil {
+				return nil
+			}
+			err := q.MarkOutboxEventPublished(ctx, db.MarkOutboxEventPublishedParams{Seq: row.Seq, Sink: string(sink)})
+			if err != nil {
+				return fmt.Errorf("marking outbox event %s published to %s: %w", entities.ID(row.ID.Bytes), sink, err)
+			}
+			published++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return published, publishErr
+}
+
+func (r *Repository) DeletePublished(ctx context.Context, before time.Time) (int, error) {
+	n, err := r.db.DeletePublishedOutboxEvents(ctx, timestamptz(before))
+	if err != nil {
+		return 0, fmt.Errorf("deleting published outbox events: %w", err)
+	}
+
+	return int(n), nil
+}
+
+func sinkNames(sinks []entities.EventSink) []string {
+	names := make([]string, 0, len(sinks))
+	for _, s := range sinks {
+		names = append(names, string(s))
+	}
+
+	return names
+}
+
+func eventFromDB(row db.Outbox) entities.Event {
+	return entities.Event{
+		ID:         row.ID.Bytes,
+		Type:       entities.EventType(row.Type),
+		ClienteID:  row.ClienteID.Bytes,
+		Payload:    row.Payload,
+		OccurredAt: row.OccurredAt.Time,
+	}
+}
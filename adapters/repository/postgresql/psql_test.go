@@ -3,10 +3,13 @@ package postgresql
 import (
 	"context"
 	"log"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/repository/repositorytest"
 	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -21,7 +24,6 @@ func TestWithPostgres(t *testing.T) {
 
 	postgresContainer, err := postgres.Run(ctx,
 		"postgres:16-alpine",
-		postgres.WithInitScripts("./../../../deployments/init.sql"),
 		postgres.WithDatabase(dbName),
 		postgres.WithUsername(dbUser),
 		postgres.WithPassword(dbPassword),
@@ -52,16 +54,124 @@ func TestWithPostgres(t *testing.T) {
 		t.Errorf("should not return any error, got: %s", err)
 	}
 
+	t.Run("migrate", func(t *testing.T) {
+		errs := make(chan error, 3)
+		for i := 0; i < cap(errs); i++ {
+			go func() { errs <- repo.Migrate(ctx) }()
+		}
+		for i := 0; i < cap(errs); i++ {
+			if err := <-errs; err != nil {
+				t.Fatalf("migrating, got error: %s", err)
+			}
+		}
+
+		if err := repo.Rollback(ctx, 1); err != nil {
+			t.Fatalf("rolling back, got error: %s", err)
+		}
+		if err := repo.Migrate(ctx); err != nil {
+			t.Fatalf("migrating again, got error: %s", err)
+		}
+	})
+
+	t.Run("migrate stops on duplicate clientes", func(t *testing.T) {
+		migrations, err := loadMigrations(migrationsFS)
+		if err != nil {
+			t.Fatalf("loading migrations, got error: %s", err)
+		}
+		// back to before the cpf and e-mail were unique
+		if err := repo.Rollback(ctx, len(migrations)-2); err != nil {
+			t.Fatalf("rolling back, got error: %s", err)
+		}
+		_, err = repo.pool.Exec(ctx, `INSERT INTO clientes (ativo, id, cpf, email, nome) VALUES
+			(true, gen_random_uuid(), '11144477735', 'fulano@email.com', 'Fulano'),
+			(true, gen_random_uuid(), '11144477735', 'FULANO@email.com', 'Fulano')`)
+		if err != nil {
+			t.Fatalf("inserting duplicates, got error: %s", err)
+		}
+
+		err = repo.Migrate(ctx)
+		if err == nil || !strings.Contains(err.Error(), "11144477735") {
+			t.Fatalf("should have listed the duplicate cpf, got: %v", err)
+		}
+
+		if _, err := repo.pool.Exec(ctx, `DELETE FROM clientes`); err != nil {
+			t.Fatalf("merging duplicates, got error: %s", err)
+		}
+		if err := repo.Migrate(ctx); err != nil {
+			t.Fatalf("migrating once merged, got error: %s", err)
+		}
+	})
+
+	t.Run("repository conformance", func(t *testing.T) {
+		repositorytest.Run(t, func(t *testing.T) ports.Repository {
+			if err := repo.db.DeleteAllCliente(ctx); err != nil {
+				t.Fatalf("cleaning db, got error: %s", err)
+			}
+			return repo
+		})
+	})
+
+	t.Run("consent repository conformance", func(t *testing.T) {
+		repositorytest.RunConsents(t, func(t *testing.T) repositorytest.ConsentRepository {
+			if err := repo.db.DeleteAllCliente(ctx); err != nil {
+				t.Fatalf("cleaning db, got error: %s", err)
+			}
+			return repo
+		})
+	})
+
+	t.Run("verification store conformance", func(t *testing.T) {
+		repositorytest.RunVerification(t, func(t *testing.T) repositorytest.VerificationRepository {
+			if err := repo.db.DeleteAllCliente(ctx); err != nil {
+				t.Fatalf("cleaning db, got error: %s", err)
+			}
+			return repo
+		})
+	})
+
+	t.Run("outbox conformance", func(t *testing.T) {
+		repositorytest.RunOutbox(t, func(t *testing.T) repositorytest.OutboxRepository {
+			if err := repo.db.DeleteAllCliente(ctx); err != nil {
+				t.Fatalf("cleaning db, got error: %s", err)
+			}
+			if err := repo.db.DeleteAllOutboxEvents(ctx); err != nil {
+				t.Fatalf("cleaning outbox, got error: %s", err)
+			}
+			return repo
+		})
+	})
+
+	t.Run("webhook repository conformance", func(t *testing.T) {
+		repositorytest.RunWebhooks(t, func(t *testing.T) repositorytest.WebhookRepository {
+			if err := repo.db.DeleteAllCliente(ctx); err != nil {
+				t.Fatalf("cleaning db, got error: %s", err)
+			}
+			if err := repo.db.DeleteAllWebhookSubscriptions(ctx); err != nil {
+				t.Fatalf("cleaning webhooks, got error: %s", err)
+			}
+			return repo
+		})
+	})
+
+	t.Run("idempotency store conformance", func(t *testing.T) {
+		repositorytest.RunIdempotency(t, func(t *testing.T) ports.IdempotencyStore {
+			if err := repo.db.DeleteAllIdempotencyKeys(ctx); err != nil {
+				t.Fatalf("cleaning idempotency keys, got error: %s", err)
+			}
+			return repo
+		})
+	})
+
 	usedUuid := entities.NewID()
-	c, _ := entities.New(usedUuid, "Fulano", "12312312312", "fulanoZZZ@email.com", true)
+	c, _ := entities.New(usedUuid, "Fulano", "12312312387", "fulanoZZZ@email.com", true)
 
 	if err := repo.db.DeleteAllCliente(context.Background()); err != nil {
 		t.Errorf("cleaning db, got error: %s", err)
 	}
 
 	t.Run("listing empty cliente", func(t *testing.T) {
-		cs, err := repo.List()
-		if len(cs) != 0 {
+		cs, err := repo.List(ctx, entities.ListOptions{Limit: 10})
+		if len(cs.Clientes) != 0 {
 			t.Error("should return empty list")
 		}
 		if err != nil {
@@ -70,7 +180,7 @@ func TestWithPostgres(t *testing.T) {
 	})
 
 	t.Run("create cliente", func(t *testing.T) {
-		err = repo.Create(*c)
+		err = repo.Create(ctx, *c)
 		if err != nil {
 			t.Errorf("should not have return any error, got: %s", err)
 		}
@@ -78,15 +188,20 @@ func TestWithPostgres(t *testing.T) {
 	})
 
 	t.Run("list cliente", func(t *testing.T) {
-		_, err := repo.List()
-		if err != nil {
-			t.Errorf("should not have return any error, got: %s", err)
+		for _, sort := range []entities.SortField{entities.SortByName, entities.SortByCPF, entities.SortByCreated} {
+			cs, err := repo.List(ctx, entities.ListOptions{Limit: 10, Sort: sort})
+			if err != nil {
+				t.Errorf("should not have return any error, got: %s", err)
+			}
+			if len(cs.Clientes) != 1 {
+				t.Errorf("should return 1 cliente sorting by %s, got: %d", sort, len(cs.Clientes))
+			}
 		}
 
 	})
 
 	t.Run("get cliente by id", func(t *testing.T) {
-		_, err = repo.GetClienteById(usedUuid)
+		_, err = repo.GetClienteById(ctx, usedUuid)
 		if err != nil {
 			t.Errorf("should not have return any error, got: %s", err)
 		}
@@ -94,7 +209,7 @@ func TestWithPostgres(t *testing.T) {
 	})
 
 	t.Run("get cliente by cpf", func(t *testing.T) {
-		_, err := repo.GetClienteByCPF("12312312312")
+		_, err := repo.GetClienteByCPF(ctx, "12312312387")
 		if err != nil {
 			t.Errorf("should not have return any error, got: %s", err)
 		}
@@ -102,7 +217,7 @@ func TestWithPostgres(t *testing.T) {
 	})
 
 	t.Run("get cliente by email", func(t *testing.T) {
-		_, err = repo.GetClienteByEmail("fulanoZZZ@email.com")
+		_, err = repo.GetClienteByEmail(ctx, "fulanoZZZ@email.com")
 		if err != nil {
 			t.Errorf("should not have return any error, got: %s", err)
 		}
@@ -110,8 +225,8 @@ func TestWithPostgres(t *testing.T) {
 	})
 
 	t.Run("update cliente", func(t *testing.T) {
-		c2, _ := entities.New(c.Id(), "Ciclano", c.CPF(), c.Email(), false)
-		err = repo.Update(*c2)
+		c2, _ := entities.New(c.Id(), "Ciclano", c.CPF(), c.Email(), false, entities.WithVersion(1))
+		_, err = repo.Update(ctx, *c2)
 		if err != nil {
 			t.Errorf("should not have return any error, got: %s", err)
 		}
@@ -119,11 +234,38 @@ func TestWithPostgres(t *testing.T) {
 	})
 
 	t.Run("remove cliente", func(t *testing.T) {
-		err = repo.Remove(c.Id())
+		err = repo.Remove(ctx, c.Id())
 		if err != nil {
 			t.Errorf("should not have return any error, got: %s", err)
 		}
 
 	})
 
+	t.Run("read legacy cliente", func(t *testing.T) {
+		// stored before the check digits were validated
+		legacy := entities.NewID()
+		_, err := repo.pool.Exec(ctx,
+			`INSERT INTO clientes (id, nome, cpf, email, ativo) VALUES ($1, 'Legado', '12312312312', 'legado@email.com', true)`,
+			legacy)
+		if err != nil {
+			t.Fatalf("inserting legacy row, got error: %s", err)
+		}
+
+		got, err := repo.GetClienteById(ctx, legacy)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if got.CPF() != "12312312312" {
+			t.Errorf("want cpf: 12312312312, got: %s", got.CPF())
+		}
+
+		page, err := repo.List(ctx, entities.ListOptions{Limit: 10})
+		if err != nil {
+			t.Errorf("should not have return any error, got: %s", err)
+		}
+		if len(page.Clientes) != 1 {
+			t.Errorf("should list the legacy cliente, got: %d", len(page.Clientes))
+		}
+	})
+
 }
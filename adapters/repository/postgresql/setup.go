@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/url"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/repository/postgresql/db"
@@ -19,6 +20,9 @@ type Config struct {
 }
 
 type Repository struct {
+	pool *pgxpool.Pool
+	// tx is the transaction of a repository given by WithinTx, nil otherwise.
+	tx pgx.Tx
 	db *db.Queries
 }
 
@@ -47,5 +51,5 @@ func New(ctx context.Context, cfg Config) (*Repository, error) {
 
 	repo := db.New(pgxPool)
 
-	return &Repository{repo}, nil
+	return &Repository{pool: pgxPool, db: repo}, nil
 }
@@ -4,14 +4,38 @@
 -- name: GetClienteById :one
 SELECT * FROM clientes WHERE id = $1 LIMIT 1;
 
+-- name: GetClienteByIdForUpdate :one
+SELECT * FROM clientes WHERE id = $1 LIMIT 1 FOR UPDATE;
+
 -- name: GetClienteByCPF :one
 SELECT * FROM clientes WHERE cpf = $1 LIMIT 1;
 
 -- name: GetClienteByEmail :one
-SELECT * FROM clientes WHERE email = $1 LIMIT 1;
+SELECT * FROM clientes WHERE lower(email) = lower(sqlc.arg('email')::text) LIMIT 1;
+
+-- name: ListClientesByName :many
+SELECT * FROM clientes
+WHERE (sqlc.narg('active')::boolean IS NULL OR ativo = sqlc.narg('active'))
+  AND (sqlc.narg('name_prefix')::text IS NULL OR nome ILIKE (sqlc.narg('name_prefix') || '%'))
+  AND (sqlc.narg('after_id')::uuid IS NULL OR (nome, id) > (sqlc.narg('after_key')::text, sqlc.narg('after_id')))
+ORDER BY nome, id
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListClientesByCPF :many
+SELECT * FROM clientes
+WHERE (sqlc.narg('active')::boolean IS NULL OR ativo = sqlc.narg('active'))
+  AND (sqlc.narg('name_prefix')::text IS NULL OR nome ILIKE (sqlc.narg('name_prefix') || '%'))
+  AND (sqlc.narg('after_id')::uuid IS NULL OR (cpf, id) > (sqlc.narg('after_key')::text, sqlc.narg('after_id')))
+ORDER BY cpf, id
+LIMIT sqlc.arg('row_limit');
 
--- name: ListCliente :many
-SELECT * FROM clientes ORDER BY cpf;
+-- name: ListClientesByCreated :many
+SELECT * FROM clientes
+WHERE (sqlc.narg('active')::boolean IS NULL OR ativo = sqlc.narg('active'))
+  AND (sqlc.narg('name_prefix')::text IS NULL OR nome ILIKE (sqlc.narg('name_prefix') || '%'))
+  AND (sqlc.narg('after_id')::uuid IS NULL OR (created_at, id) > (sqlc.narg('after_key')::timestamptz, sqlc.narg('after_id')))
+ORDER BY created_at, id
+LIMIT sqlc.arg('row_limit');
 
 -- name: CreateCliente :one
 INSERT INTO  clientes
@@ -19,13 +43,217 @@ INSERT INTO  clientes
 VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
--- name: UpdateCliente :exec
+-- name: UpdateCliente :one
 UPDATE clientes SET
-(nome, cpf, email, ativo) = ($2, $3, $4, $5)
-WHERE id = $1;
+(nome, cpf, email, ativo, deleted_at, email_verified_at, updated_at, version) = ($2, $3, $4, $5, CASE WHEN $5 THEN NULL ELSE deleted_at END, CASE WHEN lower(email) = lower($4) THEN email_verified_at END, now(), version + 1)
+WHERE id = $1 AND version = $6 AND anonymized_at IS NULL
+RETURNING *;
 
--- name: DeleteCliente :exec
+-- name: DeactivateCliente :one
+UPDATE clientes SET
+(ativo, deleted_at, updated_at, version) = (false, coalesce(deleted_at, now()), now(), version + 1)
+WHERE id = $1
+RETURNING *;
+
+-- name: ReactivateCliente :one
+UPDATE clientes SET
+(ativo, deleted_at, updated_at, version) = (true, NULL, now(), version + 1)
+WHERE id = $1 AND anonymized_at IS NULL
+RETURNING *;
+
+-- name: AnonymizeCliente :one
+UPDATE clientes SET
+(nome, cpf, email, ativo, email_verified_at, anonymized_at, anonymization_reason, updated_at, version) = ($2, $3, $4, false, NULL, $5, $6, now(), version + 1)
+WHERE id = $1 AND version = $7 AND anonymized_at IS NULL
+RETURNING *;
+
+-- name: VerifyClienteEmail :one
+UPDATE clientes SET
+(email_verified_at, updated_at, version) = ($2, now(), version + 1)
+WHERE id = $1 AND lower(email) = lower($3::text) AND anonymized_at IS NULL
+RETURNING *;
+
+-- name: DeleteCliente :execrows
 DELETE FROM clientes WHERE id = $1;
 
 -- name: DeleteAllCliente :exec
-DELETE FROM clientes;
\ No newline at end of file
+DELETE FROM clientes;
+
+-- ----------------------------------------------
+-- Consents
+
+-- name: CreateConsent :one
+INSERT INTO consents
+(cliente_id, purpose, granted, channel, policy_version)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListConsents :many
+SELECT * FROM consents WHERE cliente_id = $1 ORDER BY id;
+
+-- ----------------------------------------------
+-- Audit
+
+-- name: CreateAuditEntry :exec
+INSERT INTO cliente_audit
+(cliente_id, action, changes, actor, request_id)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ListAuditEntries :many
+SELECT * FROM cliente_audit WHERE cliente_id = $1 ORDER BY id;
+
+-- name: RedactAuditEntries :exec
+UPDATE cliente_audit SET changes = (
+    SELECT coalesce(jsonb_agg(
+        CASE WHEN c->>'field' IN ('name', 'cpf', 'email')
+        THEN jsonb_build_object('field', c->'field', 'redacted', true)
+        ELSE c END ORDER BY n), '[]'::jsonb)
+    FROM jsonb_array_elements(changes) WITH ORDINALITY AS t(c, n)
+)
+WHERE cliente_id = $1;
+
+-- ----------------------------------------------
+-- Outbox
+
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox
+(id, type, cliente_id, payload)
+VALUES ($1, $2, $3, $4);
+
+-- name: CreateOutboxPending :exec
+INSERT INTO outbox_pending
+(seq, sink)
+SELECT seq, unnest(sqlc.arg('sinks')::text[]) FROM outbox WHERE id = sqlc.arg('id');
+
+-- name: ClaimPendingOutboxEvents :many
+SELECT o.* FROM outbox_pending p JOIN outbox o ON o.seq = p.seq
+WHERE p.sink = sqlc.arg('sink')
+ORDER BY p.seq
+LIMIT sqlc.arg('row_limit')
+FOR UPDATE OF p SKIP LOCKED;
+
+-- name: MarkOutboxEventPublished :exec
+DELETE FROM outbox_pending WHERE seq = $1 AND sink = $2;
+
+-- name: RedactOutboxEvents :exec
+UPDATE outbox SET payload = payload - '{name,cpf,email}'::text[]
+WHERE cliente_id = $1;
+
+-- name: DeletePublishedOutboxEvents :execrows
+DELETE FROM outbox o
+WHERE occurred_at < $1
+  AND NOT EXISTS (SELECT 1 FROM outbox_pending p WHERE p.seq = o.seq);
+
+-- name: DeleteAllOutboxEvents :exec
+DELETE FROM outbox;
+
+-- ----------------------------------------------
+-- Webhooks
+
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions
+(id, url, events, secret)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListWebhookSubscriptions :many
+SELECT * FROM webhook_subscriptions ORDER BY created_at, id;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscriptions WHERE id = $1;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions WHERE id = $1;
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries
+(id, subscription_id, event_id, event_type, payload, status, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (subscription_id, event_id) DO NOTHING;
+
+-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries SET next_attempt_at = sqlc.arg('until')
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= sqlc.arg('now')
+    ORDER BY next_attempt_at, id
+    LIMIT sqlc.arg('row_limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries WHERE id = $1;
+
+-- name: UpdateWebhookDelivery :execrows
+UPDATE webhook_deliveries SET
+(status, attempts, next_attempt_at, last_status_code, last_error, delivered_at) =
+(sqlc.arg('status'), sqlc.arg('attempts'), sqlc.arg('next_attempt_at'), sqlc.arg('last_status_code'), sqlc.arg('last_error'), sqlc.arg('delivered_at'))
+WHERE id = sqlc.arg('id') AND attempts = sqlc.arg('expected_attempts');
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE subscription_id = sqlc.arg('subscription_id') AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+ORDER BY created_at DESC, id;
+
+-- name: RedactWebhookDeliveries :exec
+UPDATE webhook_deliveries SET payload = jsonb_set(payload, '{data}', (payload -> 'data') - '{name,cpf,email}'::text[])
+WHERE payload #>> '{data,id}' = sqlc.arg('cliente_id')::text;
+
+-- name: DeleteFinishedWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE status <> 'pending' AND created_at < $1;
+
+-- name: DeleteAllWebhookSubscriptions :exec
+DELETE FROM webhook_subscriptions;
+
+-- ----------------------------------------------
+-- Idempotency keys
+
+-- name: ReserveIdempotencyKey :one
+INSERT INTO idempotency_keys
+(subject, key, request_hash, created_at, expires_at, locked_until)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (subject, key) DO UPDATE SET
+(request_hash, status_code, header, body, created_at, expires_at, locked_until) = (EXCLUDED.request_hash, 0, NULL, NULL, EXCLUDED.created_at, EXCLUDED.expires_at, EXCLUDED.locked_until)
+WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+   OR (idempotency_keys.status_code = 0 AND idempotency_keys.locked_until <= EXCLUDED.created_at)
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys WHERE subject = $1 AND key = $2;
+
+-- name: CompleteIdempotencyKey :execrows
+UPDATE idempotency_keys SET
+(status_code, header, body) = ($3, $4, $5)
+WHERE subject = $1 AND key = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE subject = $1 AND key = $2;
+
+-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys WHERE expires_at <= sqlc.arg('now');
+
+-- name: DeleteAllIdempotencyKeys :exec
+DELETE FROM idempotency_keys;
+
+-- ----------------------------------------------
+-- E-mail verifications
+
+-- name: SaveEmailVerification :exec
+INSERT INTO email_verifications
+(cliente_id, email, code_hash, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (cliente_id) DO UPDATE SET
+(email, code_hash, attempts, created_at, expires_at) = (EXCLUDED.email, EXCLUDED.code_hash, 0, EXCLUDED.created_at, EXCLUDED.expires_at);
+
+-- name: GetEmailVerification :one
+SELECT * FROM email_verifications WHERE cliente_id = $1;
+
+-- name: AddEmailVerificationAttempt :one
+UPDATE email_verifications SET attempts = attempts + 1
+WHERE cliente_id = $1
+RETURNING attempts;
+
+-- name: DeleteEmailVerification :exec
+DELETE FROM email_verifications WHERE cliente_id = $1
\ No newline at end of file
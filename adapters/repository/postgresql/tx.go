@@ -0,0 +1,42 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/repository/postgresql/db"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *Repository) WithinTx(ctx context.Context, fn func(repo ports.Repository) error, opts ...ports.TxOption) error {
+	run := func(tx pgx.Tx) error {
+		return fn(&Repository{pool: r.pool, tx: tx, db: r.db.WithTx(tx)})
+	}
+
+	// nested in another WithinTx, a savepoint of the outer transaction
+	if r.tx != nil {
+		return pgx.BeginFunc(ctx, r.tx, run)
+	}
+
+	o := ports.NewTxOptions(opts...)
+	txOpts := pgx.TxOptions{IsoLevel: pgx.TxIsoLevel(o.Isolation)}
+	if o.ReadOnly {
+		txOpts.AccessMode = pgx.ReadOnly
+	}
+
+	return pgx.BeginTxFunc(ctx, r.pool, txOpts, run)
+}
+
+// inTx runs fn in a transaction, committing when it returns no error. Within
+// WithinTx it is a savepoint of that transaction.
+func (r *Repository) inTx(ctx context.Context, fn func(q *db.Queries) error) error {
+	run := func(tx pgx.Tx) error {
+		return fn(r.db.WithTx(tx))
+	}
+
+	if r.tx != nil {
+		return pgx.BeginFunc(ctx, r.tx, run)
+	}
+
+	return pgx.BeginFunc(ctx, r.pool, run)
+}
@@ -0,0 +1,67 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/repository/postgresql/db"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (r *Repository) SaveVerificationCode(ctx context.Context, code entities.VerificationCode) error {
+	err := r.db.SaveEmailVerification(ctx, db.SaveEmailVerificationParams{
+		ClienteID: pgtype.UUID{Bytes: code.ClienteID, Valid: true},
+		Email:     code.Email,
+		CodeHash:  code.CodeHash,
+		CreatedAt: timestamptz(code.CreatedAt),
+		ExpiresAt: timestamptz(code.ExpiresAt),
+	})
+	if err != nil {
+		return fmt.Errorf("saving verification code: %w", translateError(err))
+	}
+
+	return nil
+}
+
+func (r *Repository) GetVerificationCode(ctx context.Context, clienteID entities.ID) (*entities.VerificationCode, error) {
+	row, err := r.db.GetEmailVerification(ctx, pgtype.UUID{Bytes: clienteID, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entityErr.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting verification code: %w", err)
+	}
+
+	return &entities.VerificationCode{
+		ClienteID: row.ClienteID.Bytes,
+		Email:     row.Email,
+		CodeHash:  row.CodeHash,
+		Attempts:  int(row.Attempts),
+		CreatedAt: row.CreatedAt.Time,
+		ExpiresAt: row.ExpiresAt.Time,
+	}, nil
+}
+
+func (r *Repository) AddVerificationAttempt(ctx context.Context, clienteID entities.ID) (int, error) {
+	attempts, err := r.db.AddEmailVerificationAttempt(ctx, pgtype.UUID{Bytes: clienteID, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, entityErr.ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("counting verification attempt: %w", err)
+	}
+
+	return int(attempts), nil
+}
+
+func (r *Repository) DeleteVerificationCode(ctx context.Context, clienteID entities.ID) error {
+	if err := r.db.DeleteEmailVerification(ctx, pgtype.UUID{Bytes: clienteID, Valid: true}); err != nil {
+		return fmt.Errorf("deleting verification code: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/repository/postgresql/db"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (r *Repository) CreateSubscription(ctx context.Context, sub entities.WebhookSubscription) (entities.WebhookSubscription, error) {
+	events := make([]string, 0, len(sub.Events))
+	for _, e := range sub.Events {
+		events = append(events, string(e))
+	}
+
+	s, err := r.db.CreateWebhookSubscription(ctx, db.CreateWebhookSubscriptionParams{
+		ID:     pgtype.UUID{Bytes: sub.ID, Valid: true},
+		Url:    sub.URL,
+		Events: events,
+		Secret: sub.Secret,
+	})
+	if err != nil {
+		return entities.WebhookSubscription{}, fmt.Errorf("creating webhook subscription: %w", err)
+	}
+
+	return subscriptionFromDB(s), nil
+}
+
+func (r *Repository) ListSubscriptions(ctx context.Context) ([]entities.WebhookSubscription, error) {
+	rows, err := r.db.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhook subscriptions: %w", err)
+	}
+
+	subs := make([]entities.WebhookSubscription, 0, len(rows))
+	for _, s := range rows {
+		subs = append(subs, subscriptionFromDB(s))
+	}
+
+	return subs, nil
+}
+
+func (r *Repository) GetSubscription(ctx context.Context, id entities.ID) (*entities.WebhookSubscription, error) {
+	s, err := r.db.GetWebhookSubscription(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entityErr.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting webhook subscription %s: %w", id, err)
+	}
+
+	sub := subscriptionFromDB(s)
+	return &sub, nil
+}
+
+func (r *Repository) DeleteSubscription(ctx context.Context, id entities.ID) error {
+	rows, err := r.db.DeleteWebhookSubscription(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		return fmt.Errorf("deleting webhook subscription %s: %w", id, err)
+	}
+	if rows == 0 {
+		return entityErr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) EnqueueDelivery(ctx context.Context, d entities.WebhookDelivery) error {
+	err := r.db.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+		ID:             pgtype.UUID{Bytes: d.ID, Valid: true},
+		SubscriptionID: pgtype.UUID{Bytes: d.SubscriptionID, Valid: true},
+		EventID:        pgtype.UUID{Bytes: d.EventID, Valid: true},
+		EventType:      string(d.EventType),
+		Payload:        d.Payload,
+		Status:         string(d.Status),
+		NextAttemptAt:  timestamptz(d.NextAttemptAt),
+	})
+	if err != nil {
+		return fmt.Errorf("enqueueing webhook delivery: %w", translateError(err))
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries skips the deliveries another replica is claiming at the
+// same time, with FOR UPDATE SKIP LOCKED.
+func (r *Repository) ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]entities.WebhookDelivery, error) {
+	rows, err := r.db.ClaimDueWebhookDeliveries(ctx, db.ClaimDueWebhookDeliveriesParams{
+		Until:    timestamptz(until),
+		Now:      timestamptz(now),
+		RowLimit: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claiming due webhook deliveries: %w", err)
+	}
+
+	return deliveriesFromDB(rows), nil
+}
+
+func (r *Repository) GetDelivery(ctx context.Context, id entities.ID) (*entities.WebhookDelivery, error) {
+	row, err := r.db.GetWebhookDelivery(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entityErr.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting webhook delivery %s: %w", id, err)
+	}
+
+	d := deliveryFromDB(row)
+	return &d, nil
+}
+
+func (r *Repository) UpdateDelivery(ctx context.Context, d entities.WebhookDelivery, attempts int) error {
+	rows, err := r.db.UpdateWebhookDelivery(ctx, db.UpdateWebhookDeliveryParams{
+		ID:               pgtype.UUID{Bytes: d.ID, Valid: true},
+		Status:           string(d.Status),
+		Attempts:         int32(d.Attempts),
+		NextAttemptAt:    timestamptz(d.NextAttemptAt),
+		LastStatusCode:   int32(d.LastStatusCode),
+		LastError:        d.LastError,
+		DeliveredAt:      timestamptz(d.DeliveredAt),
+		ExpectedAttempts: int32(attempts),
+	})
+	if err != nil {
+		return fmt.Errorf("updating webhook delivery %s: %w", d.ID, err)
+	}
+	if rows == 0 {
+		if _, err := r.GetDelivery(ctx, d.ID); err != nil {
+			return err
+		}
+		return entityErr.ErrConcurrentModification
+	}
+
+	return nil
+}
+
+func (r *Repository) ListDeliveries(ctx context.Context, subscriptionID entities.ID, status entities.DeliveryStatus) ([]entities.WebhookDelivery, error) {
+	rows, err := r.db.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		SubscriptionID: pgtype.UUID{Bytes: subscriptionID, Valid: true},
+		Status:         pgtype.Text{String: string(status), Valid: status != ""},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing deliveries of webhook %s: %w", subscriptionID, err)
+	}
+
+	return deliveriesFromDB(rows), nil
+}
+
+func (r *Repository) DeleteFinishedDeliveries(ctx context.Context, before time.Time) (int, error) {
+	n, err := r.db.DeleteFinishedWebhookDeliveries(ctx, timestamptz(before))
+	if err != nil {
+		return 0, fmt.Errorf("deleting finished webhook deliveries: %w", err)
+	}
+
+	return int(n), nil
+}
+
+// timestamptz maps the zero time to NULL.
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: !t.IsZero()}
+}
+
+func subscriptionFromDB(s db.WebhookSubscription) entities.WebhookSubscription {
+	sub := entities.WebhookSubscription{
+		ID:        s.ID.Bytes,
+		URL:       s.Url,
+		Secret:    s.Secret,
+		CreatedAt: s.CreatedAt.Time,
+	}
+	for _, e := range s.Events {
+		sub.Events = append(sub.Events, entities.EventType(e))
+	}
+
+	return sub
+}
+
+func deliveriesFromDB(rows []db.WebhookDelivery) []entities.WebhookDelivery {
+	deliveries := make([]entities.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, deliveryFromDB(row))
+	}
+
+	return deliveries
+}
+
+func deliveryFromDB(d db.WebhookDelivery) entities.WebhookDelivery {
+	return entities.WebhookDelivery{
+		ID:             d.ID.Bytes,
+		SubscriptionID: d.SubscriptionID.Bytes,
+		EventID:        d.EventID.Bytes,
+		EventType:      entities.EventType(d.EventType),
+		Payload:        d.Payload,
+		Status:         entities.DeliveryStatus(d.Status),
+		Attempts:       int(d.Attempts),
+		NextAttemptAt:  d.NextAttemptAt.Time,
+		LastStatusCode: int(d.LastStatusCode),
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Time,
+		DeliveredAt:    d.DeliveredAt.Time,
+	}
+}
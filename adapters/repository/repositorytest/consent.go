@@ -0,0 +1,93 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+)
+
+// ConsentRepository is an adapter keeping both clientes and their consents.
+type ConsentRepository interface {
+	ports.Repository
+	ports.ConsentRepository
+}
+
+// RunConsents executes the conformance suite of ports.ConsentRepository.
+// newRepo must return an empty repository every time it is called.
+func RunConsents(t *testing.T, newRepo func(t *testing.T) ConsentRepository) {
+	ctx := context.Background()
+
+	t.Run("append and list consents", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("creating cliente, got error: %s", err)
+		}
+
+		records := []entities.ConsentRecord{
+			{ClienteID: c.Id(), Purpose: entities.PurposeMarketingEmail, Granted: true, Channel: entities.ChannelTotem, PolicyVersion: "v1"},
+			{ClienteID: c.Id(), Purpose: entities.PurposeSMS, Granted: true, Channel: entities.ChannelApp, PolicyVersion: "v1"},
+			{ClienteID: c.Id(), Purpose: entities.PurposeMarketingEmail, Granted: false, Channel: entities.ChannelApp},
+		}
+		for _, r := range records {
+			stored, err := repo.AppendConsent(ctx, r)
+			if err != nil {
+				t.Fatalf("should not have return any error, got: %s", err)
+			}
+			if stored.RecordedAt.IsZero() {
+				t.Errorf("should set recorded at")
+			}
+		}
+
+		history, err := repo.ConsentHistory(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if len(history) != len(records) {
+			t.Fatalf("should return %d records, got: %d", len(records), len(history))
+		}
+		for i, r := range history {
+			want := records[i]
+			if r.ClienteID != want.ClienteID || r.Purpose != want.Purpose || r.Granted != want.Granted ||
+				r.Channel != want.Channel || r.PolicyVersion != want.PolicyVersion {
+				t.Errorf("record %d, want: %+v, got: %+v", i, want, r)
+			}
+		}
+	})
+
+	t.Run("append consent of inexistent cliente", func(t *testing.T) {
+		repo := newRepo(t)
+		record := entities.ConsentRecord{ClienteID: entities.NewID(), Purpose: entities.PurposeSMS, Channel: entities.ChannelWeb}
+
+		if _, err := repo.AppendConsent(ctx, record); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("purged cliente takes its consents", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("creating cliente, got error: %s", err)
+		}
+		record := entities.ConsentRecord{ClienteID: c.Id(), Purpose: entities.PurposeSMS, Channel: entities.ChannelWeb}
+		if _, err := repo.AppendConsent(ctx, record); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		if err := repo.Remove(ctx, c.Id()); err != nil {
+			t.Fatalf("removing cliente, got error: %s", err)
+		}
+		history, err := repo.ConsentHistory(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if len(history) != 0 {
+			t.Errorf("should have no consents left, got: %d", len(history))
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+)
+
+// RunIdempotency executes the conformance suite of ports.IdempotencyStore.
+// newStore must return an empty store every time it is called.
+func RunIdempotency(t *testing.T, newStore func(t *testing.T) ports.IdempotencyStore) {
+	ctx := context.Background()
+
+	newRecord := func(key, hash string, now time.Time) entities.IdempotencyRecord {
+		return entities.IdempotencyRecord{Key: key, RequestHash: hash, CreatedAt: now, ExpiresAt: now.Add(time.Hour), LockedUntil: now.Add(time.Minute)}
+	}
+
+	t.Run("reserve, complete and replay", func(t *testing.T) {
+		store := newStore(t)
+		now := time.Now().UTC().Truncate(time.Microsecond)
+
+		rec, reserved, err := store.ReserveKey(ctx, newRecord("key-1", "hash-1", now))
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if !reserved || rec.Completed() {
+			t.Fatalf("should have reserved the key without response, got: %+v", rec)
+		}
+
+		rec.StatusCode = 201
+		rec.Header = map[string]string{"Location": "/v1/clientes/1"}
+		rec.Body = []byte("1")
+		if err := store.CompleteKey(ctx, *rec); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		stored, reserved, err := store.ReserveKey(ctx, newRecord("key-1", "hash-2", now.Add(time.Minute)))
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if reserved {
+			t.Fatal("should not reserve a stored key again")
+		}
+		if stored.RequestHash != "hash-1" || stored.StatusCode != 201 || stored.Header["Location"] != "/v1/clientes/1" || string(stored.Body) != "1" {
+			t.Errorf("should return the first request and its response, got: %+v", stored)
+		}
+		if !stored.ExpiresAt.Equal(now.Add(time.Hour)) {
+			t.Errorf("should keep the expiration of the first request, got: %s", stored.ExpiresAt)
+		}
+	})
+
+	t.Run("reserve key in progress", func(t *testing.T) {
+		store := newStore(t)
+		now := time.Now().UTC()
+
+		if _, _, err := store.ReserveKey(ctx, newRecord("key-1", "hash-1", now)); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		stored, reserved, err := store.ReserveKey(ctx, newRecord("key-1", "hash-1", now))
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if reserved || stored.Completed() {
+			t.Errorf("should return the record in progress, got: %+v", stored)
+		}
+	})
+
+	t.Run("take over key of a lost request", func(t *testing.T) {
+		store := newStore(t)
+		now := time.Now().UTC().Truncate(time.Microsecond)
+
+		if _, _, err := store.ReserveKey(ctx, newRecord("key-1", "hash-1", now)); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if _, reserved, _ := store.ReserveKey(ctx, newRecord("key-1", "hash-1", now.Add(30*time.Second))); reserved {
+			t.Error("should not take over a key still locked")
+		}
+
+		rec, reserved, err := store.ReserveKey(ctx, newRecord("key-1", "hash-1", now.Add(time.Minute)))
+		if err != nil || !reserved {
+			t.Fatalf("should take over the key once the lock expired, got: %t, %v", reserved, err)
+		}
+		if !rec.LockedUntil.Equal(now.Add(2 * time.Minute)) {
+			t.Errorf("should lock the key for the new request, got: %s", rec.LockedUntil)
+		}
+
+		rec.StatusCode = 201
+		if err := store.CompleteKey(ctx, *rec); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if _, reserved, _ := store.ReserveKey(ctx, newRecord("key-1", "hash-1", now.Add(10*time.Minute))); reserved {
+			t.Error("should not take over a completed key")
+		}
+	})
+
+	t.Run("release key", func(t *testing.T) {
+		store := newStore(t)
+		now := time.Now().UTC()
+
+		if _, _, err := store.ReserveKey(ctx, newRecord("key-1", "hash-1", now)); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if err := store.ReleaseKey(ctx, "", "key-1"); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		if _, reserved, err := store.ReserveKey(ctx, newRecord("key-1", "hash-2", now)); err != nil || !reserved {
+			t.Errorf("should reserve a released key, got: %t, %v", reserved, err)
+		}
+	})
+
+	t.Run("keys of other subjects", func(t *testing.T) {
+		store := newStore(t)
+		now := time.Now().UTC()
+
+		fulano, ciclano := newRecord("key-1", "hash-1", now), newRecord("key-1", "hash-2", now)
+		fulano.Subject, ciclano.Subject = "fulano", "ciclano"
+		if _, reserved, err := store.ReserveKey(ctx, fulano); err != nil || !reserved {
+			t.Fatalf("should have reserved the key, got: %t, %v", reserved, err)
+		}
+		rec, reserved, err := store.ReserveKey(ctx, ciclano)
+		if err != nil || !reserved || rec.Subject != "ciclano" || rec.RequestHash != "hash-2" {
+			t.Fatalf("should reserve the same key for another subject, got: %t, %+v, %v", reserved, rec, err)
+		}
+
+		rec.StatusCode = 201
+		if err := store.CompleteKey(ctx, *rec); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if err := store.ReleaseKey(ctx, "fulano", "key-1"); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		stored, reserved, err := store.ReserveKey(ctx, ciclano)
+		if err != nil || reserved || stored.StatusCode != 201 {
+			t.Errorf("should keep the key of the other subject, got: %t, %+v, %v", reserved, stored, err)
+		}
+	})
+
+	t.Run("expired key", func(t *testing.T) {
+		store := newStore(t)
+		now := time.Now().UTC()
+
+		if _, _, err := store.ReserveKey(ctx, newRecord("key-1", "hash-1", now.Add(-2*time.Hour))); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		rec, reserved, err := store.ReserveKey(ctx, newRecord("key-1", "hash-2", now))
+		if err != nil || !reserved || rec.RequestHash != "hash-2" {
+			t.Errorf("should reserve an expired key again, got: %t, %+v, %v", reserved, rec, err)
+		}
+
+		if _, _, err := store.ReserveKey(ctx, newRecord("key-2", "hash-1", now.Add(-2*time.Hour))); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if err := store.DeleteExpiredKeys(ctx, now); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if _, reserved, _ := store.ReserveKey(ctx, newRecord("key-1", "hash-3", now)); reserved {
+			t.Error("should keep the unexpired key")
+		}
+	})
+}
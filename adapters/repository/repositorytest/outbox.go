@@ -0,0 +1,316 @@
+package repositorytest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+)
+
+// OutboxRepository is an adapter keeping both clientes and the events their
+// mutations raise.
+type OutboxRepository interface {
+	ports.Repository
+	ports.Outbox
+}
+
+// RunOutbox executes the conformance suite of ports.Outbox. newRepo must
+// return an empty repository, with an empty outbox, every time it is called.
+func RunOutbox(t *testing.T, newRepo func(t *testing.T) OutboxRepository) {
+	ctx := context.Background()
+
+	t.Run("events are written with the transaction", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		created := mustCreateRaising(t, repo, c)
+
+		// a rolled back mutation takes its event with it
+		broken := errors.New("rolled back")
+		err := repo.WithinTx(ctx, func(tx ports.Repository) error {
+			update := mustClienteWithID(t, c.Id(), "Ciclano", c.CPF(), c.Email(), true, entities.WithVersion(1))
+			updated, err := tx.Update(ctx, *update)
+			if err != nil {
+				return err
+			}
+			if err := writeEvent(ctx, tx, entities.AuditUpdated, updated); err != nil {
+				return err
+			}
+			return broken
+		})
+		if !errors.Is(err, broken) {
+			t.Fatalf("want: %s, got: %v", broken, err)
+		}
+
+		err = repo.WithinTx(ctx, func(tx ports.Repository) error {
+			return writeEvent(ctx, tx, entities.AuditUpdated, c)
+		}, ports.ReadOnly())
+		if err == nil {
+			t.Error("should not write events in a read-only transaction")
+		}
+
+		events := publishAll(t, repo)
+		if len(events) != 1 || events[0].ID != created.ID {
+			t.Fatalf("should only have the created event, got: %+v", events)
+		}
+		if events[0].Type != entities.ClienteCreated || events[0].ClienteID != c.Id() {
+			t.Errorf("want %s of cliente %s, got: %s of %s", entities.ClienteCreated, c.Id(), events[0].Type, events[0].ClienteID)
+		}
+		if events[0].OccurredAt.IsZero() {
+			t.Error("should set when it occurred")
+		}
+
+		var data entities.ClienteEventData
+		if err := json.Unmarshal(events[0].Payload, &data); err != nil {
+			t.Fatalf("decoding payload, got error: %s", err)
+		}
+		if data.Name != "Fulano" || data.Version != 1 {
+			t.Errorf("should carry the stored cliente, got: %+v", data)
+		}
+	})
+
+	t.Run("publish pending events", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, c := range []*entities.Cliente{
+			mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true),
+			mustCliente(t, "Ciclano", "22255588846", "ciclano@email.com", true),
+			mustCliente(t, "Beltrano", "33366699957", "beltrano@email.com", true),
+		} {
+			mustCreateRaising(t, repo, c)
+		}
+
+		var first []entities.ID
+		n, err := repo.PublishPending(ctx, entities.SinkBroker, 1, func(ctx context.Context, e entities.Event) error {
+			first = append(first, e.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if n != 1 || len(first) != 1 {
+			t.Fatalf("should respect the limit, got: %d", n)
+		}
+
+		broken := errors.New("broker unavailable")
+		var second []entities.ID
+		n, err = repo.PublishPending(ctx, entities.SinkBroker, 10, func(ctx context.Context, e entities.Event) error {
+			second = append(second, e.ID)
+			if len(second) == 2 {
+				return broken
+			}
+			return nil
+		})
+		if !errors.Is(err, broken) {
+			t.Fatalf("want: %s, got: %v", broken, err)
+		}
+		if n != 1 || slices.Contains(second, first[0]) {
+			t.Fatalf("should have published the second event only, got %d: %v", n, second)
+		}
+
+		// the failed event and the ones after it are still pending
+		pending := publishAll(t, repo)
+		if len(pending) != 1 || pending[0].ID != second[1] {
+			t.Errorf("should only have the third event pending, got: %+v", pending)
+		}
+	})
+
+	t.Run("claimed events are skipped", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, c := range []*entities.Cliente{
+			mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true),
+			mustCliente(t, "Ciclano", "22255588846", "ciclano@email.com", true),
+		} {
+			mustCreateRaising(t, repo, c)
+		}
+
+		// another relay publishes while the first still holds its claim
+		var outer, inner []entities.ID
+		_, err := repo.PublishPending(ctx, entities.SinkBroker, 1, func(ctx context.Context, e entities.Event) error {
+			outer = append(outer, e.ID)
+			_, err := repo.PublishPending(ctx, entities.SinkBroker, 10, func(ctx context.Context, e entities.Event) error {
+				inner = append(inner, e.ID)
+				return nil
+			})
+			return err
+		})
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if len(outer) != 1 || slices.Contains(inner, outer[0]) {
+			t.Errorf("should not have published the claimed event twice, got: %v and %v", outer, inner)
+		}
+
+		left := publishAll(t, repo)
+		if published := len(outer) + len(inner) + len(left); published != 2 {
+			t.Errorf("should have published every event once, got: %d", published)
+		}
+	})
+
+	t.Run("sinks are published apart", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		mustCreateRaising(t, repo, c)
+
+		// the webhooks are published while the broker holds its claim and
+		// fails
+		broken := errors.New("broker unavailable")
+		var webhooks []entities.Event
+		_, err := repo.PublishPending(ctx, entities.SinkBroker, 10, func(ctx context.Context, e entities.Event) error {
+			webhooks = publishAllTo(t, repo, entities.SinkWebhooks)
+			return broken
+		})
+		if !errors.Is(err, broken) {
+			t.Fatalf("want: %s, got: %v", broken, err)
+		}
+		if len(webhooks) != 1 {
+			t.Fatalf("should have published the event to the webhooks, got: %d", len(webhooks))
+		}
+
+		if pending := publishAllTo(t, repo, entities.SinkWebhooks); len(pending) != 0 {
+			t.Errorf("should have nothing pending for the webhooks, got: %d", len(pending))
+		}
+		if pending := publishAll(t, repo); len(pending) != 1 || pending[0].ID != webhooks[0].ID {
+			t.Errorf("should still have the event pending for the broker, got: %+v", pending)
+		}
+	})
+
+	t.Run("anonymization redacts events", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		mustCreateRaising(t, repo, c)
+		stored, err := repo.GetClienteById(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("reading cliente, got error: %s", err)
+		}
+		anonymous, err := stored.Anonymize(time.Now().UTC(), "lgpd request")
+		if err != nil {
+			t.Fatalf("anonymizing cliente, got error: %s", err)
+		}
+		if _, err := repo.Anonymize(ctx, *anonymous); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		events := publishAll(t, repo)
+		if len(events) != 1 {
+			t.Fatalf("want 1 event, got: %d", len(events))
+		}
+		for _, e := range events {
+			for _, personal := range []string{"Fulano", c.CPF(), c.Email()} {
+				if strings.Contains(string(e.Payload), personal) {
+					t.Errorf("%s event should not keep %q, got: %s", e.Type, personal, e.Payload)
+				}
+			}
+		}
+	})
+
+	t.Run("purge redacts events", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		mustCreateRaising(t, repo, c)
+		if err := repo.Remove(ctx, c.Id()); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		events := publishAll(t, repo)
+		if len(events) != 1 {
+			t.Fatalf("want 1 event, got: %d", len(events))
+		}
+		for _, personal := range []string{"Fulano", c.CPF(), c.Email()} {
+			if strings.Contains(string(events[0].Payload), personal) {
+				t.Errorf("%s event should not keep %q, got: %s", events[0].Type, personal, events[0].Payload)
+			}
+		}
+	})
+
+	t.Run("delete published events", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, c := range []*entities.Cliente{
+			mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true),
+			mustCliente(t, "Ciclano", "22255588846", "ciclano@email.com", true),
+		} {
+			mustCreateRaising(t, repo, c)
+		}
+		if _, err := repo.PublishPending(ctx, entities.SinkBroker, 1, func(context.Context, entities.Event) error { return nil }); err != nil {
+			t.Fatalf("publishing, got error: %s", err)
+		}
+		if n, err := repo.DeletePublished(ctx, time.Now().Add(time.Hour)); err != nil || n != 0 {
+			t.Errorf("should keep the event pending for the webhooks, got %d: %v", n, err)
+		}
+		if _, err := repo.PublishPending(ctx, entities.SinkWebhooks, 1, func(context.Context, entities.Event) error { return nil }); err != nil {
+			t.Fatalf("publishing, got error: %s", err)
+		}
+
+		if n, err := repo.DeletePublished(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+			t.Errorf("should keep the events within the retention, got %d: %v", n, err)
+		}
+		if n, err := repo.DeletePublished(ctx, time.Now().Add(time.Hour)); err != nil || n != 1 {
+			t.Errorf("should have deleted the published event, got %d: %v", n, err)
+		}
+		if pending := publishAll(t, repo); len(pending) != 1 {
+			t.Errorf("should keep the pending event, got: %d", len(pending))
+		}
+	})
+}
+
+// mustCreateRaising creates the cliente and writes its event in the same
+// transaction, as the service does.
+func mustCreateRaising(t *testing.T, repo ports.Repository, c *entities.Cliente) entities.Event {
+	t.Helper()
+
+	var event entities.Event
+	err := repo.WithinTx(context.Background(), func(tx ports.Repository) error {
+		if err := tx.Create(context.Background(), *c); err != nil {
+			return err
+		}
+		stored, err := tx.GetClienteById(context.Background(), c.Id())
+		if err != nil {
+			return err
+		}
+		if event, err = entities.NewClienteEvent(entities.AuditCreated, nil, stored); err != nil {
+			return err
+		}
+		return tx.WriteEvent(context.Background(), event)
+	})
+	if err != nil {
+		t.Fatalf("creating cliente, got error: %s", err)
+	}
+
+	return event
+}
+
+// writeEvent writes the event of the change of the cliente.
+func writeEvent(ctx context.Context, repo ports.Repository, action entities.AuditAction, c *entities.Cliente) error {
+	event, err := entities.NewClienteEvent(action, nil, c)
+	if err != nil {
+		return err
+	}
+
+	return repo.WriteEvent(ctx, event)
+}
+
+// publishAll publishes and returns every event pending for the broker.
+func publishAll(t *testing.T, repo ports.Outbox) []entities.Event {
+	t.Helper()
+
+	return publishAllTo(t, repo, entities.SinkBroker)
+}
+
+// publishAllTo publishes and returns every event pending for sink.
+func publishAllTo(t *testing.T, repo ports.Outbox, sink entities.EventSink) []entities.Event {
+	t.Helper()
+
+	var events []entities.Event
+	if _, err := repo.PublishPending(context.Background(), sink, 100, func(ctx context.Context, e entities.Event) error {
+		events = append(events, e)
+		return nil
+	}); err != nil {
+		t.Fatalf("publishing pending events, got error: %s", err)
+	}
+
+	return events
+}
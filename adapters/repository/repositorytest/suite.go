@@ -0,0 +1,766 @@
+// Package repositorytest holds the behaviour every ports.Repository adapter
+// must share. Adapters run it from their own tests so they cannot drift.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+)
+
+// Run executes the conformance suite. newRepo must return an empty
+// repository every time it is called.
+func Run(t *testing.T, newRepo func(t *testing.T) ports.Repository) {
+	ctx := context.Background()
+
+	t.Run("create and get cliente", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		byID, err := repo.GetClienteById(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("getting by id, got error: %s", err)
+		}
+		assertSameCliente(t, byID, c)
+
+		byCPF, err := repo.GetClienteByCPF(ctx, c.CPF())
+		if err != nil {
+			t.Fatalf("getting by cpf, got error: %s", err)
+		}
+		assertSameCliente(t, byCPF, c)
+
+		byEmail, err := repo.GetClienteByEmail(ctx, c.Email())
+		if err != nil {
+			t.Fatalf("getting by email, got error: %s", err)
+		}
+		assertSameCliente(t, byEmail, c)
+	})
+
+	t.Run("create cliente with existent id", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		c2 := mustClienteWithID(t, c.Id(), "Ciclano", "22255588846", "ciclano@email.com", true)
+
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if err := repo.Create(ctx, *c2); !errors.Is(err, entityErr.ErrClienteAlreadyExistsForID) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrClienteAlreadyExistsForID, err)
+		}
+	})
+
+	t.Run("create cliente with existent cpf", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		c2 := mustCliente(t, "Ciclano", c.CPF(), "ciclano@email.com", true)
+
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if err := repo.Create(ctx, *c2); !errors.Is(err, entityErr.ErrClienteAlreadyExistsForCPF) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrClienteAlreadyExistsForCPF, err)
+		}
+	})
+
+	t.Run("create cliente with existent email in other case", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		c2 := mustCliente(t, "Ciclano", "22255588846", "Fulano@Email.com", true)
+
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if err := repo.Create(ctx, *c2); !errors.Is(err, entityErr.ErrClienteAlreadyExistsForEmail) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrClienteAlreadyExistsForEmail, err)
+		}
+	})
+
+	t.Run("concurrent create with same cpf", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var (
+			wg      sync.WaitGroup
+			created atomic.Int32
+		)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				c, _ := entities.New(entities.NewID(), "Fulano", "11144477735", fmt.Sprintf("fulano%d@email.com", i), true)
+				err := repo.Create(ctx, *c)
+				if err == nil {
+					created.Add(1)
+					return
+				}
+				if !errors.Is(err, entityErr.ErrClienteAlreadyExistsForCPF) {
+					t.Errorf("want: %s, got: %s", entityErr.ErrClienteAlreadyExistsForCPF, err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if n := created.Load(); n != 1 {
+			t.Errorf("should have created only 1 cliente, got: %d", n)
+		}
+	})
+
+	t.Run("get cliente by email ignoring case", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		got, err := repo.GetClienteByEmail(ctx, "FULANO@email.com")
+		if err != nil {
+			t.Fatalf("getting by email, got error: %s", err)
+		}
+		assertSameCliente(t, got, c)
+	})
+
+	t.Run("update cliente with existent cpf", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		c2 := mustCliente(t, "Ciclano", "22255588846", "ciclano@email.com", true)
+		for _, cliente := range []*entities.Cliente{c, c2} {
+			if err := repo.Create(ctx, *cliente); err != nil {
+				t.Fatalf("should not have return any error, got: %s", err)
+			}
+		}
+
+		c3 := mustClienteWithID(t, c2.Id(), c2.Name(), c.CPF(), c2.Email(), true, entities.WithVersion(1))
+		if _, err := repo.Update(ctx, *c3); !errors.Is(err, entityErr.ErrClienteAlreadyExistsForCPF) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrClienteAlreadyExistsForCPF, err)
+		}
+	})
+
+	t.Run("get inexistent cliente", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.GetClienteById(ctx, entities.NewID()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("getting by id, want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+		if _, err := repo.GetClienteByCPF(ctx, "11144477735"); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("getting by cpf, want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+		if _, err := repo.GetClienteByEmail(ctx, "fulano@email.com"); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("getting by email, want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("create cliente sets audit fields", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		got, err := repo.GetClienteById(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("getting by id, got error: %s", err)
+		}
+		if got.Version() != 1 {
+			t.Errorf("should start at version 1, got: %d", got.Version())
+		}
+		if got.CreatedAt().IsZero() || !got.UpdatedAt().Equal(got.CreatedAt()) {
+			t.Errorf("should set created and updated at, got: %s and %s", got.CreatedAt(), got.UpdatedAt())
+		}
+	})
+
+	t.Run("update cliente", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		stored, err := repo.GetClienteById(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("getting by id, got error: %s", err)
+		}
+
+		c2 := mustClienteWithID(t, c.Id(), "Ciclano", c.CPF(), "ciclano@email.com", false, entities.WithVersion(stored.Version()))
+		updated, err := repo.Update(ctx, *c2)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		assertSameCliente(t, updated, c2)
+		if updated.Version() != stored.Version()+1 {
+			t.Errorf("should bump version, want: %d, got: %d", stored.Version()+1, updated.Version())
+		}
+		if !updated.CreatedAt().Equal(stored.CreatedAt()) || updated.UpdatedAt().Before(stored.UpdatedAt()) {
+			t.Errorf("should keep created at and move updated at, got: %s and %s", updated.CreatedAt(), updated.UpdatedAt())
+		}
+
+		got, err := repo.GetClienteById(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("getting by id, got error: %s", err)
+		}
+		assertSameCliente(t, got, c2)
+		if got.Version() != updated.Version() {
+			t.Errorf("should have stored version %d, got: %d", updated.Version(), got.Version())
+		}
+	})
+
+	t.Run("update cliente with stale version", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		first := mustClienteWithID(t, c.Id(), "Ciclano", c.CPF(), c.Email(), true, entities.WithVersion(1))
+		if _, err := repo.Update(ctx, *first); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		second := mustClienteWithID(t, c.Id(), "Beltrano", c.CPF(), c.Email(), true, entities.WithVersion(1))
+		if _, err := repo.Update(ctx, *second); !errors.Is(err, entityErr.ErrConcurrentModification) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrConcurrentModification, err)
+		}
+	})
+
+	t.Run("update inexistent cliente", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+
+		if _, err := repo.Update(ctx, *c); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("deactivate and reactivate cliente", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		deactivated, err := repo.Deactivate(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if deactivated.Active() || deactivated.DeletedAt().IsZero() {
+			t.Errorf("should be inactive with deleted at, got: %t and %s", deactivated.Active(), deactivated.DeletedAt())
+		}
+		if deactivated.Version() != 2 {
+			t.Errorf("should bump version, want: 2, got: %d", deactivated.Version())
+		}
+
+		again, err := repo.Deactivate(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if !again.DeletedAt().Equal(deactivated.DeletedAt()) {
+			t.Errorf("should keep the first deleted at, want: %s, got: %s", deactivated.DeletedAt(), again.DeletedAt())
+		}
+
+		reactivated, err := repo.Reactivate(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if !reactivated.Active() || !reactivated.DeletedAt().IsZero() {
+			t.Errorf("should be active without deleted at, got: %t and %s", reactivated.Active(), reactivated.DeletedAt())
+		}
+	})
+
+	t.Run("update activating a deactivated cliente", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		deactivated, err := repo.Deactivate(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		renamed := mustClienteWithID(t, c.Id(), "Ciclano", c.CPF(), c.Email(), false, entities.WithVersion(deactivated.Version()))
+		updated, err := repo.Update(ctx, *renamed)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if !updated.DeletedAt().Equal(deactivated.DeletedAt()) {
+			t.Errorf("should keep deleted at while inactive, want: %s, got: %s", deactivated.DeletedAt(), updated.DeletedAt())
+		}
+
+		activated := mustClienteWithID(t, c.Id(), "Ciclano", c.CPF(), c.Email(), true, entities.WithVersion(updated.Version()))
+		updated, err = repo.Update(ctx, *activated)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if !updated.DeletedAt().IsZero() {
+			t.Errorf("should clear deleted at, got: %s", updated.DeletedAt())
+		}
+	})
+
+	t.Run("deactivate and reactivate inexistent cliente", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.Deactivate(ctx, entities.NewID()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+		if _, err := repo.Reactivate(ctx, entities.NewID()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("anonymize cliente", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		stored, err := repo.GetClienteById(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("getting by id, got error: %s", err)
+		}
+
+		anonymous, err := stored.Anonymize(time.Now().UTC().Truncate(time.Microsecond), "lgpd request")
+		if err != nil {
+			t.Fatalf("anonymizing, got error: %s", err)
+		}
+		got, err := repo.Anonymize(ctx, *anonymous)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		assertSameCliente(t, got, anonymous)
+		if !got.AnonymizedAt().Equal(anonymous.AnonymizedAt()) || got.AnonymizationReason() != "lgpd request" {
+			t.Errorf("should record when and why, got: %s and %q", got.AnonymizedAt(), got.AnonymizationReason())
+		}
+		if _, err := repo.GetClienteByCPF(ctx, c.CPF()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("should not find the cliente by its old cpf, got: %v", err)
+		}
+
+		update := mustClienteWithID(t, c.Id(), "Ciclano", c.CPF(), c.Email(), true, entities.WithVersion(got.Version()))
+		if _, err := repo.Update(ctx, *update); !errors.Is(err, entityErr.ErrClienteAnonymized) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrClienteAnonymized, err)
+		}
+		if _, err := repo.Reactivate(ctx, c.Id()); !errors.Is(err, entityErr.ErrClienteAnonymized) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrClienteAnonymized, err)
+		}
+		if _, err := repo.Anonymize(ctx, *anonymous); !errors.Is(err, entityErr.ErrClienteAnonymized) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrClienteAnonymized, err)
+		}
+
+		deactivated, err := repo.Deactivate(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if !deactivated.Anonymized() {
+			t.Errorf("should stay anonymized once deactivated")
+		}
+	})
+
+	t.Run("anonymize cliente with stale version", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		anonymous, err := c.Anonymize(time.Now().UTC(), "lgpd request")
+		if err != nil {
+			t.Fatalf("anonymizing, got error: %s", err)
+		}
+		if _, err := repo.Anonymize(ctx, *anonymous); !errors.Is(err, entityErr.ErrConcurrentModification) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrConcurrentModification, err)
+		}
+	})
+
+	t.Run("remove cliente", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		if err := repo.Remove(ctx, c.Id()); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if _, err := repo.GetClienteById(ctx, c.Id()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("remove inexistent cliente", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.Remove(ctx, entities.NewID()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("history of cliente", func(t *testing.T) {
+		repo := newRepo(t)
+		actx := entities.WithAuditMeta(ctx, entities.AuditMeta{Actor: "atendente", RequestID: "req-1"})
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(actx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		update := mustClienteWithID(t, c.Id(), "Ciclano", c.CPF(), c.Email(), true, entities.WithVersion(1))
+		if _, err := repo.Update(actx, *update); err != nil {
+			t.Fatalf("updating, got error: %s", err)
+		}
+		if _, err := repo.Update(actx, *update); !errors.Is(err, entityErr.ErrConcurrentModification) {
+			t.Fatalf("want: %s, got: %v", entityErr.ErrConcurrentModification, err)
+		}
+		if _, err := repo.Deactivate(actx, c.Id()); err != nil {
+			t.Fatalf("deactivating, got error: %s", err)
+		}
+		if _, err := repo.Reactivate(actx, c.Id()); err != nil {
+			t.Fatalf("reactivating, got error: %s", err)
+		}
+
+		history, err := repo.History(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		updated := history[1].Changes
+		if len(updated) != 1 || updated[0] != (entities.FieldChange{Field: "name", Before: "Fulano", After: "Ciclano"}) {
+			t.Errorf("should record the name change only, got: %+v", updated)
+		}
+
+		if err := repo.Remove(actx, c.Id()); err != nil {
+			t.Fatalf("removing, got error: %s", err)
+		}
+		if history, err = repo.History(ctx, c.Id()); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		want := []entities.AuditAction{
+			entities.AuditCreated, entities.AuditUpdated, entities.AuditDeactivated,
+			entities.AuditReactivated, entities.AuditDeleted,
+		}
+		if len(history) != len(want) {
+			t.Fatalf("want %d entries, got: %d", len(want), len(history))
+		}
+		for i, entry := range history {
+			if entry.Action != want[i] {
+				t.Errorf("entry %d: want action %s, got: %s", i, want[i], entry.Action)
+			}
+			if entry.ClienteID != c.Id() || entry.Actor != "atendente" || entry.RequestID != "req-1" {
+				t.Errorf("entry %d: should keep cliente, actor and request id, got: %+v", i, entry)
+			}
+			if entry.RecordedAt.IsZero() {
+				t.Errorf("entry %d: should set the recording time", i)
+			}
+		}
+	})
+
+	t.Run("history redacted on anonymize", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		stored, err := repo.GetClienteById(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("getting by id, got error: %s", err)
+		}
+		anonymous, err := stored.Anonymize(time.Now().UTC(), "lgpd request")
+		if err != nil {
+			t.Fatalf("anonymizing, got error: %s", err)
+		}
+		if _, err := repo.Anonymize(ctx, *anonymous); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		history, err := repo.History(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if len(history) != 2 || history[1].Action != entities.AuditAnonymized {
+			t.Fatalf("want created and anonymized entries, got: %+v", history)
+		}
+		for _, entry := range history {
+			for _, change := range entry.Changes {
+				for _, personal := range []string{c.Name(), c.CPF(), c.Email(), anonymous.CPF(), anonymous.Email()} {
+					if change.Before == personal || change.After == personal {
+						t.Errorf("%s entry should not keep %q", entry.Action, personal)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("history redacted on purge", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		update := mustClienteWithID(t, c.Id(), "Ciclano", c.CPF(), "ciclano@email.com", true, entities.WithVersion(1))
+		if _, err := repo.Update(ctx, *update); err != nil {
+			t.Fatalf("updating, got error: %s", err)
+		}
+		if err := repo.Remove(ctx, c.Id()); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		history, err := repo.History(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if len(history) != 3 || history[2].Action != entities.AuditDeleted {
+			t.Fatalf("want created, updated and deleted entries, got: %+v", history)
+		}
+		for _, entry := range history {
+			for _, change := range entry.Changes {
+				for _, personal := range []string{c.Name(), c.CPF(), c.Email(), update.Name(), update.Email()} {
+					if change.Before == personal || change.After == personal {
+						t.Errorf("%s entry should not keep %q", entry.Action, personal)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("within tx commits", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+
+		err := repo.WithinTx(ctx, func(tx ports.Repository) error {
+			if err := tx.Create(ctx, *c); err != nil {
+				return err
+			}
+			_, err := tx.Deactivate(ctx, c.Id())
+			return err
+		}, ports.WithIsolation(ports.Serializable))
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		stored, err := repo.GetClienteById(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("getting by id, got error: %s", err)
+		}
+		if stored.Active() {
+			t.Error("should have committed the deactivation")
+		}
+	})
+
+	t.Run("within tx rolls back on error", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		errAbort := errors.New("abort")
+
+		err := repo.WithinTx(ctx, func(tx ports.Repository) error {
+			if err := tx.Create(ctx, *c); err != nil {
+				return err
+			}
+			if _, err := tx.GetClienteById(ctx, c.Id()); err != nil {
+				t.Errorf("should see its own writes, got: %s", err)
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("want: %s, got: %v", errAbort, err)
+		}
+
+		if _, err := repo.GetClienteById(ctx, c.Id()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+		if history, _ := repo.History(ctx, c.Id()); len(history) != 0 {
+			t.Errorf("should have rolled back the history too, got: %+v", history)
+		}
+	})
+
+	t.Run("nested within tx rolls back alone", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		c2 := mustCliente(t, "Ciclano", "22255588846", "ciclano@email.com", true)
+		errAbort := errors.New("abort")
+
+		err := repo.WithinTx(ctx, func(tx ports.Repository) error {
+			if err := tx.Create(ctx, *c); err != nil {
+				return err
+			}
+			err := tx.WithinTx(ctx, func(inner ports.Repository) error {
+				if err := inner.Create(ctx, *c2); err != nil {
+					return err
+				}
+				return errAbort
+			})
+			if !errors.Is(err, errAbort) {
+				return fmt.Errorf("want: %s, got: %v", errAbort, err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		if _, err := repo.GetClienteById(ctx, c.Id()); err != nil {
+			t.Errorf("should have committed the outer create, got: %s", err)
+		}
+		if _, err := repo.GetClienteById(ctx, c2.Id()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("read-only within tx refuses writes", func(t *testing.T) {
+		repo := newRepo(t)
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("creating cliente, got error: %s", err)
+		}
+		c2 := mustCliente(t, "Ciclano", "22255588846", "ciclano@email.com", true)
+
+		err := repo.WithinTx(ctx, func(tx ports.Repository) error {
+			if _, err := tx.GetClienteById(ctx, c.Id()); err != nil {
+				t.Errorf("should read in a read-only tx, got: %s", err)
+			}
+			return tx.Create(ctx, *c2)
+		}, ports.ReadOnly())
+		if err == nil {
+			t.Fatal("should have refused the write")
+		}
+
+		err = repo.WithinTx(ctx, func(tx ports.Repository) error {
+			_, err := tx.Deactivate(ctx, c.Id())
+			return err
+		}, ports.ReadOnly())
+		if err == nil {
+			t.Fatal("should have refused the write")
+		}
+
+		if _, err := repo.GetClienteById(ctx, c2.Id()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+		if stored, _ := repo.GetClienteById(ctx, c.Id()); stored == nil || !stored.Active() {
+			t.Errorf("should not have deactivated the cliente, got: %+v", stored)
+		}
+	})
+
+	t.Run("list clientes", func(t *testing.T) {
+		repo := newRepo(t)
+
+		// created in an order that differs from both name and cpf order
+		clientes := []*entities.Cliente{
+			mustCliente(t, "Carla Dias", "44477700083", "carla@email.com", true),
+			mustCliente(t, "Ana Souza", "33366699957", "ana@email.com", true),
+			mustCliente(t, "Eduardo Reis", "11144477735", "eduardo@email.com", false),
+			mustCliente(t, "Bruno Lima", "55588811194", "bruno@email.com", true),
+			mustCliente(t, "Daniela Alves", "22255588846", "daniela@email.com", true),
+		}
+		for _, c := range clientes {
+			if err := repo.Create(ctx, *c); err != nil {
+				t.Fatalf("creating cliente, got error: %s", err)
+			}
+		}
+
+		tests := []struct {
+			name string
+			opts entities.ListOptions
+			want []string
+		}{
+			{"by cpf", entities.ListOptions{Sort: entities.SortByCPF}, []string{"Eduardo Reis", "Daniela Alves", "Ana Souza", "Carla Dias", "Bruno Lima"}},
+			{"by name", entities.ListOptions{Sort: entities.SortByName}, []string{"Ana Souza", "Bruno Lima", "Carla Dias", "Daniela Alves", "Eduardo Reis"}},
+			{"by created", entities.ListOptions{Sort: entities.SortByCreated}, []string{"Carla Dias", "Ana Souza", "Eduardo Reis", "Bruno Lima", "Daniela Alves"}},
+			{"only active", entities.ListOptions{Sort: entities.SortByName, Active: ptr(true)}, []string{"Ana Souza", "Bruno Lima", "Carla Dias", "Daniela Alves"}},
+			{"only inactive", entities.ListOptions{Sort: entities.SortByName, Active: ptr(false)}, []string{"Eduardo Reis"}},
+			{"name prefix", entities.ListOptions{Sort: entities.SortByName, NamePrefix: "da"}, []string{"Daniela Alves"}},
+			{"name prefix with wildcard", entities.ListOptions{Sort: entities.SortByName, NamePrefix: "%a"}, nil},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				for _, limit := range []int{1, 2, 10} {
+					opts := tt.opts
+					opts.Limit = limit
+
+					got := listAll(t, repo, opts)
+					if !equal(got, tt.want) {
+						t.Errorf("limit %d: want: %v, got: %v", limit, tt.want, got)
+					}
+				}
+			})
+		}
+	})
+}
+
+// listAll follows the cursors until the last page and returns the names seen.
+func listAll(t *testing.T, repo ports.Repository, opts entities.ListOptions) []string {
+	t.Helper()
+
+	var names []string
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("should have stopped paginating")
+		}
+
+		page, err := repo.List(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("listing clientes, got error: %s", err)
+		}
+		if len(page.Clientes) > opts.Limit {
+			t.Fatalf("page has %d clientes, limit is %d", len(page.Clientes), opts.Limit)
+		}
+
+		for _, c := range page.Clientes {
+			names = append(names, c.Name())
+		}
+
+		if page.Next == nil {
+			return names
+		}
+		opts.After = page.Next
+	}
+}
+
+func mustCliente(t *testing.T, name, cpf, email string, active bool) *entities.Cliente {
+	t.Helper()
+	return mustClienteWithID(t, entities.NewID(), name, cpf, email, active)
+}
+
+func mustClienteWithID(t *testing.T, id entities.ID, name, cpf, email string, active bool, opts ...entities.Option) *entities.Cliente {
+	t.Helper()
+
+	c, err := entities.New(id, name, cpf, email, active, opts...)
+	if err != nil {
+		t.Fatalf("building cliente, got error: %s", err)
+	}
+
+	return c
+}
+
+func assertSameCliente(t *testing.T, got, want *entities.Cliente) {
+	t.Helper()
+
+	if got.Id() != want.Id() || got.Name() != want.Name() || got.CPF() != want.CPF() ||
+		got.Email() != want.Email() || got.Active() != want.Active() {
+		t.Errorf("want: %+v, got: %+v", *want, *got)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,239 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+)
+
+// VerificationRepository is an adapter keeping both clientes and the codes
+// that verify their e-mails.
+type VerificationRepository interface {
+	ports.Repository
+	ports.VerificationStore
+}
+
+// RunVerification executes the conformance suite of ports.VerificationStore
+// and of the e-mail verification of ports.Repository. newRepo must return an
+// empty repository every time it is called.
+func RunVerification(t *testing.T, newRepo func(t *testing.T) VerificationRepository) {
+	ctx := context.Background()
+
+	create := func(t *testing.T, repo VerificationRepository) *entities.Cliente {
+		t.Helper()
+		c := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		if err := repo.Create(ctx, *c); err != nil {
+			t.Fatalf("creating cliente, got error: %s", err)
+		}
+		return c
+	}
+
+	newCode := func(c *entities.Cliente, hash string) entities.VerificationCode {
+		now := time.Now().UTC().Truncate(time.Microsecond)
+		return entities.VerificationCode{
+			ClienteID: c.Id(),
+			Email:     c.Email(),
+			CodeHash:  hash,
+			CreatedAt: now,
+			ExpiresAt: now.Add(15 * time.Minute),
+		}
+	}
+
+	t.Run("save and get code", func(t *testing.T) {
+		repo := newRepo(t)
+		c := create(t, repo)
+
+		code := newCode(c, "hash-1")
+		if err := repo.SaveVerificationCode(ctx, code); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		stored, err := repo.GetVerificationCode(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if stored.ClienteID != c.Id() || stored.Email != code.Email || stored.CodeHash != "hash-1" || stored.Attempts != 0 ||
+			!stored.CreatedAt.Equal(code.CreatedAt) || !stored.ExpiresAt.Equal(code.ExpiresAt) {
+			t.Errorf("want: %+v, got: %+v", code, *stored)
+		}
+	})
+
+	t.Run("new code replaces the last one and its attempts", func(t *testing.T) {
+		repo := newRepo(t)
+		c := create(t, repo)
+
+		if err := repo.SaveVerificationCode(ctx, newCode(c, "hash-1")); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		for want := 1; want <= 2; want++ {
+			attempts, err := repo.AddVerificationAttempt(ctx, c.Id())
+			if err != nil {
+				t.Fatalf("should not have return any error, got: %s", err)
+			}
+			if attempts != want {
+				t.Errorf("should have counted %d attempts, got: %d", want, attempts)
+			}
+		}
+
+		if err := repo.SaveVerificationCode(ctx, newCode(c, "hash-2")); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		stored, err := repo.GetVerificationCode(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if stored.CodeHash != "hash-2" || stored.Attempts != 0 {
+			t.Errorf("should have replaced the code, got: %+v", *stored)
+		}
+	})
+
+	t.Run("missing code", func(t *testing.T) {
+		repo := newRepo(t)
+		c := create(t, repo)
+
+		if _, err := repo.GetVerificationCode(ctx, c.Id()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("should have return ErrNotFound, got: %v", err)
+		}
+		if _, err := repo.AddVerificationAttempt(ctx, c.Id()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("should have return ErrNotFound, got: %v", err)
+		}
+		if err := repo.SaveVerificationCode(ctx, entities.VerificationCode{ClienteID: entities.NewID(), Email: "x@email.com"}); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("should not save a code of a missing cliente, got: %v", err)
+		}
+	})
+
+	t.Run("delete code", func(t *testing.T) {
+		repo := newRepo(t)
+		c := create(t, repo)
+
+		if err := repo.SaveVerificationCode(ctx, newCode(c, "hash-1")); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if err := repo.DeleteVerificationCode(ctx, c.Id()); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if _, err := repo.GetVerificationCode(ctx, c.Id()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("should have return ErrNotFound, got: %v", err)
+		}
+		// deleting twice is fine
+		if err := repo.DeleteVerificationCode(ctx, c.Id()); err != nil {
+			t.Errorf("should not have return any error, got: %s", err)
+		}
+	})
+
+	t.Run("codes go away with the cliente", func(t *testing.T) {
+		repo := newRepo(t)
+		c := create(t, repo)
+
+		if err := repo.SaveVerificationCode(ctx, newCode(c, "hash-1")); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if err := repo.Remove(ctx, c.Id()); err != nil {
+			t.Fatalf("removing cliente, got error: %s", err)
+		}
+		if _, err := repo.GetVerificationCode(ctx, c.Id()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("should have return ErrNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("codes go away with the anonymization", func(t *testing.T) {
+		repo := newRepo(t)
+		c := create(t, repo)
+
+		if err := repo.SaveVerificationCode(ctx, newCode(c, "hash-1")); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		stored, err := repo.GetClienteById(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("reading cliente, got error: %s", err)
+		}
+		anonymous, err := stored.Anonymize(time.Now().UTC(), "lgpd request")
+		if err != nil {
+			t.Fatalf("anonymizing cliente, got error: %s", err)
+		}
+		if _, err := repo.Anonymize(ctx, *anonymous); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		// the code would keep the e-mail the anonymization erased
+		if _, err := repo.GetVerificationCode(ctx, c.Id()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("should have return ErrNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("verify e-mail", func(t *testing.T) {
+		repo := newRepo(t)
+		c := create(t, repo)
+		before, err := repo.GetClienteById(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		at := time.Now().UTC().Truncate(time.Microsecond)
+
+		// the address is matched regardless of case
+		verified, err := repo.VerifyEmail(ctx, c.Id(), "FULANO@email.com", at)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if !verified.EmailVerified() || !verified.EmailVerifiedAt().Equal(at) {
+			t.Errorf("should have verified the e-mail at %s, got: %s", at, verified.EmailVerifiedAt())
+		}
+		if verified.Version() != before.Version()+1 {
+			t.Errorf("should have bumped the version, got: %d", verified.Version())
+		}
+
+		stored, err := repo.GetClienteById(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if !stored.EmailVerifiedAt().Equal(at) {
+			t.Errorf("should have stored the verification, got: %s", stored.EmailVerifiedAt())
+		}
+	})
+
+	t.Run("verify e-mail that changed", func(t *testing.T) {
+		repo := newRepo(t)
+		c := create(t, repo)
+
+		_, err := repo.VerifyEmail(ctx, c.Id(), "other@email.com", time.Now())
+		if !errors.Is(err, entityErr.ErrConcurrentModification) {
+			t.Errorf("should have return ErrConcurrentModification, got: %v", err)
+		}
+		if _, err := repo.VerifyEmail(ctx, entities.NewID(), c.Email(), time.Now()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("should have return ErrNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("changing the e-mail drops its verification", func(t *testing.T) {
+		repo := newRepo(t)
+		c := create(t, repo)
+
+		verified, err := repo.VerifyEmail(ctx, c.Id(), c.Email(), time.Now())
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		sameEmail := mustClienteWithID(t, c.Id(), "Ciclano", c.CPF(), "Fulano@Email.com", true, entities.WithVersion(verified.Version()))
+		updated, err := repo.Update(ctx, *sameEmail)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if !updated.EmailVerified() {
+			t.Error("should have kept the verification of the same e-mail")
+		}
+
+		otherEmail := mustClienteWithID(t, c.Id(), "Ciclano", c.CPF(), "ciclano@email.com", true, entities.WithVersion(updated.Version()))
+		updated, err = repo.Update(ctx, *otherEmail)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if updated.EmailVerified() {
+			t.Error("should have dropped the verification of the old e-mail")
+		}
+	})
+}
@@ -0,0 +1,347 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+)
+
+// WebhookRepository is an adapter keeping both clientes and the webhook
+// deliveries of their events.
+type WebhookRepository interface {
+	ports.Repository
+	ports.WebhookRepository
+}
+
+// RunWebhooks executes the conformance suite of ports.WebhookRepository.
+// newRepo must return an empty repository every time it is called.
+func RunWebhooks(t *testing.T, newRepo func(t *testing.T) WebhookRepository) {
+	ctx := context.Background()
+
+	t.Run("create, get and delete subscription", func(t *testing.T) {
+		repo := newRepo(t)
+		sub := mustSubscription(t, ctx, repo, entities.ClienteCreated)
+
+		got, err := repo.GetSubscription(ctx, sub.ID)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if got.URL != sub.URL || got.Secret != sub.Secret || len(got.Events) != 1 || got.Events[0] != entities.ClienteCreated {
+			t.Errorf("want: %+v, got: %+v", sub, got)
+		}
+		if got.CreatedAt.IsZero() {
+			t.Error("should set created at")
+		}
+
+		subs, err := repo.ListSubscriptions(ctx)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if len(subs) != 1 {
+			t.Errorf("should list 1 subscription, got: %d", len(subs))
+		}
+
+		if err := repo.DeleteSubscription(ctx, sub.ID); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if _, err := repo.GetSubscription(ctx, sub.ID); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+		if err := repo.DeleteSubscription(ctx, sub.ID); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("enqueue and deliver", func(t *testing.T) {
+		repo := newRepo(t)
+		sub := mustSubscription(t, ctx, repo)
+		now := time.Now().UTC().Truncate(time.Microsecond)
+		event := entities.Event{ID: entities.NewID(), Type: entities.ClienteCreated, ClienteID: entities.NewID(), Payload: []byte(`{}`), OccurredAt: now}
+
+		d, err := entities.NewWebhookDelivery(sub, event, now)
+		if err != nil {
+			t.Fatalf("creating delivery, got error: %s", err)
+		}
+		for range 2 {
+			if err := repo.EnqueueDelivery(ctx, d); err != nil {
+				t.Fatalf("should not have return any error, got: %s", err)
+			}
+		}
+		again, _ := entities.NewWebhookDelivery(sub, event, now)
+		if err := repo.EnqueueDelivery(ctx, again); err != nil {
+			t.Fatalf("should ignore a second delivery of the event, got: %s", err)
+		}
+
+		due, err := repo.ClaimDueDeliveries(ctx, now, now.Add(time.Minute), 10)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if len(due) != 1 || due[0].ID != d.ID {
+			t.Fatalf("should have the delivery due once, got: %+v", due)
+		}
+		if !due[0].NextAttemptAt.Equal(now.Add(time.Minute)) {
+			t.Errorf("should have moved the next attempt to the end of the claim, got: %s", due[0].NextAttemptAt)
+		}
+
+		d.Failed(now, 500, "unexpected status 500")
+		if err := repo.UpdateDelivery(ctx, d, 0); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if due, _ := repo.ClaimDueDeliveries(ctx, now, now, 10); len(due) != 0 {
+			t.Errorf("should not be due before the next attempt, got: %d", len(due))
+		}
+		if due, _ := repo.ClaimDueDeliveries(ctx, d.NextAttemptAt, d.NextAttemptAt, 10); len(due) != 1 {
+			t.Errorf("should be due at the next attempt, got: %d", len(due))
+		}
+
+		got, err := repo.GetDelivery(ctx, d.ID)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if got.Attempts != 1 || got.LastStatusCode != 500 || got.LastError != d.LastError || !got.NextAttemptAt.Equal(d.NextAttemptAt) {
+			t.Errorf("should have stored the attempt, got: %+v", got)
+		}
+	})
+
+	t.Run("claimed deliveries are skipped", func(t *testing.T) {
+		repo := newRepo(t)
+		sub := mustSubscription(t, ctx, repo)
+		now := time.Now().UTC().Truncate(time.Microsecond)
+
+		for range 3 {
+			event := entities.Event{ID: entities.NewID(), Type: entities.ClienteUpdated, ClienteID: entities.NewID(), Payload: []byte(`{}`)}
+			d, _ := entities.NewWebhookDelivery(sub, event, now.Add(-time.Minute))
+			if err := repo.EnqueueDelivery(ctx, d); err != nil {
+				t.Fatalf("enqueueing, got error: %s", err)
+			}
+		}
+
+		first, err := repo.ClaimDueDeliveries(ctx, now, now.Add(time.Minute), 2)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		second, err := repo.ClaimDueDeliveries(ctx, now, now.Add(time.Minute), 2)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if len(first) != 2 || len(second) != 1 || second[0].ID == first[0].ID || second[0].ID == first[1].ID {
+			t.Errorf("should claim each delivery once, got: %d and %d", len(first), len(second))
+		}
+
+		if expired, _ := repo.ClaimDueDeliveries(ctx, now.Add(time.Minute), now.Add(2*time.Minute), 10); len(expired) != 3 {
+			t.Errorf("should claim them again once the claims expire, got: %d", len(expired))
+		}
+	})
+
+	t.Run("update of a changed delivery", func(t *testing.T) {
+		repo := newRepo(t)
+		sub := mustSubscription(t, ctx, repo)
+		now := time.Now().UTC()
+		event := entities.Event{ID: entities.NewID(), Type: entities.ClienteUpdated, ClienteID: entities.NewID(), Payload: []byte(`{}`)}
+		d, _ := entities.NewWebhookDelivery(sub, event, now)
+		if err := repo.EnqueueDelivery(ctx, d); err != nil {
+			t.Fatalf("enqueueing, got error: %s", err)
+		}
+
+		mine, other := d, d
+		other.Failed(now, 500, "unexpected status 500")
+		if err := repo.UpdateDelivery(ctx, other, 0); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		mine.Delivered(now, 204)
+		if err := repo.UpdateDelivery(ctx, mine, 0); !errors.Is(err, entityErr.ErrConcurrentModification) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrConcurrentModification, err)
+		}
+		if got, _ := repo.GetDelivery(ctx, d.ID); got == nil || got.Status != entities.DeliveryPending || got.Attempts != 1 {
+			t.Errorf("should have kept the first outcome, got: %+v", got)
+		}
+
+		missing := d
+		missing.ID = entities.NewID()
+		if err := repo.UpdateDelivery(ctx, missing, 0); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("list deliveries by status", func(t *testing.T) {
+		repo := newRepo(t)
+		sub := mustSubscription(t, ctx, repo)
+		other := mustSubscription(t, ctx, repo)
+		now := time.Now().UTC()
+
+		var dead entities.WebhookDelivery
+		for i, s := range []entities.WebhookSubscription{sub, sub, other} {
+			event := entities.Event{ID: entities.NewID(), Type: entities.ClienteUpdated, ClienteID: entities.NewID(), Payload: []byte(`{}`)}
+			d, _ := entities.NewWebhookDelivery(s, event, now)
+			if err := repo.EnqueueDelivery(ctx, d); err != nil {
+				t.Fatalf("enqueueing, got error: %s", err)
+			}
+			if i == 0 {
+				for range entities.WebhookMaxAttempts {
+					d.Failed(now, 0, "connection refused")
+				}
+				if err := repo.UpdateDelivery(ctx, d, 0); err != nil {
+					t.Fatalf("updating, got error: %s", err)
+				}
+				dead = d
+			}
+		}
+
+		all, err := repo.ListDeliveries(ctx, sub.ID, "")
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if len(all) != 2 {
+			t.Errorf("should list the 2 deliveries of the subscription, got: %d", len(all))
+		}
+
+		letters, err := repo.ListDeliveries(ctx, sub.ID, entities.DeliveryDead)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if len(letters) != 1 || letters[0].ID != dead.ID {
+			t.Errorf("should list the dead letter only, got: %+v", letters)
+		}
+
+		if err := repo.DeleteSubscription(ctx, sub.ID); err != nil {
+			t.Fatalf("deleting, got error: %s", err)
+		}
+		if _, err := repo.GetDelivery(ctx, dead.ID); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("should delete the deliveries with the subscription, got: %v", err)
+		}
+	})
+
+	t.Run("delete finished deliveries", func(t *testing.T) {
+		repo := newRepo(t)
+		sub := mustSubscription(t, ctx, repo)
+		now := time.Now().UTC()
+
+		var pending entities.WebhookDelivery
+		for i, outcome := range []func(d *entities.WebhookDelivery){
+			func(d *entities.WebhookDelivery) { d.Delivered(now, 204) },
+			func(d *entities.WebhookDelivery) {
+				for range entities.WebhookMaxAttempts {
+					d.Failed(now, 0, "connection refused")
+				}
+			},
+			func(d *entities.WebhookDelivery) { d.Failed(now, 500, "unexpected status 500") },
+		} {
+			event := entities.Event{ID: entities.NewID(), Type: entities.ClienteUpdated, ClienteID: entities.NewID(), Payload: []byte(`{}`)}
+			d, _ := entities.NewWebhookDelivery(sub, event, now)
+			if err := repo.EnqueueDelivery(ctx, d); err != nil {
+				t.Fatalf("enqueueing, got error: %s", err)
+			}
+			outcome(&d)
+			if err := repo.UpdateDelivery(ctx, d, 0); err != nil {
+				t.Fatalf("updating, got error: %s", err)
+			}
+			if i == 2 {
+				pending = d
+			}
+		}
+
+		if n, err := repo.DeleteFinishedDeliveries(ctx, now.Add(-time.Hour)); err != nil || n != 0 {
+			t.Errorf("should keep the deliveries within the retention, got: %d, %v", n, err)
+		}
+		if n, err := repo.DeleteFinishedDeliveries(ctx, now.Add(time.Hour)); err != nil || n != 2 {
+			t.Errorf("should delete the delivered and dead deliveries, got: %d, %v", n, err)
+		}
+
+		left, err := repo.ListDeliveries(ctx, sub.ID, "")
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if len(left) != 1 || left[0].ID != pending.ID {
+			t.Errorf("should keep the pending delivery only, got: %+v", left)
+		}
+	})
+
+	t.Run("anonymization redacts deliveries", func(t *testing.T) {
+		repo := newRepo(t)
+		sub := mustSubscription(t, ctx, repo)
+		fulano := mustCliente(t, "Fulano", "11144477735", "fulano@email.com", true)
+		ciclano := mustCliente(t, "Ciclano", "22255588846", "ciclano@email.com", true)
+
+		deliveries := make(map[entities.ID]entities.WebhookDelivery)
+		for _, c := range []*entities.Cliente{fulano, ciclano} {
+			if err := repo.Create(ctx, *c); err != nil {
+				t.Fatalf("creating cliente, got error: %s", err)
+			}
+			event, err := entities.NewClienteEvent(entities.AuditCreated, nil, c)
+			if err != nil {
+				t.Fatalf("creating event, got error: %s", err)
+			}
+			d, _ := entities.NewWebhookDelivery(sub, event, time.Now().UTC())
+			if err := repo.EnqueueDelivery(ctx, d); err != nil {
+				t.Fatalf("enqueueing, got error: %s", err)
+			}
+			deliveries[c.Id()] = d
+		}
+
+		stored, err := repo.GetClienteById(ctx, fulano.Id())
+		if err != nil {
+			t.Fatalf("reading cliente, got error: %s", err)
+		}
+		anonymous, err := stored.Anonymize(time.Now().UTC(), "lgpd request")
+		if err != nil {
+			t.Fatalf("anonymizing cliente, got error: %s", err)
+		}
+		if _, err := repo.Anonymize(ctx, *anonymous); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+
+		got, err := repo.GetDelivery(ctx, deliveries[fulano.Id()].ID)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		for _, personal := range []string{"Fulano", fulano.CPF(), fulano.Email()} {
+			if strings.Contains(string(got.Payload), personal) {
+				t.Errorf("delivery should not keep %q, got: %s", personal, got.Payload)
+			}
+		}
+		if !strings.Contains(string(got.Payload), fulano.Id().String()) {
+			t.Errorf("delivery should keep the cliente id, got: %s", got.Payload)
+		}
+
+		other, err := repo.GetDelivery(ctx, deliveries[ciclano.Id()].ID)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if !strings.Contains(string(other.Payload), "Ciclano") {
+			t.Errorf("should keep the deliveries of other clientes, got: %s", other.Payload)
+		}
+	})
+
+	t.Run("enqueue for inexistent subscription", func(t *testing.T) {
+		repo := newRepo(t)
+		sub := entities.WebhookSubscription{ID: entities.NewID()}
+		d, _ := entities.NewWebhookDelivery(sub, entities.Event{ID: entities.NewID(), Payload: []byte(`{}`)}, time.Now())
+
+		if err := repo.EnqueueDelivery(ctx, d); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+}
+
+func mustSubscription(t *testing.T, ctx context.Context, repo ports.WebhookRepository, events ...entities.EventType) entities.WebhookSubscription {
+	t.Helper()
+
+	sub, err := repo.CreateSubscription(ctx, entities.WebhookSubscription{
+		ID:     entities.NewID(),
+		URL:    "https://parceiro.example.com/hooks",
+		Events: events,
+		Secret: "whsec_test",
+	})
+	if err != nil {
+		t.Fatalf("creating subscription, got error: %s", err)
+	}
+
+	return sub
+}
@@ -0,0 +1,189 @@
+// Package session signs the short-lived session tokens of the clientes
+// identified at the totem and verifies them. The other services check the
+// session of a request with Require and the public key of the signer.
+package session
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// Header carries the session token in the requests of a cliente.
+const Header = "X-Pedeai-Session"
+
+const (
+	// version prefixes the tokens, so their format can change.
+	version    = "v1"
+	defaultTTL = 15 * time.Minute
+	leeway     = 30 * time.Second
+)
+
+var ErrInvalidSession = errors.New("invalid session")
+
+// Claims is what a session token tells about the cliente.
+type Claims struct {
+	ClienteID string `json:"cid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Signer issues the session tokens, signed with Ed25519.
+type Signer struct {
+	key ed25519.PrivateKey
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewSigner issues tokens valid for ttl, 15 minutes when zero.
+func NewSigner(key ed25519.PrivateKey, ttl time.Duration) *Signer {
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+
+	return &Signer{key: key, ttl: ttl, now: time.Now}
+}
+
+// PublicKey verifies the tokens of the signer.
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+func (s *Signer) IssueSession(ctx context.Context, clienteID entities.ID) (entities.Session, error) {
+	now := s.now().UTC()
+	expiresAt := now.Add(s.ttl)
+
+	payload, err := json.Marshal(Claims{
+		ClienteID: clienteID.String(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return entities.Session{}, err
+	}
+
+	signed := version + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sig := ed25519.Sign(s.key, []byte(signed))
+
+	return entities.Session{
+		ClienteID: clienteID,
+		Token:     signed + "." + base64.RawURLEncoding.EncodeToString(sig),
+		ExpiresAt: time.Unix(expiresAt.Unix(), 0).UTC(),
+	}, nil
+}
+
+// Verifier checks the session tokens with the public key of their signer.
+type Verifier struct {
+	key ed25519.PublicKey
+	now func() time.Time
+}
+
+func NewVerifier(key ed25519.PublicKey) *Verifier {
+	return &Verifier{key: key, now: time.Now}
+}
+
+// Verify returns the claims of a token signed with the key and not expired.
+// Errors wrap ErrInvalidSession.
+func (v *Verifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != version {
+		return Claims{}, fmt.Errorf("%w: malformed", ErrInvalidSession)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !ed25519.Verify(v.key, []byte(parts[0]+"."+parts[1]), sig) {
+		return Claims{}, fmt.Errorf("%w: bad signature", ErrInvalidSession)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %s", ErrInvalidSession, err)
+	}
+	var c Claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return Claims{}, fmt.Errorf("%w: %s", ErrInvalidSession, err)
+	}
+	if v.now().After(time.Unix(c.ExpiresAt, 0).Add(leeway)) {
+		return Claims{}, fmt.Errorf("%w: expired", ErrInvalidSession)
+	}
+
+	return c, nil
+}
+
+type claimsKey struct{}
+
+// FromContext returns the claims of the session checked by Require.
+func FromContext(ctx context.Context) (Claims, bool) {
+	c, ok := ctx.Value(claimsKey{}).(Claims)
+	return c, ok
+}
+
+// Require rejects with 401 the requests without a valid session token in
+// Header and puts the claims of the others in their context.
+func Require(v *Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.Header.Get(Header)
+			if token == "" {
+				unauthorized(w, r, fmt.Errorf("%w: missing %s header", ErrInvalidSession, Header))
+				return
+			}
+
+			c, err := v.Verify(token)
+			if err != nil {
+				unauthorized(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, c)))
+		})
+	}
+}
+
+// unauthorized answers with the problem the clientes api answers with.
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"type":     "/problems/invalid_session",
+		"title":    http.StatusText(http.StatusUnauthorized),
+		"status":   http.StatusUnauthorized,
+		"code":     "invalid_session",
+		"detail":   err.Error(),
+		"instance": r.URL.Path,
+	})
+}
+
+// ParsePrivateKey reads a signing key from the base64 of its 32 bytes seed.
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	seed, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decoding session key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("session key must have %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// ParsePublicKey reads a verifying key from the base64 of its 32 bytes.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decoding session public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("session public key must have %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+
+	return ed25519.PublicKey(key), nil
+}
@@ -0,0 +1,149 @@
+package session
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+func newKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key, error: %s", err)
+	}
+	return key
+}
+
+func TestSession(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	signer := NewSigner(newKey(t), 5*time.Minute)
+	signer.now = func() time.Time { return now }
+
+	clienteID := entities.NewID()
+	s, err := signer.IssueSession(context.Background(), clienteID)
+	if err != nil {
+		t.Fatalf("should not have return any error, got: %s", err)
+	}
+	if !s.ExpiresAt.Equal(now.Add(5 * time.Minute)) {
+		t.Errorf("want session expiring at %s, got: %s", now.Add(5*time.Minute), s.ExpiresAt)
+	}
+
+	verifier := NewVerifier(signer.PublicKey())
+	verifier.now = func() time.Time { return now.Add(time.Minute) }
+
+	t.Run("valid session", func(t *testing.T) {
+		c, err := verifier.Verify(s.Token)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if c.ClienteID != clienteID.String() {
+			t.Errorf("want cliente %s, got: %s", clienteID, c.ClienteID)
+		}
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		late := NewVerifier(signer.PublicKey())
+		late.now = func() time.Time { return now.Add(10 * time.Minute) }
+		if _, err := late.Verify(s.Token); !errors.Is(err, ErrInvalidSession) {
+			t.Errorf("should have return %v, got: %v", ErrInvalidSession, err)
+		}
+	})
+
+	parts := strings.Split(s.Token, ".")
+	forged := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"cid":"`+entities.NewID().String()+`","exp":9999999999}`)) + "." + parts[2]
+	other, _ := NewSigner(newKey(t), 0).IssueSession(context.Background(), clienteID)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"malformed", "not-a-session"},
+		{"unknown version", "v2." + parts[1] + "." + parts[2]},
+		{"forged claims", forged},
+		{"signed with another key", other.Token},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(tt.token); !errors.Is(err, ErrInvalidSession) {
+				t.Errorf("should have return %v, got: %v", ErrInvalidSession, err)
+			}
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	signer := NewSigner(newKey(t), 0)
+	s, _ := signer.IssueSession(context.Background(), entities.NewID())
+
+	var got Claims
+	h := Require(NewVerifier(signer.PublicKey()))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"valid session", s.Token, http.StatusNoContent},
+		{"no session", "", http.StatusUnauthorized},
+		{"invalid session", "v1.e30.AAAA", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/pedidos", nil)
+			if tt.token != "" {
+				req.Header.Set(Header, tt.token)
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.status)
+			}
+			if tt.status == http.StatusUnauthorized && !strings.Contains(rr.Body.String(), `"code":"invalid_session"`) {
+				t.Errorf("should have return code invalid_session, got: %s", rr.Body)
+			}
+		})
+	}
+
+	if got.ClienteID != s.ClienteID.String() {
+		t.Errorf("should have put the session of cliente %s in the context, got: %+v", s.ClienteID, got)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	key := newKey(t)
+	seed := base64.StdEncoding.EncodeToString(key.Seed())
+	pub := base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+
+	parsed, err := ParsePrivateKey(seed)
+	if err != nil || !parsed.Equal(key) {
+		t.Errorf("should have parsed the private key, got: %v", err)
+	}
+	parsedPub, err := ParsePublicKey(pub)
+	if err != nil || !parsedPub.Equal(key.Public()) {
+		t.Errorf("should have parsed the public key, got: %v", err)
+	}
+
+	if _, err := ParsePrivateKey(base64.StdEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Error("should have return an error")
+	}
+	if _, err := ParsePublicKey("not base64!"); err == nil {
+		t.Error("should have return an error")
+	}
+}
@@ -0,0 +1,51 @@
+// Package webhook posts webhook deliveries over HTTP.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+const defaultTimeout = 10 * time.Second
+
+type Sender struct {
+	client *http.Client
+}
+
+// New returns a Sender whose requests time out after timeout, 10 seconds
+// when it is zero. Redirects are not followed, a delivery must reach the
+// registered endpoint.
+func New(timeout time.Duration) *Sender {
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	return &Sender{client: &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+func (s *Sender) Send(ctx context.Context, url string, header map[string]string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
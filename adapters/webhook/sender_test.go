@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSender(t *testing.T) {
+	var (
+		gotBody      string
+		gotSignature string
+	)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		gotSignature = r.Header.Get("X-Pedeai-Signature")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	s := New(0)
+	s.client.Transport = srv.Client().Transport
+
+	status, err := s.Send(context.Background(), srv.URL, map[string]string{"X-Pedeai-Signature": "sha256=abc"}, []byte(`{"id":"1"}`))
+	if err != nil {
+		t.Fatalf("should not have return any error, got: %s", err)
+	}
+	if status != http.StatusAccepted {
+		t.Errorf("want status %d, got: %d", http.StatusAccepted, status)
+	}
+	if gotBody != `{"id":"1"}` || gotSignature != "sha256=abc" {
+		t.Errorf("should have posted body and headers, got: %q and %q", gotBody, gotSignature)
+	}
+}
@@ -3,20 +3,42 @@ package api
 import (
 	"log/slog"
 	"net/http"
+	"time"
 
 	v1 "github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/handlers"
 	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/usecases"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
+// RequestTimeout bounds every request context, so database calls made on its
+// behalf are cancelled instead of piling up when the client is gone.
+const RequestTimeout = 10 * time.Second
+
+// NewServer serves the API, authenticating every request with the verifier
+// unless it is nil.
 func NewServer(
 	logger *slog.Logger,
+	verifier handlers.TokenVerifier,
 	clienteUC usecases.ClienteUseCase,
+	consentUC usecases.ConsentUseCase,
+	webhookUC usecases.WebhookUseCase,
+	idempotencyUC usecases.IdempotencyUseCase,
+	verificationUC usecases.VerificationUseCase,
 ) http.Handler {
 	r := chi.NewRouter()
 
-	r.Mount("/v1", v1.AddRoutes(clienteUC))
+	r.Use(middleware.RequestID)
+	r.Use(middleware.Timeout(RequestTimeout))
+
+	r.Route("/v1", func(r chi.Router) {
+		if verifier != nil {
+			r.Use(handlers.Authenticate(verifier))
+		}
+		r.Mount("/", v1.AddRoutes(clienteUC, consentUC, webhookUC, idempotencyUC, verificationUC))
+	})
 
 	return r
 }
@@ -4,6 +4,6 @@ import "testing"
 
 func TestAPI(t *testing.T) {
 	t.Run("test API", func(t *testing.T) {
-		_ = NewServer(nil, nil)
+		_ = NewServer(nil, nil, nil, nil, nil, nil, nil)
 	})
 }
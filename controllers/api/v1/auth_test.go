@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/handlers"
+	domainEntities "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/services"
+)
+
+type VerifierMock struct{}
+
+func (VerifierMock) Verify(ctx context.Context, token string) (domainEntities.Principal, error) {
+	if token != "valid-token" {
+		return domainEntities.Principal{}, errors.New("invalid token")
+	}
+	return domainEntities.Principal{Subject: "cliente-app"}, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	var actor string
+	protected := handlers.Authenticate(VerifierMock{})(handlers.AuditContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = domainEntities.AuditMetaFrom(r.Context()).Actor
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	t.Run("valid token", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/clientes", nil)
+		req.Header.Set("Authorization", "Bearer valid-token")
+
+		rr := httptest.NewRecorder()
+		protected.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNoContent {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNoContent)
+		}
+		if actor != "cliente-app" {
+			t.Errorf("should have audited the principal as actor, got: %q", actor)
+		}
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+	}{
+		{"no token", ""},
+		{"not a bearer token", "Basic dXNlcjpwYXNz"},
+		{"invalid token", "Bearer forged-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/clientes", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			rr := httptest.NewRecorder()
+			protected.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusUnauthorized {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnauthorized)
+			}
+			if rr.Header().Get("WWW-Authenticate") == "" {
+				t.Error("should have challenged the client")
+			}
+
+			var problem entities.Problem
+			if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+				t.Fatalf("decoding problem, error: %s", err)
+			}
+			if problem.Code != "unauthenticated" {
+				t.Errorf("should have return code %q, got: %q", "unauthenticated", problem.Code)
+			}
+		})
+	}
+}
+
+func TestAuthorizedRoutes(t *testing.T) {
+	clienteID := domainEntities.NewID()
+	consents := &ConsentUseCaseMock{
+		Clientes: map[domainEntities.ID]bool{clienteID: true},
+		Records:  make(map[domainEntities.ID][]domainEntities.ConsentRecord),
+	}
+	webhooks := &WebhookUseCaseMock{
+		Subs: make(map[domainEntities.ID]domainEntities.WebhookSubscription),
+		Sent: make(map[domainEntities.ID]domainEntities.WebhookDelivery),
+	}
+
+	policy := domainEntities.DefaultPolicy()
+	routes := AddRoutes(&clienteUCMock,
+		services.NewAuthorizedConsents(consents, policy),
+		services.NewAuthorizedWebhooks(webhooks, policy),
+		&idempotencyUCMock, &verificationUCMock)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"record consent", "PUT", "/clientes/" + clienteID.String() + "/consents/marketing_email",
+			`{"granted":true,"channel":"app","policy_version":"v1"}`},
+		{"list consents", "GET", "/clientes/" + clienteID.String() + "/consents", ""},
+		{"consent history", "GET", "/clientes/" + clienteID.String() + "/consents/history", ""},
+		{"create webhook", "POST", "/webhooks", `{"url":"https://example.com/hook"}`},
+		{"list webhooks", "GET", "/webhooks", ""},
+	}
+
+	for _, tt := range tests {
+		for _, role := range []string{"cliente", "backoffice"} {
+			t.Run(tt.name+" as "+role, func(t *testing.T) {
+				req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				req = req.WithContext(domainEntities.WithPrincipal(req.Context(), domainEntities.Principal{Subject: "test", Roles: []string{role}}))
+
+				rr := httptest.NewRecorder()
+				routes.ServeHTTP(rr, req)
+
+				if role == "cliente" && rr.Code != http.StatusForbidden {
+					t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusForbidden)
+				}
+				if role == "backoffice" && rr.Code >= http.StatusBadRequest {
+					t.Errorf("should have allowed the back office, got: %v %s", rr.Code, rr.Body)
+				}
+			})
+		}
+	}
+}
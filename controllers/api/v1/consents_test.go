@@ -0,0 +1,179 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+	domainEntities "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+
+	"github.com/google/uuid"
+)
+
+type ConsentUseCaseMock struct {
+	Clientes map[domainEntities.ID]bool
+	Records  map[domainEntities.ID][]domainEntities.ConsentRecord
+}
+
+var consentUCMock = ConsentUseCaseMock{
+	Clientes: make(map[domainEntities.ID]bool),
+	Records:  make(map[domainEntities.ID][]domainEntities.ConsentRecord),
+}
+
+func (c *ConsentUseCaseMock) Record(ctx context.Context, record domainEntities.ConsentRecord) (domainEntities.Consent, error) {
+	if err := record.Validate(); err != nil {
+		return domainEntities.Consent{}, err
+	}
+	if !c.Clientes[record.ClienteID] {
+		return domainEntities.Consent{}, entityErr.ErrNotFound
+	}
+	record.RecordedAt = time.Now()
+	c.Records[record.ClienteID] = append(c.Records[record.ClienteID], record)
+	for _, consent := range domainEntities.CurrentConsents(c.Records[record.ClienteID]) {
+		if consent.Purpose == record.Purpose {
+			return consent, nil
+		}
+	}
+	return domainEntities.Consent{}, entityErr.ErrNotFound
+}
+
+func (c *ConsentUseCaseMock) Consents(ctx context.Context, clienteID uuid.UUID) ([]domainEntities.Consent, error) {
+	history, err := c.History(ctx, clienteID)
+	if err != nil {
+		return nil, err
+	}
+	return domainEntities.CurrentConsents(history), nil
+}
+
+func (c *ConsentUseCaseMock) History(ctx context.Context, clienteID uuid.UUID) ([]domainEntities.ConsentRecord, error) {
+	if !c.Clientes[clienteID] {
+		return nil, entityErr.ErrNotFound
+	}
+	return c.Records[clienteID], nil
+}
+
+func TestConsentHandlers(t *testing.T) {
+	routes := AddRoutes(&clienteUCMock, &consentUCMock, &webhookUCMock, &idempotencyUCMock, &verificationUCMock)
+
+	clienteID := domainEntities.NewID()
+	consentUCMock.Clientes[clienteID] = true
+
+	t.Run("grant consent", func(t *testing.T) {
+		b := bytes.NewBufferString(`{"granted":true,"channel":"totem","policy_version":"v1"}`)
+		req, err := http.NewRequest("PUT", fmt.Sprintf("/clientes/%s/consents/marketing_email", clienteID), b)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+
+		var consent entities.Consent
+		if err := json.NewDecoder(rr.Body).Decode(&consent); err != nil {
+			t.Fatalf("decoding body, error: %s", err)
+		}
+		if consent.Granted == nil || !*consent.Granted || consent.GrantedAt == nil || consent.PolicyVersion != "v1" {
+			t.Errorf("should have granted consent, got: %+v", consent)
+		}
+	})
+
+	t.Run("revoke consent", func(t *testing.T) {
+		b := bytes.NewBufferString(`{"granted":false,"channel":"app"}`)
+		req, err := http.NewRequest("PUT", fmt.Sprintf("/clientes/%s/consents/marketing_email", clienteID), b)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+	})
+
+	t.Run("list consents", func(t *testing.T) {
+		req, err := http.NewRequest("GET", fmt.Sprintf("/clientes/%s/consents", clienteID), nil)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		var list entities.ConsentList
+		if err := json.NewDecoder(rr.Body).Decode(&list); err != nil {
+			t.Fatalf("decoding body, error: %s", err)
+		}
+		if len(list.Data) != 1 || *list.Data[0].Granted || list.Data[0].RevokedAt == nil {
+			t.Errorf("should have return revoked marketing e-mail, got: %+v", list.Data)
+		}
+	})
+
+	t.Run("consent history", func(t *testing.T) {
+		req, err := http.NewRequest("GET", fmt.Sprintf("/clientes/%s/consents/history", clienteID), nil)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		var history entities.ConsentHistory
+		if err := json.NewDecoder(rr.Body).Decode(&history); err != nil {
+			t.Fatalf("decoding body, error: %s", err)
+		}
+		if len(history.Data) != 2 || !history.Data[0].Granted || history.Data[1].Granted {
+			t.Errorf("should have return grant then revoke, got: %+v", history.Data)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   string
+	}{
+		{"without granted", "PUT", fmt.Sprintf("/clientes/%s/consents/sms", clienteID), `{"channel":"app"}`, http.StatusUnprocessableEntity, "validation_failed"},
+		{"unknown purpose", "PUT", fmt.Sprintf("/clientes/%s/consents/telepathy", clienteID), `{"granted":false,"channel":"app"}`, http.StatusUnprocessableEntity, "validation_failed"},
+		{"malformed body", "PUT", fmt.Sprintf("/clientes/%s/consents/sms", clienteID), `{`, http.StatusBadRequest, "malformed_body"},
+		{"inexistent cliente", "GET", fmt.Sprintf("/clientes/%s/consents", uuid.New()), "", http.StatusNotFound, "not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatalf("creating request, error: %s", err)
+			}
+
+			rr := httptest.NewRecorder()
+			routes.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.status)
+			}
+
+			var problem entities.Problem
+			if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+				t.Fatalf("decoding problem, error: %s", err)
+			}
+			if problem.Code != tt.code {
+				t.Errorf("should have return code %q, got: %q", tt.code, problem.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+type FieldChange struct {
+	Field    string `json:"field"`
+	Before   string `json:"before,omitempty"`
+	After    string `json:"after,omitempty"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+type AuditEntry struct {
+	Action     entities.AuditAction `json:"action"`
+	Changes    []FieldChange        `json:"changes"`
+	Actor      string               `json:"actor,omitempty"`
+	RequestID  string               `json:"request_id,omitempty"`
+	RecordedAt time.Time            `json:"recorded_at"`
+}
+
+type AuditHistory struct {
+	Data []AuditEntry `json:"data"`
+}
+
+func AuditEntryFromDomain(e entities.AuditEntry) AuditEntry {
+	out := AuditEntry{
+		Action:     e.Action,
+		Changes:    make([]FieldChange, 0, len(e.Changes)),
+		Actor:      e.Actor,
+		RequestID:  e.RequestID,
+		RecordedAt: e.RecordedAt,
+	}
+	for _, c := range e.Changes {
+		out.Changes = append(out.Changes, FieldChange(c))
+	}
+
+	return out
+}
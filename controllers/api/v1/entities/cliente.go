@@ -1,17 +1,53 @@
 package entities
 
-import "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+import (
+	"time"
 
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// Cliente is both the request and the response body. CreatedAt, UpdatedAt,
+// DeletedAt, Version, the e-mail verification and the anonymization fields
+// are only ever set in responses, the version travels in the ETag and
+// If-Match headers.
 type Cliente struct {
-	ID     entities.ID `json:"id,omitempty"`
-	Name   string      `json:"name,omitempty"`
-	CPF    string      `json:"cpf,omitempty"`
-	Email  string      `json:"email,omitempty"`
-	Active bool        `json:"active,omitempty"`
+	ID        entities.ID `json:"id,omitempty"`
+	Name      string      `json:"name,omitempty"`
+	CPF       string      `json:"cpf,omitempty"`
+	Email     string      `json:"email,omitempty"`
+	Active    *bool       `json:"active,omitempty"`
+	CreatedAt *time.Time  `json:"created_at,omitempty"`
+	UpdatedAt *time.Time  `json:"updated_at,omitempty"`
+	DeletedAt *time.Time  `json:"deleted_at,omitempty"`
+	Version   int         `json:"version,omitempty"`
+
+	EmailVerified   *bool      `json:"email_verified,omitempty"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+
+	AnonymizedAt        *time.Time `json:"anonymized_at,omitempty"`
+	AnonymizationReason string     `json:"anonymization_reason,omitempty"`
+}
+
+// EmailVerification is the body of an e-mail verification confirmation, with
+// the code sent to the cliente.
+type EmailVerification struct {
+	Code string `json:"code"`
 }
 
-func (c *Cliente) ToDomain() (*entities.Cliente, error) {
-	cDomain, err := entities.New(c.ID, c.Name, c.CPF, c.Email, c.Active)
+// Anonymization is the body of an anonymization request.
+type Anonymization struct {
+	Reason string `json:"reason"`
+}
+
+// ToDomain converts the payload into a domain Cliente, a missing Active
+// defaults to true.
+func (c *Cliente) ToDomain(opts ...entities.Option) (*entities.Cliente, error) {
+	active := true
+	if c.Active != nil {
+		active = *c.Active
+	}
+
+	cDomain, err := entities.New(c.ID, c.Name, c.CPF, c.Email, active, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -20,11 +56,36 @@ func (c *Cliente) ToDomain() (*entities.Cliente, error) {
 }
 
 func FromDomain(c *entities.Cliente) (*Cliente, error) {
-	return &Cliente{
-		ID:     c.Id(),
-		Name:   c.Name(),
-		CPF:    c.CPF(),
-		Email:  c.Email(),
-		Active: c.Active(),
-	}, nil
+	active := c.Active()
+	emailVerified := c.EmailVerified()
+	out := &Cliente{
+		ID:            c.Id(),
+		Name:          c.Name(),
+		CPF:           c.CPF(),
+		Email:         c.Email(),
+		Active:        &active,
+		Version:       c.Version(),
+		EmailVerified: &emailVerified,
+	}
+
+	if createdAt := c.CreatedAt(); !createdAt.IsZero() {
+		out.CreatedAt = &createdAt
+	}
+	if updatedAt := c.UpdatedAt(); !updatedAt.IsZero() {
+		out.UpdatedAt = &updatedAt
+	}
+	if deletedAt := c.DeletedAt(); !deletedAt.IsZero() {
+		out.DeletedAt = &deletedAt
+	}
+	if emailVerified {
+		verifiedAt := c.EmailVerifiedAt()
+		out.EmailVerifiedAt = &verifiedAt
+	}
+	if c.Anonymized() {
+		anonymizedAt := c.AnonymizedAt()
+		out.AnonymizedAt = &anonymizedAt
+		out.AnonymizationReason = c.AnonymizationReason()
+	}
+
+	return out, nil
 }
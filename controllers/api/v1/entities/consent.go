@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// Consent is the state of a purpose in responses. As a request body it
+// changes that state and only Granted, Channel and PolicyVersion are read.
+type Consent struct {
+	Purpose       entities.ConsentPurpose `json:"purpose,omitempty"`
+	Granted       *bool                   `json:"granted"`
+	Channel       entities.ConsentChannel `json:"channel,omitempty"`
+	PolicyVersion string                  `json:"policy_version,omitempty"`
+	GrantedAt     *time.Time              `json:"granted_at,omitempty"`
+	RevokedAt     *time.Time              `json:"revoked_at,omitempty"`
+}
+
+type ConsentList struct {
+	Data []*Consent `json:"data"`
+}
+
+type ConsentRecord struct {
+	Purpose       entities.ConsentPurpose `json:"purpose"`
+	Granted       bool                    `json:"granted"`
+	Channel       entities.ConsentChannel `json:"channel"`
+	PolicyVersion string                  `json:"policy_version,omitempty"`
+	RecordedAt    time.Time               `json:"recorded_at"`
+}
+
+type ConsentHistory struct {
+	Data []ConsentRecord `json:"data"`
+}
+
+func ConsentFromDomain(c entities.Consent) *Consent {
+	granted := c.Granted
+	out := &Consent{
+		Purpose:       c.Purpose,
+		Granted:       &granted,
+		Channel:       c.Channel,
+		PolicyVersion: c.PolicyVersion,
+	}
+
+	if !c.GrantedAt.IsZero() {
+		out.GrantedAt = &c.GrantedAt
+	}
+	if !c.RevokedAt.IsZero() {
+		out.RevokedAt = &c.RevokedAt
+	}
+
+	return out
+}
+
+func ConsentRecordFromDomain(r entities.ConsentRecord) ConsentRecord {
+	return ConsentRecord{
+		Purpose:       r.Purpose,
+		Granted:       r.Granted,
+		Channel:       r.Channel,
+		PolicyVersion: r.PolicyVersion,
+		RecordedAt:    r.RecordedAt,
+	}
+}
@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// ClienteExport is the JSON rendering of a data export, every section is a
+// list of objects keyed by column.
+type ClienteExport struct {
+	ClienteID   entities.ID                    `json:"cliente_id"`
+	GeneratedAt time.Time                      `json:"generated_at"`
+	Sections    map[string][]map[string]string `json:"sections"`
+}
+
+func ExportFromDomain(e *entities.ClienteExport) *ClienteExport {
+	out := &ClienteExport{
+		ClienteID:   e.ClienteID,
+		GeneratedAt: e.GeneratedAt,
+		Sections:    make(map[string][]map[string]string, len(e.Sections)),
+	}
+
+	for _, section := range e.Sections {
+		rows := make([]map[string]string, 0, len(section.Rows))
+		for _, row := range section.Rows {
+			obj := make(map[string]string, len(section.Columns))
+			for i, col := range section.Columns {
+				obj[col] = row[i]
+			}
+			rows = append(rows, obj)
+		}
+		out.Sections[section.Name] = rows
+	}
+
+	return out
+}
@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// Identification is the body of POST /identify, with either the cpf or the
+// e-mail of the cliente.
+type Identification struct {
+	CPF   string `json:"cpf,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// ClienteSummary is a cliente with its personal data masked.
+type ClienteSummary struct {
+	ID    entities.ID `json:"id"`
+	Name  string      `json:"name"`
+	CPF   string      `json:"cpf"`
+	Email string      `json:"email"`
+}
+
+type Identified struct {
+	Token     string         `json:"token"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	Cliente   ClienteSummary `json:"cliente"`
+}
+
+func IdentifiedFromDomain(i entities.Identified) Identified {
+	return Identified{
+		Token:     i.Session.Token,
+		ExpiresAt: i.Session.ExpiresAt,
+		Cliente: ClienteSummary{
+			ID:    i.Cliente.ID,
+			Name:  i.Cliente.Name,
+			CPF:   i.Cliente.CPF,
+			Email: i.Cliente.Email,
+		},
+	}
+}
@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+type ClienteList struct {
+	Data []*Cliente `json:"data"`
+	Next string     `json:"next,omitempty"`
+}
+
+type cursor struct {
+	Sort entities.SortField `json:"s"`
+	Key  string             `json:"k"`
+	ID   entities.ID        `json:"i"`
+}
+
+// EncodeCursor turns a domain cursor into the opaque token handed to clients.
+func EncodeCursor(c *entities.Cursor) string {
+	b, _ := json.Marshal(cursor{Sort: c.Sort, Key: c.Key, ID: c.ID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*entities.Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", entityErr.ErrInvalidListOptions)
+	}
+
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", entityErr.ErrInvalidListOptions)
+	}
+
+	return &entities.Cursor{Sort: c.Sort, Key: c.Key, ID: c.ID}, nil
+}
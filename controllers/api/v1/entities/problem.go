@@ -0,0 +1,21 @@
+package entities
+
+// Problem is the RFC 7807 "application/problem+json" body returned on errors.
+// Code is stable and meant for clients to switch on, Title and Detail are for
+// humans.
+type Problem struct {
+	Type     string           `json:"type"`
+	Title    string           `json:"title"`
+	Status   int              `json:"status"`
+	Code     string           `json:"code"`
+	Detail   string           `json:"detail,omitempty"`
+	Instance string           `json:"instance,omitempty"`
+	Errors   []FieldViolation `json:"errors,omitempty"`
+}
+
+// FieldViolation is one failed validation rule, listed in Problem.Errors.
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
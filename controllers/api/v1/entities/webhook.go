@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// WebhookSubscription as a request body only has URL and Events. The secret
+// is only in the response to its creation.
+type WebhookSubscription struct {
+	ID        string               `json:"id,omitempty"`
+	URL       string               `json:"url"`
+	Events    []entities.EventType `json:"events"`
+	Secret    string               `json:"secret,omitempty"`
+	CreatedAt *time.Time           `json:"created_at,omitempty"`
+}
+
+type WebhookSubscriptionList struct {
+	Data []WebhookSubscription `json:"data"`
+}
+
+type WebhookDelivery struct {
+	ID             string                  `json:"id"`
+	EventID        string                  `json:"event_id"`
+	EventType      entities.EventType      `json:"event_type"`
+	Status         entities.DeliveryStatus `json:"status"`
+	Attempts       int                     `json:"attempts"`
+	NextAttemptAt  *time.Time              `json:"next_attempt_at,omitempty"`
+	LastStatusCode int                     `json:"last_status_code,omitempty"`
+	LastError      string                  `json:"last_error,omitempty"`
+	CreatedAt      time.Time               `json:"created_at"`
+	DeliveredAt    *time.Time              `json:"delivered_at,omitempty"`
+}
+
+type WebhookDeliveryList struct {
+	Data []WebhookDelivery `json:"data"`
+}
+
+func (s WebhookSubscription) ToDomain() entities.WebhookSubscription {
+	return entities.WebhookSubscription{URL: s.URL, Events: s.Events}
+}
+
+// WebhookSubscriptionFromDomain leaves the secret out, set it on creation.
+func WebhookSubscriptionFromDomain(s entities.WebhookSubscription) WebhookSubscription {
+	out := WebhookSubscription{
+		ID:     s.ID.String(),
+		URL:    s.URL,
+		Events: s.Events,
+	}
+	if out.Events == nil {
+		out.Events = []entities.EventType{}
+	}
+	if !s.CreatedAt.IsZero() {
+		out.CreatedAt = &s.CreatedAt
+	}
+
+	return out
+}
+
+func WebhookDeliveryFromDomain(d entities.WebhookDelivery) WebhookDelivery {
+	out := WebhookDelivery{
+		ID:             d.ID.String(),
+		EventID:        d.EventID.String(),
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+	}
+	if !d.NextAttemptAt.IsZero() {
+		out.NextAttemptAt = &d.NextAttemptAt
+	}
+	if !d.DeliveredAt.IsZero() {
+		out.DeliveredAt = &d.DeliveredAt
+	}
+
+	return out
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	entitiesDomain "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// TokenVerifier tells who a bearer token was issued to.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (entitiesDomain.Principal, error)
+}
+
+// Authenticate rejects the requests without a valid bearer token with 401
+// and puts the principal of the token in the context of the others.
+func Authenticate(verifier TokenVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				ErrorResponse(w, r, fmt.Errorf("%w: missing bearer token", ErrUnauthenticated))
+				return
+			}
+
+			p, err := verifier.Verify(r.Context(), token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				ErrorResponse(w, r, fmt.Errorf("%w: %s", ErrUnauthenticated, err))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(entitiesDomain.WithPrincipal(r.Context(), p)))
+		})
+	}
+}
@@ -2,10 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
 	entitiesDomain "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
 	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/usecases"
 
 	"github.com/go-chi/chi/v5"
@@ -14,35 +18,54 @@ import (
 func HandleListClientes(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Query().Get("cpf") != "" {
-			cliente, err := clienteUC.GetClienteByCPF(r.URL.Query().Get("cpf"))
+			cliente, err := clienteUC.GetClienteByCPF(r.Context(), r.URL.Query().Get("cpf"))
 			if err != nil {
-				http.Error(w, "Internal Error", http.StatusInternalServerError)
+				ErrorResponse(w, r, err)
 				return
 			}
 
-			ClienteResponse(w, cliente)
+			ClienteResponse(w, r, cliente)
 			return
 		} else if r.URL.Query().Get("email") != "" {
-			cliente, err := clienteUC.GetClienteByEmail(r.URL.Query().Get("email"))
+			cliente, err := clienteUC.GetClienteByEmail(r.Context(), r.URL.Query().Get("email"))
 			if err != nil {
-				http.Error(w, "Internal Error", http.StatusInternalServerError)
+				ErrorResponse(w, r, err)
 				return
 			}
 
-			ClienteResponse(w, cliente)
+			ClienteResponse(w, r, cliente)
 			return
 		} else {
-			clientes, _ := clienteUC.List()
-			var cOut []*entities.Cliente
-			for _, c := range clientes {
+			opts, err := ListOptionsDecode(r)
+			if err != nil {
+				ErrorResponse(w, r, err)
+				return
+			}
+
+			page, err := clienteUC.List(r.Context(), opts)
+			if err != nil {
+				ErrorResponse(w, r, err)
+				return
+			}
+
+			cOut := entities.ClienteList{Data: make([]*entities.Cliente, 0, len(page.Clientes))}
+			for _, c := range page.Clientes {
 				out, _ := entities.FromDomain(c)
-				cOut = append(cOut, out)
+				cOut.Data = append(cOut.Data, out)
 			}
 
+			if page.Next != nil {
+				q := r.URL.Query()
+				q.Set("cursor", entities.EncodeCursor(page.Next))
+				next := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+				cOut.Next = next.String()
+				w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", cOut.Next))
+			}
+
+			w.Header().Set("Content-Type", "application/json")
 			jEncode := json.NewEncoder(w)
-			err := jEncode.Encode(cOut)
-			if err != nil {
-				http.Error(w, "Internal Error", http.StatusInternalServerError)
+			if err := jEncode.Encode(cOut); err != nil {
+				ErrorResponse(w, r, err)
 				return
 			}
 		}
@@ -51,35 +74,39 @@ func HandleListClientes(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
 
 func HandleGetSingleCliente(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := chi.URLParam(r, "id")
-		uuid, err := entitiesDomain.StringToID(id)
+		uuid, err := ClienteID(r)
 		if err != nil {
-			http.Error(w, "Internal Error", http.StatusInternalServerError)
+			ErrorResponse(w, r, err)
 			return
 		}
 
-		c, err := clienteUC.GetClienteById(uuid)
+		c, err := clienteUC.GetClienteById(r.Context(), uuid)
 		if err != nil {
-			http.Error(w, "Internal Error", http.StatusInternalServerError)
+			ErrorResponse(w, r, err)
 			return
 		}
 
-		ClienteResponse(w, c)
-		return
+		ClienteResponse(w, r, c)
 	}
 }
 
 func HandleCreateCliente(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		c, err := ClienteDecode(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
 		cDomain, err := c.ToDomain()
 		if err != nil {
-			http.Error(w, "Internal Error", http.StatusInternalServerError)
+			ErrorResponse(w, r, err)
 			return
 		}
-		uuid, err := clienteUC.Create(*cDomain)
+
+		uuid, err := clienteUC.Create(r.Context(), *cDomain)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			ErrorResponse(w, r, err)
 			return
 		}
 
@@ -90,41 +117,91 @@ func HandleCreateCliente(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
 
 func HandleUpdateCliente(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := chi.URLParam(r, "id")
-		uuid, err := entitiesDomain.StringToID(id)
+		uuid, err := ClienteID(r)
 		if err != nil {
-			http.Error(w, "Internal Error", http.StatusInternalServerError)
+			ErrorResponse(w, r, err)
 			return
 		}
 
 		c, err := ClienteDecode(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		if c.Active == nil {
+			var verr entityErr.ValidationError
+			verr.Add("active", "required", entityErr.ErrActiveRequired)
+			ErrorResponse(w, r, verr.Err())
+			return
+		}
+
+		version, err := IfMatchVersion(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
 		c.ID = uuid
-		cDomain, err := c.ToDomain()
+		cDomain, err := c.ToDomain(entitiesDomain.WithVersion(version))
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			ErrorResponse(w, r, err)
 			return
 		}
 
-		if err = clienteUC.Update(*cDomain); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		updated, err := clienteUC.Update(r.Context(), *cDomain)
+		if err != nil {
+			ErrorResponse(w, r, err)
 			return
 		}
 
+		w.Header().Set("ETag", ETag(updated))
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
+// HandlePatchCliente applies a JSON Merge Patch (RFC 7386) to the cliente and
+// answers with the updated representation.
+func HandlePatchCliente(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uuid, err := ClienteID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		patch, err := ClientePatchDecode(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		patch.ExpectedVersion, err = IfMatchVersion(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		c, err := clienteUC.Patch(r.Context(), uuid, patch)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		ClienteResponse(w, r, c)
+	}
+}
+
 func HandleRemoveCliente(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := chi.URLParam(r, "id")
-		uuid, err := entitiesDomain.StringToID(id)
+		uuid, err := ClienteID(r)
 		if err != nil {
-			http.Error(w, "Internal Error", http.StatusInternalServerError)
+			ErrorResponse(w, r, err)
 			return
 		}
 
-		if err := clienteUC.Remove(uuid); err != nil {
-			http.Error(w, "Internal Error", http.StatusInternalServerError)
+		if err := clienteUC.Remove(r.Context(), uuid); err != nil {
+			ErrorResponse(w, r, err)
 			return
 		}
 
@@ -132,26 +209,187 @@ func HandleRemoveCliente(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
 	}
 }
 
-func ClienteResponse(w http.ResponseWriter, c *entitiesDomain.Cliente) {
+func HandleReactivateCliente(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uuid, err := ClienteID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		c, err := clienteUC.Reactivate(r.Context(), uuid)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		ClienteResponse(w, r, c)
+	}
+}
+
+// HandleAnonymizeCliente erases the personal data of the cliente for good,
+// see Service.Anonymize.
+func HandleAnonymizeCliente(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uuid, err := ClienteID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		var body entities.Anonymization
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			ErrorResponse(w, r, fmt.Errorf("%w: %s", ErrMalformedBody, err))
+			return
+		}
+
+		c, err := clienteUC.Anonymize(r.Context(), uuid, body.Reason)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		ClienteResponse(w, r, c)
+	}
+}
+
+// HandlePurgeCliente deletes the cliente for good, unlike HandleRemoveCliente
+// which only deactivates it.
+func HandlePurgeCliente(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uuid, err := ClienteID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		if err := clienteUC.Purge(r.Context(), uuid); err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func ClienteResponse(w http.ResponseWriter, r *http.Request, c *entitiesDomain.Cliente) {
 	cOut, err := entities.FromDomain(c)
 	if err != nil {
-		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		ErrorResponse(w, r, err)
 		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", ETag(c))
 	jEncode := json.NewEncoder(w)
 	_ = jEncode.Encode(cOut)
-	if err != nil {
-		http.Error(w, "Internal Error", http.StatusInternalServerError)
-		return
-	}
 }
 
 func ClienteDecode(r *http.Request) (*entities.Cliente, error) {
 	var c entities.Cliente
 	err := json.NewDecoder(r.Body).Decode(&c)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %s", ErrMalformedBody, err)
 	}
 
 	return &c, nil
 }
+
+// ClientePatchDecode reads a merge patch document. A null name, cpf or email
+// clears the field, which validation then rejects; a null active is rejected
+// right away since it has no meaningful empty value.
+func ClientePatchDecode(r *http.Request) (entitiesDomain.ClientePatch, error) {
+	var patch entitiesDomain.ClientePatch
+
+	var doc map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		return patch, fmt.Errorf("%w: %s", ErrMalformedBody, err)
+	}
+
+	fields := map[string]**string{
+		"name":  &patch.Name,
+		"cpf":   &patch.CPF,
+		"email": &patch.Email,
+	}
+	for name, dst := range fields {
+		raw, ok := doc[name]
+		if !ok {
+			continue
+		}
+		var v *string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return patch, fmt.Errorf("%w: %s: %s", ErrMalformedBody, name, err)
+		}
+		if v == nil {
+			v = new(string)
+		}
+		*dst = v
+	}
+
+	if raw, ok := doc["active"]; ok {
+		if err := json.Unmarshal(raw, &patch.Active); err != nil {
+			return patch, fmt.Errorf("%w: active: %s", ErrMalformedBody, err)
+		}
+		if patch.Active == nil {
+			var verr entityErr.ValidationError
+			verr.Add("active", "required", entityErr.ErrActiveRequired)
+			return patch, verr.Err()
+		}
+	}
+
+	return patch, nil
+}
+
+// ListOptionsDecode reads limit, cursor, sort, active, include_inactive and
+// name (a prefix) from the query string.
+func ListOptionsDecode(r *http.Request) (entitiesDomain.ListOptions, error) {
+	var opts entitiesDomain.ListOptions
+	q := r.URL.Query()
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("%w: limit must be a number", entityErr.ErrInvalidListOptions)
+		}
+		opts.Limit = limit
+	}
+
+	if v := q.Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("%w: active must be a boolean", entityErr.ErrInvalidListOptions)
+		}
+		opts.Active = &active
+	}
+
+	if v := q.Get("include_inactive"); v != "" {
+		include, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("%w: include_inactive must be a boolean", entityErr.ErrInvalidListOptions)
+		}
+		opts.IncludeInactive = include
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		after, err := entities.DecodeCursor(v)
+		if err != nil {
+			return opts, err
+		}
+		opts.After = after
+	}
+
+	opts.Sort = entitiesDomain.SortField(q.Get("sort"))
+	opts.NamePrefix = q.Get("name")
+
+	return opts, nil
+}
+
+func ClienteID(r *http.Request) (entitiesDomain.ID, error) {
+	id := chi.URLParam(r, "id")
+	uuid, err := entitiesDomain.StringToID(id)
+	if err != nil {
+		return uuid, fmt.Errorf("%w: %s", ErrInvalidID, err)
+	}
+
+	return uuid, nil
+}
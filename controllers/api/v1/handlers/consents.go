@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+	entitiesDomain "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/usecases"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func HandleListConsents(consentUC usecases.ConsentUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uuid, err := ClienteID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		consents, err := consentUC.Consents(r.Context(), uuid)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		out := entities.ConsentList{Data: make([]*entities.Consent, 0, len(consents))}
+		for _, c := range consents {
+			out.Data = append(out.Data, entities.ConsentFromDomain(c))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
+
+func HandleConsentHistory(consentUC usecases.ConsentUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uuid, err := ClienteID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		history, err := consentUC.History(r.Context(), uuid)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		out := entities.ConsentHistory{Data: make([]entities.ConsentRecord, 0, len(history))}
+		for _, record := range history {
+			out.Data = append(out.Data, entities.ConsentRecordFromDomain(record))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
+
+// HandleRecordConsent grants or revokes the purpose in the path and answers
+// with its new state.
+func HandleRecordConsent(consentUC usecases.ConsentUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uuid, err := ClienteID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		var body entities.Consent
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			ErrorResponse(w, r, fmt.Errorf("%w: %s", ErrMalformedBody, err))
+			return
+		}
+
+		if body.Granted == nil {
+			var verr entityErr.ValidationError
+			verr.Add("granted", "required", entityErr.ErrGrantedRequired)
+			ErrorResponse(w, r, verr.Err())
+			return
+		}
+
+		consent, err := consentUC.Record(r.Context(), entitiesDomain.ConsentRecord{
+			ClienteID:     uuid,
+			Purpose:       entitiesDomain.ConsentPurpose(chi.URLParam(r, "purpose")),
+			Granted:       *body.Granted,
+			Channel:       body.Channel,
+			PolicyVersion: body.PolicyVersion,
+		})
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entities.ConsentFromDomain(consent))
+	}
+}
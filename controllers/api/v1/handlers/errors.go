@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+const problemContentType = "application/problem+json"
+
+var (
+	ErrInvalidID     = errors.New("invalid cliente id")
+	ErrMalformedBody = errors.New("malformed request body")
+)
+
+type problemMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// problemMappings is checked in order with errors.Is, the first match wins.
+var problemMappings = []problemMapping{
+	{ErrUnauthenticated, http.StatusUnauthorized, "unauthenticated"},
+	{entityErr.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{ErrInvalidID, http.StatusBadRequest, "invalid_id"},
+	{ErrInvalidWebhookID, http.StatusBadRequest, "invalid_id"},
+	{ErrInvalidDeliveryID, http.StatusBadRequest, "invalid_id"},
+	{ErrInvalidDeliveryStatus, http.StatusBadRequest, "invalid_delivery_status"},
+	{ErrMalformedBody, http.StatusBadRequest, "malformed_body"},
+	{ErrMalformedIfMatch, http.StatusBadRequest, "malformed_if_match"},
+	{entityErr.ErrInvalidIdempotencyKey, http.StatusBadRequest, "invalid_idempotency_key"},
+	{entityErr.ErrIdempotencyKeyInProgress, http.StatusConflict, "idempotency_key_in_progress"},
+	{entityErr.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, "idempotency_key_reused"},
+	{entityErr.ErrConcurrentModification, http.StatusPreconditionFailed, "concurrent_modification"},
+	{entityErr.ErrInvalidListOptions, http.StatusBadRequest, "invalid_list_options"},
+	{ErrInvalidExportFormat, http.StatusBadRequest, "invalid_export_format"},
+	{entityErr.ErrNotFound, http.StatusNotFound, "not_found"},
+	{entityErr.ErrClienteAlreadyExistsForID, http.StatusConflict, "cliente_already_exists_for_id"},
+	{entityErr.ErrClienteAlreadyExistsForCPF, http.StatusConflict, "cliente_already_exists_for_cpf"},
+	{entityErr.ErrClienteAlreadyExistsForEmail, http.StatusConflict, "cliente_already_exists_for_email"},
+	{entityErr.ErrClienteAnonymized, http.StatusConflict, "cliente_anonymized"},
+	{entityErr.ErrEmailAlreadyVerified, http.StatusConflict, "email_already_verified"},
+	{entityErr.ErrVerificationRequestedTooSoon, http.StatusTooManyRequests, "verification_requested_too_soon"},
+	{entityErr.ErrTooManyVerificationAttempts, http.StatusTooManyRequests, "too_many_verification_attempts"},
+	{entityErr.ErrInvalidVerificationCode, http.StatusUnprocessableEntity, "invalid_verification_code"},
+	{entityErr.ErrVerificationCodeExpired, http.StatusUnprocessableEntity, "verification_code_expired"},
+	{entityErr.ErrNameRequired, http.StatusUnprocessableEntity, "name_required"},
+	{entityErr.ErrNameTooShort, http.StatusUnprocessableEntity, "name_too_short"},
+	{entityErr.ErrInvalidCPF, http.StatusUnprocessableEntity, "invalid_cpf"},
+	{entityErr.ErrInvalidEmail, http.StatusUnprocessableEntity, "invalid_email"},
+	{entityErr.ErrActiveRequired, http.StatusUnprocessableEntity, "active_required"},
+	{entityErr.ErrAnonymizationReasonRequired, http.StatusUnprocessableEntity, "reason_required"},
+	{entityErr.ErrInvalidConsentPurpose, http.StatusUnprocessableEntity, "invalid_consent_purpose"},
+	{entityErr.ErrInvalidConsentChannel, http.StatusUnprocessableEntity, "invalid_consent_channel"},
+	{entityErr.ErrPolicyVersionRequired, http.StatusUnprocessableEntity, "policy_version_required"},
+	{entityErr.ErrGrantedRequired, http.StatusUnprocessableEntity, "granted_required"},
+	{entityErr.ErrInvalidWebhookURL, http.StatusUnprocessableEntity, "invalid_webhook_url"},
+	{entityErr.ErrInvalidEventType, http.StatusUnprocessableEntity, "invalid_event_type"},
+}
+
+// ProblemFromError translates an error into the problem it should be reported
+// as. Unknown errors become a 500 without leaking their message.
+func ProblemFromError(err error) entities.Problem {
+	var verr *entityErr.ValidationError
+	if errors.As(err, &verr) {
+		p := entities.Problem{
+			Type:   "/problems/validation_failed",
+			Title:  http.StatusText(http.StatusUnprocessableEntity),
+			Status: http.StatusUnprocessableEntity,
+			Code:   "validation_failed",
+			Detail: verr.Error(),
+		}
+		for _, f := range verr.Fields {
+			p.Errors = append(p.Errors, entities.FieldViolation{
+				Field:   f.Field,
+				Rule:    f.Rule,
+				Message: f.Message,
+			})
+		}
+
+		return p
+	}
+
+	for _, m := range problemMappings {
+		if errors.Is(err, m.err) {
+			return entities.Problem{
+				Type:   fmt.Sprintf("/problems/%s", m.code),
+				Title:  http.StatusText(m.status),
+				Status: m.status,
+				Code:   m.code,
+				Detail: err.Error(),
+			}
+		}
+	}
+
+	return entities.Problem{
+		Type:   "/problems/internal_error",
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+		Code:   "internal_error",
+	}
+}
+
+func ErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	p := ProblemFromError(err)
+	p.Instance = r.URL.Path
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
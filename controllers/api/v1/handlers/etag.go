@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	entitiesDomain "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+var ErrMalformedIfMatch = errors.New("malformed If-Match header")
+
+// ETag is the strong entity tag of a cliente, derived from its version.
+func ETag(c *entitiesDomain.Cliente) string {
+	return fmt.Sprintf(`"%d"`, c.Version())
+}
+
+// IfMatchVersion returns the version the request is conditioned on, 0 when
+// there is no If-Match header or it is "*". A tag that cannot be one of ours,
+// weak tags included, can never match and fails the precondition.
+func IfMatchVersion(r *http.Request) (int, error) {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" || h == "*" {
+		return 0, nil
+	}
+
+	if strings.Contains(h, ",") {
+		return 0, fmt.Errorf("%w: only one entity tag is supported", ErrMalformedIfMatch)
+	}
+
+	tag, ok := strings.CutPrefix(h, `"`)
+	if ok {
+		tag, ok = strings.CutSuffix(tag, `"`)
+	}
+	if !ok {
+		return 0, entityErr.ErrConcurrentModification
+	}
+
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, entityErr.ErrConcurrentModification
+	}
+
+	return version, nil
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+	entitiesDomain "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/usecases"
+)
+
+var ErrInvalidExportFormat = errors.New("invalid export format")
+
+// HandleExportCliente answers a data-subject access request with everything
+// held about the cliente. It is JSON by default, format=zip gives a ZIP with
+// the same JSON and one CSV per section.
+func HandleExportCliente(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format != "" && format != "json" && format != "zip" {
+			ErrorResponse(w, r, fmt.Errorf("%w: %q, use json or zip", ErrInvalidExportFormat, format))
+			return
+		}
+
+		uuid, err := ClienteID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		export, err := clienteUC.Export(r.Context(), uuid)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		if format == "zip" {
+			b, err := ExportZip(export)
+			if err != nil {
+				ErrorResponse(w, r, err)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/zip")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"cliente-%s.zip\"", uuid))
+			w.Write(b)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entities.ExportFromDomain(export))
+	}
+}
+
+// ExportZip packs the export as cliente.json plus a <section>.csv for every
+// section.
+func ExportZip(export *entitiesDomain.ClienteExport) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	f, err := zw.Create("cliente.json")
+	if err != nil {
+		return nil, fmt.Errorf("creating export json: %w", err)
+	}
+	if err := json.NewEncoder(f).Encode(entities.ExportFromDomain(export)); err != nil {
+		return nil, fmt.Errorf("writing export json: %w", err)
+	}
+
+	for _, section := range export.Sections {
+		f, err := zw.Create(section.Name + ".csv")
+		if err != nil {
+			return nil, fmt.Errorf("creating export csv %s: %w", section.Name, err)
+		}
+
+		cw := csv.NewWriter(f)
+		cw.Write(section.Columns)
+		cw.WriteAll(section.Rows)
+		if err := cw.Error(); err != nil {
+			return nil, fmt.Errorf("writing export csv %s: %w", section.Name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("closing export zip: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+	entitiesDomain "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/usecases"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// AuditContext tags the request context with who is behind its mutations,
+// the authenticated principal, for the audit history kept by the repository.
+func AuditContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta := entitiesDomain.AuditMetaFrom(r.Context())
+		meta.RequestID = middleware.GetReqID(r.Context())
+		if p, ok := entitiesDomain.PrincipalFrom(r.Context()); ok {
+			meta.Actor = p.Subject
+		}
+
+		next.ServeHTTP(w, r.WithContext(entitiesDomain.WithAuditMeta(r.Context(), meta)))
+	})
+}
+
+func HandleClienteHistory(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uuid, err := ClienteID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		history, err := clienteUC.History(r.Context(), uuid)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		out := entities.AuditHistory{Data: make([]entities.AuditEntry, 0, len(history))}
+		for _, entry := range history {
+			out.Data = append(out.Data, entities.AuditEntryFromDomain(entry))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
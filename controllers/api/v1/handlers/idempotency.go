@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+
+	entitiesDomain "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/usecases"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+// Idempotent lets clients retry a request sent with an Idempotency-Key header
+// without repeating it: the response to the first successful request is
+// replayed to the retries. Each principal has keys of its own. Reusing the
+// key for another request fails with 422, and retrying while the first
+// request is in progress with 409. Unsuccessful responses are not kept, so
+// the request can be retried.
+func Idempotent(idempotencyUC usecases.IdempotencyUseCase) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestBytes))
+			if err != nil {
+				ErrorResponse(w, r, fmt.Errorf("%w: %s", ErrMalformedBody, err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			stored, err := idempotencyUC.Begin(r.Context(), key, requestHash(r, body))
+			if err != nil {
+				ErrorResponse(w, r, err)
+				return
+			}
+			if stored != nil {
+				replay(w, *stored)
+				return
+			}
+
+			var out bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&out)
+
+			next.ServeHTTP(ww, r)
+
+			// the response is sent, the client may be gone already
+			ctx := context.WithoutCancel(r.Context())
+			if ww.Status() < 200 || ww.Status() > 299 {
+				_ = idempotencyUC.Abandon(ctx, key)
+				return
+			}
+
+			rec := entitiesDomain.IdempotencyRecord{
+				Key:        key,
+				StatusCode: ww.Status(),
+				Header:     make(map[string]string),
+				Body:       out.Bytes(),
+			}
+			for name := range ww.Header() {
+				rec.Header[name] = ww.Header().Get(name)
+			}
+			// the request had its effect, a key whose response could not be
+			// stored is never released: it stays in progress until its lease
+			// expires
+			_ = idempotencyUC.Complete(ctx, rec)
+		})
+	}
+}
+
+// requestHash identifies the request a key was first used for.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.Path)
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, rec entitiesDomain.IdempotencyRecord) {
+	for name, value := range rec.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(rec.StatusCode)
+	w.Write(rec.Body)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+	entitiesDomain "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/usecases"
+)
+
+// HandleIdentify identifies the cliente at the totem by cpf or e-mail and
+// answers with a session token and a masked summary of the cliente, see
+// Service.Identify.
+func HandleIdentify(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body entities.Identification
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			ErrorResponse(w, r, fmt.Errorf("%w: %s", ErrMalformedBody, err))
+			return
+		}
+
+		identified, err := clienteUC.Identify(r.Context(), entitiesDomain.Identification{
+			CPF:   body.CPF,
+			Email: body.Email,
+		})
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		// the token is a credential
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entities.IdentifiedFromDomain(identified))
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/usecases"
+)
+
+// HandleGetMe answers with the cliente the request is authenticated as.
+func HandleGetMe(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := clienteUC.Me(r.Context())
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		ClienteResponse(w, r, c)
+	}
+}
+
+// HandlePatchMe applies a JSON Merge Patch to the cliente the request is
+// authenticated as. Clientes can only change their name and e-mail.
+func HandlePatchMe(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		patch, err := ClientePatchDecode(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		patch.ExpectedVersion, err = IfMatchVersion(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		c, err := clienteUC.PatchMe(r.Context(), patch)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		ClienteResponse(w, r, c)
+	}
+}
+
+// HandleRemoveMe deactivates the cliente the request is authenticated as.
+func HandleRemoveMe(clienteUC usecases.ClienteUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := clienteUC.RemoveMe(r.Context()); err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/usecases"
+)
+
+// HandleRequestEmailVerification sends a one-time code to the e-mail of the
+// cliente, see VerificationService.RequestEmailVerification. It answers 202
+// as the code may still be on its way.
+func HandleRequestEmailVerification(verificationUC usecases.VerificationUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uuid, err := ClienteID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		if err := verificationUC.RequestEmailVerification(r.Context(), uuid); err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// HandleConfirmEmailVerification verifies the e-mail of the cliente with the
+// code sent to it and answers with the verified cliente.
+func HandleConfirmEmailVerification(verificationUC usecases.VerificationUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uuid, err := ClienteID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		var body entities.EmailVerification
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			ErrorResponse(w, r, fmt.Errorf("%w: %s", ErrMalformedBody, err))
+			return
+		}
+
+		c, err := verificationUC.ConfirmEmailVerification(r.Context(), uuid, body.Code)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		ClienteResponse(w, r, c)
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+	entitiesDomain "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/usecases"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var (
+	ErrInvalidWebhookID      = errors.New("invalid webhook id")
+	ErrInvalidDeliveryID     = errors.New("invalid delivery id")
+	ErrInvalidDeliveryStatus = errors.New("invalid delivery status")
+)
+
+func HandleCreateWebhook(webhookUC usecases.WebhookUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body entities.WebhookSubscription
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			ErrorResponse(w, r, fmt.Errorf("%w: %s", ErrMalformedBody, err))
+			return
+		}
+
+		sub, err := webhookUC.Subscribe(r.Context(), body.ToDomain())
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		out := entities.WebhookSubscriptionFromDomain(sub)
+		out.Secret = sub.Secret
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/v1/webhooks/"+out.ID)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
+
+func HandleListWebhooks(webhookUC usecases.WebhookUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subs, err := webhookUC.Subscriptions(r.Context())
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		out := entities.WebhookSubscriptionList{Data: make([]entities.WebhookSubscription, 0, len(subs))}
+		for _, s := range subs {
+			out.Data = append(out.Data, entities.WebhookSubscriptionFromDomain(s))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
+
+func HandleGetWebhook(webhookUC usecases.WebhookUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := WebhookID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		sub, err := webhookUC.Subscription(r.Context(), id)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entities.WebhookSubscriptionFromDomain(*sub))
+	}
+}
+
+func HandleDeleteWebhook(webhookUC usecases.WebhookUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := WebhookID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		if err := webhookUC.Unsubscribe(r.Context(), id); err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleListDeliveries is the delivery log of the webhook, filtered by the
+// status query parameter.
+func HandleListDeliveries(webhookUC usecases.WebhookUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := entitiesDomain.DeliveryStatus(r.URL.Query().Get("status"))
+		switch status {
+		case "", entitiesDomain.DeliveryPending, entitiesDomain.DeliveryDelivered, entitiesDomain.DeliveryDead:
+		default:
+			ErrorResponse(w, r, fmt.Errorf("%w: %q", ErrInvalidDeliveryStatus, status))
+			return
+		}
+
+		deliveriesResponse(w, r, webhookUC, status)
+	}
+}
+
+// HandleListDeadLetters lists the deliveries that failed every attempt.
+func HandleListDeadLetters(webhookUC usecases.WebhookUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deliveriesResponse(w, r, webhookUC, entitiesDomain.DeliveryDead)
+	}
+}
+
+func deliveriesResponse(w http.ResponseWriter, r *http.Request, webhookUC usecases.WebhookUseCase, status entitiesDomain.DeliveryStatus) {
+	id, err := WebhookID(r)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	deliveries, err := webhookUC.Deliveries(r.Context(), id, status)
+	if err != nil {
+		ErrorResponse(w, r, err)
+		return
+	}
+
+	out := entities.WebhookDeliveryList{Data: make([]entities.WebhookDelivery, 0, len(deliveries))}
+	for _, d := range deliveries {
+		out.Data = append(out.Data, entities.WebhookDeliveryFromDomain(d))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// HandleRedeliver queues a delivery again, usually a dead letter.
+func HandleRedeliver(webhookUC usecases.WebhookUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := WebhookID(r)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+		deliveryID, err := entitiesDomain.StringToID(chi.URLParam(r, "delivery"))
+		if err != nil {
+			ErrorResponse(w, r, fmt.Errorf("%w: %s", ErrInvalidDeliveryID, err))
+			return
+		}
+
+		d, err := webhookUC.Redeliver(r.Context(), id, deliveryID)
+		if err != nil {
+			ErrorResponse(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(entities.WebhookDeliveryFromDomain(*d))
+	}
+}
+
+func WebhookID(r *http.Request) (entitiesDomain.ID, error) {
+	id, err := entitiesDomain.StringToID(chi.URLParam(r, "id"))
+	if err != nil {
+		return id, fmt.Errorf("%w: %s", ErrInvalidWebhookID, err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,160 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/handlers"
+	domainEntities "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+type IdempotencyUseCaseMock struct {
+	Keys map[string]domainEntities.IdempotencyRecord
+	// CompleteErr fails Complete when set
+	CompleteErr error
+}
+
+var idempotencyUCMock = IdempotencyUseCaseMock{
+	Keys: make(map[string]domainEntities.IdempotencyRecord),
+}
+
+func (i *IdempotencyUseCaseMock) Begin(ctx context.Context, key, requestHash string) (*domainEntities.IdempotencyRecord, error) {
+	if err := domainEntities.ValidateIdempotencyKey(key); err != nil {
+		return nil, err
+	}
+	stored, ok := i.Keys[key]
+	switch {
+	case !ok:
+		i.Keys[key] = domainEntities.IdempotencyRecord{Key: key, RequestHash: requestHash}
+		return nil, nil
+	case stored.RequestHash != requestHash:
+		return nil, entityErr.ErrIdempotencyKeyReused
+	case !stored.Completed():
+		return nil, entityErr.ErrIdempotencyKeyInProgress
+	}
+	return &stored, nil
+}
+
+func (i *IdempotencyUseCaseMock) Complete(ctx context.Context, rec domainEntities.IdempotencyRecord) error {
+	if i.CompleteErr != nil {
+		return i.CompleteErr
+	}
+	stored := i.Keys[rec.Key]
+	stored.StatusCode, stored.Header, stored.Body = rec.StatusCode, rec.Header, rec.Body
+	i.Keys[rec.Key] = stored
+	return nil
+}
+
+func (i *IdempotencyUseCaseMock) Abandon(ctx context.Context, key string) error {
+	delete(i.Keys, key)
+	return nil
+}
+
+func TestIdempotentCreate(t *testing.T) {
+	routes := AddRoutes(&clienteUCMock, &consentUCMock, &webhookUCMock, &idempotencyUCMock, &verificationUCMock)
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/clientes", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+		req.Header.Set(handlers.IdempotencyKeyHeader, key)
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+		return rr
+	}
+
+	body := `{"name":"Beltrano","cpf":"52998224725","email":"beltrano@email.com"}`
+
+	t.Run("retry replays the response", func(t *testing.T) {
+		first := post("create-1", body)
+		if first.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", first.Code, http.StatusCreated)
+		}
+
+		retry := post("create-1", body)
+		if retry.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", retry.Code, http.StatusCreated)
+		}
+		if retry.Body.String() != first.Body.String() {
+			t.Errorf("should have replayed cliente %s, got: %s", first.Body, retry.Body)
+		}
+		if retry.Header().Get(handlers.IdempotentReplayedHeader) != "true" {
+			t.Error("should have flagged the response as replayed")
+		}
+	})
+
+	t.Run("failed request is not kept", func(t *testing.T) {
+		conflict := `{"name":"Fulano","cpf":"98765432100","email":"f@email.com"}`
+		if rr := post("create-2", conflict); rr.Code != http.StatusConflict {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusConflict)
+		}
+		if _, ok := idempotencyUCMock.Keys["create-2"]; ok {
+			t.Error("should have released the key")
+		}
+	})
+
+	tests := []struct {
+		name   string
+		key    string
+		body   string
+		status int
+		code   string
+	}{
+		{"key reused with another body", "create-1", `{"name":"Beltrano","cpf":"11144477735","email":"beltrano@email.com"}`, http.StatusUnprocessableEntity, "idempotency_key_reused"},
+		{"key too long", strings.Repeat("k", 256), body, http.StatusBadRequest, "invalid_idempotency_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := post(tt.key, tt.body)
+			if rr.Code != tt.status {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.status)
+			}
+
+			var problem entities.Problem
+			if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+				t.Fatalf("decoding problem, error: %s", err)
+			}
+			if problem.Code != tt.code {
+				t.Errorf("should have return code %q, got: %q", tt.code, problem.Code)
+			}
+		})
+	}
+
+	t.Run("key is kept when its response is not stored", func(t *testing.T) {
+		idempotencyUCMock.CompleteErr = errors.New("database unavailable")
+		t.Cleanup(func() { idempotencyUCMock.CompleteErr = nil })
+
+		created := `{"name":"Beltrano","cpf":"11144477735","email":"outro.beltrano@email.com"}`
+		if rr := post("create-4", created); rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+		if _, ok := idempotencyUCMock.Keys["create-4"]; !ok {
+			t.Error("should not have released the key of a created cliente")
+		}
+	})
+
+	t.Run("request in progress", func(t *testing.T) {
+		if rr := post("create-3", body); rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+		// as if the first request had not finished yet
+		rec := idempotencyUCMock.Keys["create-3"]
+		rec.StatusCode = 0
+		idempotencyUCMock.Keys["create-3"] = rec
+
+		if rr := post("create-3", body); rr.Code != http.StatusConflict {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusConflict)
+		}
+	})
+}
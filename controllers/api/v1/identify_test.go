@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+)
+
+func TestIdentify(t *testing.T) {
+	routes := AddRoutes(&clienteUCMock, &consentUCMock, &webhookUCMock, &idempotencyUCMock, &verificationUCMock)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/identify", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("identify by cpf", func(t *testing.T) {
+		rr := post(`{"cpf":"` + existentClientCPF + `"}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if rr.Header().Get("Cache-Control") != "no-store" {
+			t.Error("should not let the token be cached")
+		}
+
+		var identified entities.Identified
+		if err := json.NewDecoder(rr.Body).Decode(&identified); err != nil {
+			t.Fatalf("decoding response, error: %s", err)
+		}
+		if identified.Token != "session-token" {
+			t.Errorf("should have return the session token, got: %q", identified.Token)
+		}
+		if identified.Cliente.CPF != "***.123.123-**" || identified.Cliente.Email != "f***@email.com" {
+			t.Errorf("should have masked the cliente, got: %+v", identified.Cliente)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"unknown cliente", `{"email":"nobody@email.com"}`, http.StatusNotFound, "not_found"},
+		{"no identifier", `{}`, http.StatusUnprocessableEntity, "validation_failed"},
+		{"malformed body", `{"cpf":`, http.StatusBadRequest, "malformed_body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := post(tt.body)
+			if rr.Code != tt.status {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.status)
+			}
+
+			var problem entities.Problem
+			if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+				t.Fatalf("decoding problem, error: %s", err)
+			}
+			if problem.Code != tt.code {
+				t.Errorf("should have return code %q, got: %q", tt.code, problem.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+	domainEntities "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+func TestMe(t *testing.T) {
+	routes := AddRoutes(&clienteUCMock, &consentUCMock, &webhookUCMock, &idempotencyUCMock, &verificationUCMock)
+
+	id := domainEntities.NewID()
+	me, _ := domainEntities.New(id, "Beltrano Me", "71428793860", "me@email.com", true)
+	clienteUCMock.Base[id] = me
+	t.Cleanup(func() { delete(clienteUCMock.Base, id) })
+
+	do := func(method, body string, p *domainEntities.Principal) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, "/clientes/me", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+		if p != nil {
+			req = req.WithContext(domainEntities.WithPrincipal(req.Context(), *p))
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+		return rr
+	}
+
+	decode := func(rr *httptest.ResponseRecorder) entities.Cliente {
+		var c entities.Cliente
+		if err := json.NewDecoder(rr.Body).Decode(&c); err != nil {
+			t.Fatalf("decoding cliente, error: %s", err)
+		}
+		return c
+	}
+
+	bySubject := &domainEntities.Principal{Subject: id.String()}
+	byCPF := &domainEntities.Principal{Subject: "app|42", CPF: "71428793860"}
+
+	t.Run("get by subject", func(t *testing.T) {
+		rr := do("GET", "", bySubject)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if c := decode(rr); c.ID != id {
+			t.Errorf("should have return cliente %s, got: %s", id, c.ID)
+		}
+	})
+
+	t.Run("get by cpf", func(t *testing.T) {
+		rr := do("GET", "", byCPF)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if c := decode(rr); c.ID != id {
+			t.Errorf("should have return cliente %s, got: %s", id, c.ID)
+		}
+	})
+
+	t.Run("unknown cliente", func(t *testing.T) {
+		rr := do("GET", "", &domainEntities.Principal{Subject: domainEntities.NewID().String()})
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("patch name", func(t *testing.T) {
+		rr := do("PATCH", `{"name":"Beltrano Silva"}`, bySubject)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if c := decode(rr); c.Name != "Beltrano Silva" {
+			t.Errorf("should have renamed the cliente, got: %s", c.Name)
+		}
+	})
+
+	t.Run("patch active", func(t *testing.T) {
+		rr := do("PATCH", `{"active":false,"cpf":"11144477735"}`, bySubject)
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+		}
+
+		var problem entities.Problem
+		if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+			t.Fatalf("decoding problem, error: %s", err)
+		}
+		if len(problem.Errors) != 2 || problem.Errors[0].Rule != "not_editable" {
+			t.Errorf("should have rejected cpf and active as not editable, got: %+v", problem.Errors)
+		}
+		if !clienteUCMock.Base[id].Active() {
+			t.Error("should not have deactivated the cliente")
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		if rr := do("DELETE", "", byCPF); rr.Code != http.StatusNoContent {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNoContent)
+		}
+		if clienteUCMock.Base[id].Active() {
+			t.Error("should have deactivated the cliente")
+		}
+	})
+}
@@ -7,15 +7,55 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
-func AddRoutes(clienteUC usecases.ClienteUseCase) *chi.Mux {
+func AddRoutes(
+	clienteUC usecases.ClienteUseCase,
+	consentUC usecases.ConsentUseCase,
+	webhookUC usecases.WebhookUseCase,
+	idempotencyUC usecases.IdempotencyUseCase,
+	verificationUC usecases.VerificationUseCase,
+) *chi.Mux {
 	r := chi.NewRouter()
 
+	r.Use(handlers.AuditContext)
+
 	r.Route("/clientes", func(r chi.Router) {
 		r.Get("/", handlers.HandleListClientes(clienteUC))
+		r.Get("/me", handlers.HandleGetMe(clienteUC))
+		r.Patch("/me", handlers.HandlePatchMe(clienteUC))
+		r.Delete("/me", handlers.HandleRemoveMe(clienteUC))
 		r.Get("/{id}", handlers.HandleGetSingleCliente(clienteUC))
-		r.Post("/", handlers.HandleCreateCliente(clienteUC))
+		r.With(handlers.Idempotent(idempotencyUC)).Post("/", handlers.HandleCreateCliente(clienteUC))
 		r.Put("/{id}", handlers.HandleUpdateCliente(clienteUC))
+		r.Patch("/{id}", handlers.HandlePatchCliente(clienteUC))
 		r.Delete("/{id}", handlers.HandleRemoveCliente(clienteUC))
+		r.Post("/{id}/reactivate", handlers.HandleReactivateCliente(clienteUC))
+		r.Post("/{id}/anonymize", handlers.HandleAnonymizeCliente(clienteUC))
+		r.Get("/{id}/export", handlers.HandleExportCliente(clienteUC))
+		r.Get("/{id}/history", handlers.HandleClienteHistory(clienteUC))
+
+		r.Get("/{id}/consents", handlers.HandleListConsents(consentUC))
+		r.Get("/{id}/consents/history", handlers.HandleConsentHistory(consentUC))
+		r.Put("/{id}/consents/{purpose}", handlers.HandleRecordConsent(consentUC))
+
+		r.Post("/{id}/email-verification", handlers.HandleRequestEmailVerification(verificationUC))
+		r.Post("/{id}/email-verification/confirm", handlers.HandleConfirmEmailVerification(verificationUC))
+	})
+
+	r.Post("/identify", handlers.HandleIdentify(clienteUC))
+
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Get("/", handlers.HandleListWebhooks(webhookUC))
+		r.Post("/", handlers.HandleCreateWebhook(webhookUC))
+		r.Get("/{id}", handlers.HandleGetWebhook(webhookUC))
+		r.Delete("/{id}", handlers.HandleDeleteWebhook(webhookUC))
+		r.Get("/{id}/deliveries", handlers.HandleListDeliveries(webhookUC))
+		r.Get("/{id}/dead-letters", handlers.HandleListDeadLetters(webhookUC))
+		r.Post("/{id}/deliveries/{delivery}/redeliver", handlers.HandleRedeliver(webhookUC))
+	})
+
+	// removes what DELETE /clientes/{id} keeps, granted to admins by default
+	r.Route("/admin", func(r chi.Router) {
+		r.Delete("/clientes/{id}", handlers.HandlePurgeCliente(clienteUC))
 	})
 
 	return r
@@ -15,7 +15,7 @@ import (
 // Feature: Get cliente searching by ID
 // Scenario: Successfully retrieve cliente information searching by ID
 func TestBDD(t *testing.T) {
-	routes := AddRoutes(&clienteUCMock)
+	routes := AddRoutes(&clienteUCMock, &consentUCMock, &webhookUCMock, &idempotencyUCMock, &verificationUCMock)
 
 	t.Run("get cliente by id", func(t *testing.T) {
 
@@ -1,7 +1,10 @@
 package v1
 
 import (
+	"archive/zip"
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,18 +12,21 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
 	domainEntities "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
 	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
 )
 
 var (
 	existentClientID    string = "d1e78e30-2023-4f75-bb3f-41a3b4bacd4d"
-	existentClientCPF   string = "12312312312"
+	existentClientCPF   string = "12312312387"
 	existentClientEmail string = "fulano@email.com"
 	existentCliente     *domainEntities.Cliente
 )
@@ -44,7 +50,7 @@ func init() {
 	c2, _ := domainEntities.New(
 		uuid2,
 		"Ciclano",
-		"98765432112",
+		"98765432100",
 		"ciclano@email.com",
 		false,
 	)
@@ -57,15 +63,42 @@ func init() {
 	clienteUCMock = mock
 }
 
-func (c *ClienteUseCaseMock) Create(cliente domainEntities.Cliente) (uuid.UUID, error) {
+func (c *ClienteUseCaseMock) Create(ctx context.Context, cliente domainEntities.Cliente) (uuid.UUID, error) {
+	for _, v := range c.Base {
+		if v.CPF() == cliente.CPF() {
+			return uuid.Nil, entityErr.ErrClienteAlreadyExistsForCPF
+		}
+	}
 	return domainEntities.NewID(), nil
 }
 
-func (c *ClienteUseCaseMock) List() ([]*domainEntities.Cliente, error) {
-	return slices.Collect(maps.Values(c.Base)), nil
+func (c *ClienteUseCaseMock) List(ctx context.Context, opts domainEntities.ListOptions) (domainEntities.ClientePage, error) {
+	opts, err := opts.Normalize()
+	if err != nil {
+		return domainEntities.ClientePage{}, err
+	}
+
+	clientes := slices.SortedFunc(maps.Values(c.Base), func(a, b *domainEntities.Cliente) int {
+		return strings.Compare(a.CPF(), b.CPF())
+	})
+	if opts.After != nil {
+		clientes = slices.DeleteFunc(clientes, func(cliente *domainEntities.Cliente) bool {
+			return cliente.CPF() <= opts.After.Key
+		})
+	}
+
+	var page domainEntities.ClientePage
+	if len(clientes) > opts.Limit {
+		clientes = clientes[:opts.Limit]
+		last := clientes[len(clientes)-1]
+		page.Next = &domainEntities.Cursor{Sort: opts.Sort, Key: last.CPF(), ID: last.Id()}
+	}
+	page.Clientes = clientes
+
+	return page, nil
 }
 
-func (c *ClienteUseCaseMock) GetClienteById(id uuid.UUID) (*domainEntities.Cliente, error) {
+func (c *ClienteUseCaseMock) GetClienteById(ctx context.Context, id uuid.UUID) (*domainEntities.Cliente, error) {
 	uuid, err := domainEntities.StringToID(id.String())
 	if err != nil {
 		return nil, errors.New("converting uuid")
@@ -77,7 +110,7 @@ func (c *ClienteUseCaseMock) GetClienteById(id uuid.UUID) (*domainEntities.Clien
 	return cliente, nil
 }
 
-func (c *ClienteUseCaseMock) GetClienteByCPF(cpf string) (*domainEntities.Cliente, error) {
+func (c *ClienteUseCaseMock) GetClienteByCPF(ctx context.Context, cpf string) (*domainEntities.Cliente, error) {
 	for _, v := range c.Base {
 		if v.CPF() == cpf {
 			return v, nil
@@ -87,7 +120,7 @@ func (c *ClienteUseCaseMock) GetClienteByCPF(cpf string) (*domainEntities.Client
 	return nil, entityErr.ErrNotFound
 }
 
-func (c *ClienteUseCaseMock) GetClienteByEmail(email string) (*domainEntities.Cliente, error) {
+func (c *ClienteUseCaseMock) GetClienteByEmail(ctx context.Context, email string) (*domainEntities.Cliente, error) {
 	for _, v := range c.Base {
 		if v.Email() == email {
 			return v, nil
@@ -97,15 +130,101 @@ func (c *ClienteUseCaseMock) GetClienteByEmail(email string) (*domainEntities.Cl
 	return nil, entityErr.ErrNotFound
 }
 
-func (c *ClienteUseCaseMock) Update(cliente domainEntities.Cliente) error {
-	if _, ok := c.Base[cliente.Id()]; !ok {
-		return entityErr.ErrNotFound
+func (c *ClienteUseCaseMock) Update(ctx context.Context, cliente domainEntities.Cliente) (*domainEntities.Cliente, error) {
+	stored, ok := c.Base[cliente.Id()]
+	if !ok {
+		return nil, entityErr.ErrNotFound
 	}
-	c.Base[cliente.Id()] = &cliente
-	return nil
+	if cliente.Version() != 0 && cliente.Version() != stored.Version() {
+		return nil, entityErr.ErrConcurrentModification
+	}
+	updated, _ := domainEntities.New(cliente.Id(), cliente.Name(), cliente.CPF(), cliente.Email(), cliente.Active(),
+		domainEntities.WithVersion(stored.Version()+1))
+	c.Base[cliente.Id()] = updated
+	return updated, nil
 }
 
-func (c *ClienteUseCaseMock) Remove(id uuid.UUID) error {
+func (c *ClienteUseCaseMock) Patch(ctx context.Context, id uuid.UUID, patch domainEntities.ClientePatch) (*domainEntities.Cliente, error) {
+	cliente, ok := c.Base[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	if patch.ExpectedVersion != 0 && patch.ExpectedVersion != cliente.Version() {
+		return nil, entityErr.ErrConcurrentModification
+	}
+	patched, err := cliente.Apply(patch)
+	if err != nil {
+		return nil, err
+	}
+	c.Base[id] = patched
+	return patched, nil
+}
+
+func (c *ClienteUseCaseMock) Remove(ctx context.Context, id uuid.UUID) error {
+	_, err := c.setActive(id, false)
+	return err
+}
+
+func (c *ClienteUseCaseMock) Reactivate(ctx context.Context, id uuid.UUID) (*domainEntities.Cliente, error) {
+	return c.setActive(id, true)
+}
+
+func (c *ClienteUseCaseMock) setActive(id uuid.UUID, active bool) (*domainEntities.Cliente, error) {
+	stored, ok := c.Base[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	var deletedAt time.Time
+	if !active {
+		deletedAt = time.Now()
+	}
+	updated, _ := domainEntities.New(id, stored.Name(), stored.CPF(), stored.Email(), active,
+		domainEntities.WithDeletedAt(deletedAt), domainEntities.WithVersion(stored.Version()+1))
+	c.Base[id] = updated
+	return updated, nil
+}
+
+func (c *ClienteUseCaseMock) Anonymize(ctx context.Context, id uuid.UUID, reason string) (*domainEntities.Cliente, error) {
+	stored, ok := c.Base[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	anonymous, err := stored.Anonymize(time.Now(), reason)
+	if err != nil {
+		return nil, err
+	}
+	c.Base[id] = anonymous
+	return anonymous, nil
+}
+
+func (c *ClienteUseCaseMock) Export(ctx context.Context, id uuid.UUID) (*domainEntities.ClienteExport, error) {
+	stored, ok := c.Base[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	return &domainEntities.ClienteExport{
+		ClienteID:   id,
+		GeneratedAt: time.Now(),
+		Sections:    []domainEntities.ExportSection{domainEntities.ClienteSection(stored)},
+	}, nil
+}
+
+// History echoes the audit meta of the request, to check it is set.
+func (c *ClienteUseCaseMock) History(ctx context.Context, id uuid.UUID) ([]domainEntities.AuditEntry, error) {
+	if _, ok := c.Base[id]; !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	meta := domainEntities.AuditMetaFrom(ctx)
+	return []domainEntities.AuditEntry{{
+		ClienteID: id,
+		Action:    domainEntities.AuditCreated,
+		Changes:   []domainEntities.FieldChange{{Field: "name", After: "Fulano"}},
+		Actor:     meta.Actor,
+		RequestID: meta.RequestID,
+	}}, nil
+}
+
+func (c *ClienteUseCaseMock) Purge(ctx context.Context, id uuid.UUID) error {
 	if _, ok := c.Base[id]; !ok {
 		return entityErr.ErrNotFound
 	}
@@ -113,8 +232,55 @@ func (c *ClienteUseCaseMock) Remove(id uuid.UUID) error {
 	return nil
 }
 
+func (c *ClienteUseCaseMock) Identify(ctx context.Context, id domainEntities.Identification) (domainEntities.Identified, error) {
+	if err := id.Validate(); err != nil {
+		return domainEntities.Identified{}, err
+	}
+	cliente, err := c.GetClienteByCPF(ctx, id.CPF)
+	if id.Email != "" {
+		cliente, err = c.GetClienteByEmail(ctx, id.Email)
+	}
+	if err != nil {
+		return domainEntities.Identified{}, err
+	}
+	return domainEntities.Identified{
+		Cliente: domainEntities.Summarize(cliente),
+		Session: domainEntities.Session{ClienteID: cliente.Id(), Token: "session-token", ExpiresAt: time.Now().Add(time.Minute)},
+	}, nil
+}
+
+func (c *ClienteUseCaseMock) Me(ctx context.Context) (*domainEntities.Cliente, error) {
+	p, ok := domainEntities.PrincipalFrom(ctx)
+	if !ok {
+		return nil, entityErr.ErrForbidden
+	}
+	if id, err := domainEntities.StringToID(p.Subject); err == nil {
+		return c.GetClienteById(ctx, id)
+	}
+	return c.GetClienteByCPF(ctx, p.CPF)
+}
+
+func (c *ClienteUseCaseMock) PatchMe(ctx context.Context, patch domainEntities.ClientePatch) (*domainEntities.Cliente, error) {
+	if err := patch.ValidateForOwner(); err != nil {
+		return nil, err
+	}
+	me, err := c.Me(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Patch(ctx, me.Id(), patch)
+}
+
+func (c *ClienteUseCaseMock) RemoveMe(ctx context.Context) error {
+	me, err := c.Me(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Remove(ctx, me.Id())
+}
+
 func TestHandlers(t *testing.T) {
-	routes := AddRoutes(&clienteUCMock)
+	routes := AddRoutes(&clienteUCMock, &consentUCMock, &webhookUCMock, &idempotencyUCMock, &verificationUCMock)
 
 	t.Run("list clientes", func(t *testing.T) {
 		req, err := http.NewRequest("GET", "/clientes", nil)
@@ -129,13 +295,50 @@ func TestHandlers(t *testing.T) {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 		}
 
-		clientes := []*entities.Cliente{}
+		clientes := entities.ClienteList{}
 		if err := json.Unmarshal(rr.Body.Bytes(), &clientes); err != nil {
 			t.Errorf("unmarshalling json: %s", err)
 		}
 
-		if len(clientes) != 2 {
-			t.Errorf("should have return list with 2 itens, got: %d", len(clientes))
+		if len(clientes.Data) != 2 {
+			t.Errorf("should have return list with 2 itens, got: %d", len(clientes.Data))
+		}
+	})
+
+	t.Run("list clientes by page", func(t *testing.T) {
+		next := "/clientes?limit=1"
+		seen := 0
+		for pages := 0; next != ""; pages++ {
+			if pages > 2 {
+				t.Fatal("should have stopped paginating")
+			}
+
+			req, err := http.NewRequest("GET", next, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			routes.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+
+			clientes := entities.ClienteList{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &clientes); err != nil {
+				t.Fatalf("unmarshalling json: %s", err)
+			}
+			if len(clientes.Data) != 1 {
+				t.Errorf("should have return page with 1 item, got: %d", len(clientes.Data))
+			}
+
+			seen += len(clientes.Data)
+			next = clientes.Next
+		}
+
+		if seen != 2 {
+			t.Errorf("should have walked through 2 clientes, got: %d", seen)
 		}
 	})
 
@@ -160,6 +363,10 @@ func TestHandlers(t *testing.T) {
 		if cliente.ID.String() != existentClientID {
 			t.Errorf("should have return existent cliente with id: %s, got: %s", existentClientID, cliente.ID)
 		}
+
+		if etag := rr.Header().Get("ETag"); etag != fmt.Sprintf(`"%d"`, cliente.Version) {
+			t.Errorf("should have return etag of version %d, got: %s", cliente.Version, etag)
+		}
 	})
 
 	t.Run("get cliente by CPF", func(t *testing.T) {
@@ -211,9 +418,9 @@ func TestHandlers(t *testing.T) {
 	t.Run("create cliente", func(t *testing.T) {
 		cliente := entities.Cliente{
 			Name:   "Fulano",
-			CPF:    "83483483423",
+			CPF:    "83483483446",
 			Email:  "outro@email.com",
-			Active: false,
+			Active: boolPtr(false),
 		}
 
 		c, err := json.Marshal(cliente)
@@ -240,9 +447,9 @@ func TestHandlers(t *testing.T) {
 		clienteMock := clienteUCMock.Base[cUuid]
 		cliente := entities.Cliente{
 			Name:   "Ciclano2",
-			CPF:    "74374374326",
+			CPF:    "74374374302",
 			Email:  "outro2@email.com",
-			Active: false,
+			Active: boolPtr(false),
 		}
 
 		c, err := json.Marshal(cliente)
@@ -264,11 +471,74 @@ func TestHandlers(t *testing.T) {
 		}
 
 		clienteMockUpdated := clienteUCMock.Base[cUuid]
+		if etag := rr.Header().Get("ETag"); etag != fmt.Sprintf(`"%d"`, clienteMockUpdated.Version()) {
+			t.Errorf("should have return etag of version %d, got: %s", clienteMockUpdated.Version(), etag)
+		}
 		if clienteMockUpdated.CPF() != cliente.CPF {
 			t.Errorf("should have updated cliente")
 		}
 	})
 
+	t.Run("patch cliente with stale etag", func(t *testing.T) {
+		b := bytes.NewBufferString(`{"name":"Beltrano"}`)
+		req, err := http.NewRequest("PATCH", fmt.Sprintf("/clientes/%s", existentClientID), b)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+		req.Header.Set("If-Match", `"999"`)
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusPreconditionFailed {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusPreconditionFailed)
+		}
+	})
+
+	t.Run("put cliente with weak etag", func(t *testing.T) {
+		b := bytes.NewBufferString(`{"name":"Beltrano","cpf":"74374374302","email":"outro2@email.com","active":true}`)
+		req, err := http.NewRequest("PUT", fmt.Sprintf("/clientes/%s", existentClientID), b)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+		req.Header.Set("If-Match", `W/"1"`)
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusPreconditionFailed {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusPreconditionFailed)
+		}
+	})
+
+	t.Run("patch cliente", func(t *testing.T) {
+		b := bytes.NewBufferString(`{"name":"Beltrano"}`)
+		req, err := http.NewRequest("PATCH", fmt.Sprintf("/clientes/%s", existentClientID), b)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set("If-Match", fmt.Sprintf(`"%d"`, clienteUCMock.Base[uuid.MustParse(existentClientID)].Version()))
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+
+		cliente := entities.Cliente{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &cliente); err != nil {
+			t.Errorf("unmarshalling json: %s", err)
+		}
+		if cliente.Name != "Beltrano" {
+			t.Errorf("should have patched name, got: %s", cliente.Name)
+		}
+		if cliente.CPF != "74374374302" {
+			t.Errorf("should have kept cpf, got: %s", cliente.CPF)
+		}
+	})
+
 	t.Run("remove cliente", func(t *testing.T) {
 		req, err := http.NewRequest("DELETE", fmt.Sprintf("/clientes/%s", existentClientID), nil)
 		if err != nil {
@@ -282,9 +552,261 @@ func TestHandlers(t *testing.T) {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
 		}
 
+		cUuid, _ := domainEntities.StringToID(existentClientID)
+		if c, ok := clienteUCMock.Base[cUuid]; !ok || c.Active() {
+			t.Errorf("should have kept the cliente deactivated")
+		}
+	})
+
+	t.Run("reactivate cliente", func(t *testing.T) {
+		req, err := http.NewRequest("POST", fmt.Sprintf("/clientes/%s/reactivate", existentClientID), nil)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+
+		var cliente entities.Cliente
+		if err := json.NewDecoder(rr.Body).Decode(&cliente); err != nil {
+			t.Fatalf("decoding body, error: %s", err)
+		}
+		if cliente.Active == nil || !*cliente.Active || cliente.DeletedAt != nil {
+			t.Errorf("should have reactivated cliente, got: %+v", cliente)
+		}
+	})
+
+	t.Run("export cliente", func(t *testing.T) {
+		req, err := http.NewRequest("GET", fmt.Sprintf("/clientes/%s/export", existentClientID), nil)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+
+		var export entities.ClienteExport
+		if err := json.NewDecoder(rr.Body).Decode(&export); err != nil {
+			t.Fatalf("decoding body, error: %s", err)
+		}
+		cpf := clienteUCMock.Base[uuid.MustParse(existentClientID)].CPF()
+		if rows := export.Sections["cliente"]; len(rows) != 1 || rows[0]["cpf"] != cpf {
+			t.Errorf("should have exported the cliente record, got: %v", export.Sections)
+		}
+	})
+
+	t.Run("export cliente as zip", func(t *testing.T) {
+		req, err := http.NewRequest("GET", fmt.Sprintf("/clientes/%s/export?format=zip", existentClientID), nil)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+		if err != nil {
+			t.Fatalf("reading zip, error: %s", err)
+		}
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		if !slices.Equal(names, []string{"cliente.json", "cliente.csv"}) {
+			t.Errorf("should have json and csv files, got: %v", names)
+		}
+
+		f, _ := zr.Open("cliente.csv")
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			t.Fatalf("reading csv, error: %s", err)
+		}
+		cpf := clienteUCMock.Base[uuid.MustParse(existentClientID)].CPF()
+		if len(records) != 2 || records[0][2] != "cpf" || records[1][2] != cpf {
+			t.Errorf("should have header and cliente row, got: %v", records)
+		}
+	})
+
+	t.Run("anonymize cliente", func(t *testing.T) {
+		b := bytes.NewBufferString(`{"reason":"lgpd request"}`)
+		req, err := http.NewRequest("POST", fmt.Sprintf("/clientes/%s/anonymize", existentClientID), b)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+
+		var cliente entities.Cliente
+		if err := json.NewDecoder(rr.Body).Decode(&cliente); err != nil {
+			t.Fatalf("decoding body, error: %s", err)
+		}
+		if cliente.AnonymizedAt == nil || cliente.AnonymizationReason != "lgpd request" || cliente.CPF == existentClientCPF {
+			t.Errorf("should have anonymized cliente, got: %+v", cliente)
+		}
+
+		req, _ = http.NewRequest("PATCH", fmt.Sprintf("/clientes/%s", existentClientID), bytes.NewBufferString(`{"name":"Beltrano"}`))
+		rr = httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusConflict {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusConflict)
+		}
+	})
+
+	t.Run("cliente history", func(t *testing.T) {
+		req, err := http.NewRequest("GET", fmt.Sprintf("/clientes/%s/history", existentClientID), nil)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+		req.Header.Set(middleware.RequestIDHeader, "req-123")
+
+		rr := httptest.NewRecorder()
+		middleware.RequestID(routes).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+
+		var history entities.AuditHistory
+		if err := json.NewDecoder(rr.Body).Decode(&history); err != nil {
+			t.Fatalf("decoding body, error: %s", err)
+		}
+		if len(history.Data) != 1 || history.Data[0].Action != domainEntities.AuditCreated {
+			t.Fatalf("should have return the created entry, got: %+v", history.Data)
+		}
+		if history.Data[0].RequestID != "req-123" {
+			t.Errorf("should have tagged the context with the request id, got: %q", history.Data[0].RequestID)
+		}
+	})
+
+	t.Run("purge cliente", func(t *testing.T) {
+		req, err := http.NewRequest("DELETE", fmt.Sprintf("/admin/clientes/%s", existentClientID), nil)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusNoContent {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+		}
+
 		cUuid, _ := domainEntities.StringToID(existentClientID)
 		if _, ok := clienteUCMock.Base[cUuid]; ok {
 			t.Errorf("should have deleted cliente")
 		}
 	})
 }
+
+func TestHandlersProblems(t *testing.T) {
+	routes := AddRoutes(&clienteUCMock, &consentUCMock, &webhookUCMock, &idempotencyUCMock, &verificationUCMock)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   string
+	}{
+		{"invalid id", "GET", "/clientes/not-an-uuid", "", http.StatusBadRequest, "invalid_id"},
+		{"inexistent cliente", "GET", fmt.Sprintf("/clientes/%s", uuid.New()), "", http.StatusNotFound, "not_found"},
+		{"inexistent cpf", "GET", "/clientes?cpf=00000000000", "", http.StatusNotFound, "not_found"},
+		{"malformed body", "POST", "/clientes", "{", http.StatusBadRequest, "malformed_body"},
+		{"invalid cpf", "POST", "/clientes", `{"name":"Fulano","cpf":"123","email":"f@email.com"}`, http.StatusUnprocessableEntity, "validation_failed"},
+		{"existent cpf", "POST", "/clientes", `{"name":"Fulano","cpf":"98765432100","email":"f@email.com"}`, http.StatusConflict, "cliente_already_exists_for_cpf"},
+		{"put without active", "PUT", fmt.Sprintf("/clientes/%s", uuid.New()), `{"name":"Fulano","cpf":"52998224725","email":"f@email.com"}`, http.StatusUnprocessableEntity, "validation_failed"},
+		{"patch with null active", "PATCH", fmt.Sprintf("/clientes/%s", uuid.New()), `{"active":null}`, http.StatusUnprocessableEntity, "validation_failed"},
+		{"patch with wrong type", "PATCH", fmt.Sprintf("/clientes/%s", uuid.New()), `{"name":10}`, http.StatusBadRequest, "malformed_body"},
+		{"patch inexistent cliente", "PATCH", fmt.Sprintf("/clientes/%s", uuid.New()), `{"name":"Fulano"}`, http.StatusNotFound, "not_found"},
+		{"invalid sort", "GET", "/clientes?sort=email", "", http.StatusBadRequest, "invalid_list_options"},
+		{"invalid cursor", "GET", "/clientes?cursor=bm90LWpzb24", "", http.StatusBadRequest, "invalid_list_options"},
+		{"removing inexistent cliente", "DELETE", fmt.Sprintf("/clientes/%s", uuid.New()), "", http.StatusNotFound, "not_found"},
+		{"reactivating inexistent cliente", "POST", fmt.Sprintf("/clientes/%s/reactivate", uuid.New()), "", http.StatusNotFound, "not_found"},
+		{"purging inexistent cliente", "DELETE", fmt.Sprintf("/admin/clientes/%s", uuid.New()), "", http.StatusNotFound, "not_found"},
+		{"anonymizing inexistent cliente", "POST", fmt.Sprintf("/clientes/%s/anonymize", uuid.New()), `{"reason":"lgpd"}`, http.StatusNotFound, "not_found"},
+		{"anonymizing with malformed body", "POST", fmt.Sprintf("/clientes/%s/anonymize", uuid.New()), `{"reason":`, http.StatusBadRequest, "malformed_body"},
+		{"invalid export format", "GET", fmt.Sprintf("/clientes/%s/export?format=xml", uuid.New()), "", http.StatusBadRequest, "invalid_export_format"},
+		{"exporting inexistent cliente", "GET", fmt.Sprintf("/clientes/%s/export", uuid.New()), "", http.StatusNotFound, "not_found"},
+		{"invalid include_inactive", "GET", "/clientes?include_inactive=maybe", "", http.StatusBadRequest, "invalid_list_options"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			routes.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.status {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.status)
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("handler returned wrong content type: got %s", ct)
+			}
+
+			problem := entities.Problem{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+				t.Errorf("unmarshalling json: %s", err)
+			}
+			if problem.Code != tt.code {
+				t.Errorf("handler returned wrong problem code: got %s want %s", problem.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestHandlersValidationProblem(t *testing.T) {
+	routes := AddRoutes(&clienteUCMock, &consentUCMock, &webhookUCMock, &idempotencyUCMock, &verificationUCMock)
+
+	body := bytes.NewBufferString(`{"name":"ab","cpf":"123","email":"invalid"}`)
+	req, err := http.NewRequest("POST", "/clientes", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	routes.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+	}
+
+	problem := entities.Problem{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("unmarshalling json: %s", err)
+	}
+
+	fields := []string{}
+	for _, e := range problem.Errors {
+		fields = append(fields, e.Field)
+	}
+	if !slices.Equal(fields, []string{"name", "cpf", "email"}) {
+		t.Errorf("should have reported every invalid field, got: %v", fields)
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+	domainEntities "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+
+	"github.com/google/uuid"
+)
+
+// VerificationUseCaseMock sends "123456" to the clientes of the cliente use
+// case mock, once.
+type VerificationUseCaseMock struct {
+	Sent map[domainEntities.ID]bool
+}
+
+var verificationUCMock = VerificationUseCaseMock{
+	Sent: make(map[domainEntities.ID]bool),
+}
+
+func (v *VerificationUseCaseMock) RequestEmailVerification(ctx context.Context, id uuid.UUID) error {
+	if _, err := clienteUCMock.GetClienteById(ctx, id); err != nil {
+		return err
+	}
+	if v.Sent[id] {
+		return entityErr.ErrVerificationRequestedTooSoon
+	}
+	v.Sent[id] = true
+	return nil
+}
+
+func (v *VerificationUseCaseMock) ConfirmEmailVerification(ctx context.Context, id uuid.UUID, code string) (*domainEntities.Cliente, error) {
+	if !v.Sent[id] {
+		return nil, entityErr.ErrVerificationCodeExpired
+	}
+	if code != "123456" {
+		return nil, entityErr.ErrInvalidVerificationCode
+	}
+	c, err := clienteUCMock.GetClienteById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	delete(v.Sent, id)
+	return domainEntities.New(c.Id(), c.Name(), c.CPF(), c.Email(), c.Active(),
+		domainEntities.WithVersion(c.Version()+1), domainEntities.WithEmailVerified(time.Now()))
+}
+
+func TestEmailVerification(t *testing.T) {
+	routes := AddRoutes(&clienteUCMock, &consentUCMock, &webhookUCMock, &idempotencyUCMock, &verificationUCMock)
+	id := domainEntities.NewID()
+	c, _ := domainEntities.New(id, "Verificado", "11144477735", "verificado@email.com", true)
+	clienteUCMock.Base[id] = c
+	t.Cleanup(func() {
+		delete(clienteUCMock.Base, id)
+		clear(verificationUCMock.Sent)
+	})
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+		return rr
+	}
+
+	path := "/clientes/" + id.String() + "/email-verification"
+
+	t.Run("request and confirm", func(t *testing.T) {
+		if rr := post(path, ""); rr.Code != http.StatusAccepted {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusAccepted)
+		}
+
+		rr := post(path+"/confirm", `{"code":"123456"}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+
+		var c entities.Cliente
+		if err := json.NewDecoder(rr.Body).Decode(&c); err != nil {
+			t.Fatalf("decoding response, error: %s", err)
+		}
+		if c.EmailVerified == nil || !*c.EmailVerified || c.EmailVerifiedAt == nil {
+			t.Errorf("should have return the verified cliente, got: %+v", c)
+		}
+	})
+
+	t.Run("request another code", func(t *testing.T) {
+		if rr := post(path, ""); rr.Code != http.StatusAccepted {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusAccepted)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"code already sent", path, "", http.StatusTooManyRequests, "verification_requested_too_soon"},
+		{"wrong code", path + "/confirm", `{"code":"654321"}`, http.StatusUnprocessableEntity, "invalid_verification_code"},
+		{"malformed body", path + "/confirm", `{"code":`, http.StatusBadRequest, "malformed_body"},
+		{"invalid id", "/clientes/123/email-verification", "", http.StatusBadRequest, "invalid_id"},
+		{"unknown cliente", "/clientes/" + uuid.NewString() + "/email-verification", "", http.StatusNotFound, "not_found"},
+		{"code never sent", "/clientes/" + uuid.NewString() + "/email-verification/confirm", `{"code":"123456"}`, http.StatusUnprocessableEntity, "verification_code_expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := post(tt.path, tt.body)
+			if rr.Code != tt.status {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.status)
+			}
+
+			var problem entities.Problem
+			if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+				t.Fatalf("decoding problem, error: %s", err)
+			}
+			if problem.Code != tt.code {
+				t.Errorf("should have return code %q, got: %q", tt.code, problem.Code)
+			}
+		})
+	}
+}
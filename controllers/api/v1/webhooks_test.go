@@ -0,0 +1,278 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/entities"
+	domainEntities "github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+
+	"github.com/google/uuid"
+)
+
+type WebhookUseCaseMock struct {
+	Subs map[domainEntities.ID]domainEntities.WebhookSubscription
+	Sent map[domainEntities.ID]domainEntities.WebhookDelivery
+}
+
+var webhookUCMock = WebhookUseCaseMock{
+	Subs: make(map[domainEntities.ID]domainEntities.WebhookSubscription),
+	Sent: make(map[domainEntities.ID]domainEntities.WebhookDelivery),
+}
+
+func (w *WebhookUseCaseMock) Subscribe(ctx context.Context, sub domainEntities.WebhookSubscription) (domainEntities.WebhookSubscription, error) {
+	if err := sub.Validate(); err != nil {
+		return domainEntities.WebhookSubscription{}, err
+	}
+	sub.ID = domainEntities.NewID()
+	sub.Secret = "whsec_test"
+	sub.CreatedAt = time.Now()
+	w.Subs[sub.ID] = sub
+	return sub, nil
+}
+
+func (w *WebhookUseCaseMock) Subscriptions(ctx context.Context) ([]domainEntities.WebhookSubscription, error) {
+	subs := make([]domainEntities.WebhookSubscription, 0, len(w.Subs))
+	for _, s := range w.Subs {
+		subs = append(subs, s)
+	}
+	return subs, nil
+}
+
+func (w *WebhookUseCaseMock) Subscription(ctx context.Context, id uuid.UUID) (*domainEntities.WebhookSubscription, error) {
+	s, ok := w.Subs[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	return &s, nil
+}
+
+func (w *WebhookUseCaseMock) Unsubscribe(ctx context.Context, id uuid.UUID) error {
+	if _, ok := w.Subs[id]; !ok {
+		return entityErr.ErrNotFound
+	}
+	delete(w.Subs, id)
+	return nil
+}
+
+func (w *WebhookUseCaseMock) Deliveries(ctx context.Context, id uuid.UUID, status domainEntities.DeliveryStatus) ([]domainEntities.WebhookDelivery, error) {
+	if _, ok := w.Subs[id]; !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	var deliveries []domainEntities.WebhookDelivery
+	for _, d := range w.Sent {
+		if d.SubscriptionID == id && (status == "" || d.Status == status) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+func (w *WebhookUseCaseMock) Redeliver(ctx context.Context, id, deliveryID uuid.UUID) (*domainEntities.WebhookDelivery, error) {
+	d, ok := w.Sent[deliveryID]
+	if !ok || d.SubscriptionID != id {
+		return nil, entityErr.ErrNotFound
+	}
+	d.Redeliver(time.Now())
+	w.Sent[deliveryID] = d
+	return &d, nil
+}
+
+func TestWebhookHandlers(t *testing.T) {
+	routes := AddRoutes(&clienteUCMock, &consentUCMock, &webhookUCMock, &idempotencyUCMock, &verificationUCMock)
+
+	var created entities.WebhookSubscription
+
+	t.Run("create webhook", func(t *testing.T) {
+		b := bytes.NewBufferString(`{"url":"https://example.com/hooks","events":["ClienteCreated"]}`)
+		req, err := http.NewRequest("POST", "/webhooks", b)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&created); err != nil {
+			t.Fatalf("decoding body, error: %s", err)
+		}
+		if created.Secret == "" || rr.Header().Get("Location") != "/v1/webhooks/"+created.ID {
+			t.Errorf("should have return the secret and location, got: %+v", created)
+		}
+	})
+
+	subID, _ := domainEntities.StringToID(created.ID)
+	dead := domainEntities.WebhookDelivery{
+		ID: domainEntities.NewID(), SubscriptionID: subID, EventID: domainEntities.NewID(),
+		EventType: domainEntities.ClienteCreated, Status: domainEntities.DeliveryDead, Attempts: domainEntities.WebhookMaxAttempts,
+	}
+	delivered := domainEntities.WebhookDelivery{
+		ID: domainEntities.NewID(), SubscriptionID: subID, EventID: domainEntities.NewID(),
+		EventType: domainEntities.ClienteCreated, Status: domainEntities.DeliveryDelivered, Attempts: 1,
+	}
+	webhookUCMock.Sent[dead.ID] = dead
+	webhookUCMock.Sent[delivered.ID] = delivered
+
+	t.Run("get webhook without secret", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/webhooks/"+created.ID, nil)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		var sub entities.WebhookSubscription
+		if err := json.NewDecoder(rr.Body).Decode(&sub); err != nil {
+			t.Fatalf("decoding body, error: %s", err)
+		}
+		if sub.ID != created.ID || sub.Secret != "" {
+			t.Errorf("should have return the webhook without its secret, got: %+v", sub)
+		}
+	})
+
+	t.Run("list webhooks", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/webhooks", nil)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		var list entities.WebhookSubscriptionList
+		if err := json.NewDecoder(rr.Body).Decode(&list); err != nil {
+			t.Fatalf("decoding body, error: %s", err)
+		}
+		if len(list.Data) != 1 || list.Data[0].ID != created.ID {
+			t.Errorf("should have return the created webhook, got: %+v", list.Data)
+		}
+	})
+
+	t.Run("list deliveries", func(t *testing.T) {
+		req, err := http.NewRequest("GET", fmt.Sprintf("/webhooks/%s/deliveries?status=delivered", created.ID), nil)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		var list entities.WebhookDeliveryList
+		if err := json.NewDecoder(rr.Body).Decode(&list); err != nil {
+			t.Fatalf("decoding body, error: %s", err)
+		}
+		if len(list.Data) != 1 || list.Data[0].ID != delivered.ID.String() {
+			t.Errorf("should have return the delivered delivery, got: %+v", list.Data)
+		}
+	})
+
+	t.Run("list dead letters", func(t *testing.T) {
+		req, err := http.NewRequest("GET", fmt.Sprintf("/webhooks/%s/dead-letters", created.ID), nil)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		var list entities.WebhookDeliveryList
+		if err := json.NewDecoder(rr.Body).Decode(&list); err != nil {
+			t.Fatalf("decoding body, error: %s", err)
+		}
+		if len(list.Data) != 1 || list.Data[0].ID != dead.ID.String() {
+			t.Errorf("should have return the dead letter, got: %+v", list.Data)
+		}
+	})
+
+	t.Run("redeliver dead letter", func(t *testing.T) {
+		req, err := http.NewRequest("POST", fmt.Sprintf("/webhooks/%s/deliveries/%s/redeliver", created.ID, dead.ID), nil)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusAccepted {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusAccepted)
+		}
+
+		var d entities.WebhookDelivery
+		if err := json.NewDecoder(rr.Body).Decode(&d); err != nil {
+			t.Fatalf("decoding body, error: %s", err)
+		}
+		if d.Status != domainEntities.DeliveryPending || d.Attempts != 0 {
+			t.Errorf("should have queued the delivery again, got: %+v", d)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   string
+	}{
+		{"plain http url", "POST", "/webhooks", `{"url":"http://example.com/hooks"}`, http.StatusUnprocessableEntity, "validation_failed"},
+		{"unknown event", "POST", "/webhooks", `{"url":"https://example.com/hooks","events":["PedidoCreated"]}`, http.StatusUnprocessableEntity, "validation_failed"},
+		{"malformed body", "POST", "/webhooks", `{`, http.StatusBadRequest, "malformed_body"},
+		{"invalid id", "GET", "/webhooks/abc", "", http.StatusBadRequest, "invalid_id"},
+		{"invalid delivery id", "POST", fmt.Sprintf("/webhooks/%s/deliveries/abc/redeliver", created.ID), "", http.StatusBadRequest, "invalid_id"},
+		{"invalid status", "GET", fmt.Sprintf("/webhooks/%s/deliveries?status=lost", created.ID), "", http.StatusBadRequest, "invalid_delivery_status"},
+		{"delivery of another webhook", "POST", fmt.Sprintf("/webhooks/%s/deliveries/%s/redeliver", uuid.New(), dead.ID), "", http.StatusNotFound, "not_found"},
+		{"inexistent webhook", "GET", fmt.Sprintf("/webhooks/%s", uuid.New()), "", http.StatusNotFound, "not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatalf("creating request, error: %s", err)
+			}
+
+			rr := httptest.NewRecorder()
+			routes.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.status)
+			}
+
+			var problem entities.Problem
+			if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+				t.Fatalf("decoding problem, error: %s", err)
+			}
+			if problem.Code != tt.code {
+				t.Errorf("should have return code %q, got: %q", tt.code, problem.Code)
+			}
+		})
+	}
+
+	t.Run("delete webhook", func(t *testing.T) {
+		req, err := http.NewRequest("DELETE", "/webhooks/"+created.ID, nil)
+		if err != nil {
+			t.Fatalf("creating request, error: %s", err)
+		}
+
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusNoContent {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+		}
+		if _, ok := webhookUCMock.Subs[subID]; ok {
+			t.Error("should have deleted the webhook")
+		}
+	})
+}
@@ -0,0 +1,8 @@
+-- Sample clientes for local development. The schema is created by the
+-- application migrations, run this after the app has started once.
+INSERT INTO "clientes" ("ativo", "id", "cpf", "email", "nome") VALUES
+('t',	'63a59178-39f8-4a28-a2c7-989a57ca7b54',	'12312312387',	'filipe@email.com',	'FILIPE ANDRADE'),
+('f',	'5793fc61-8d22-4183-9b20-079e624074a3',	'78978978932',	'murilo@email.com',	'MURILO MARTINS'),
+('t',	'b57b4dcc-c47f-40f0-8331-6185bb9b3568',	'45645645600',	'joao@email.com',	'JOAO MARCOS'),
+('t',	'b57b4dcc-c47f-40f0-8331-6185bb343443',	'35645645600',	'caio@email.com',	'CAIO MATOS')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,156 @@
+package entities
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreated     AuditAction = "created"
+	AuditUpdated     AuditAction = "updated"
+	AuditDeactivated AuditAction = "deactivated"
+	AuditReactivated AuditAction = "reactivated"
+	AuditAnonymized  AuditAction = "anonymized"
+	AuditDeleted     AuditAction = "deleted"
+)
+
+// personalFields are redacted from the audit log once a cliente is
+// anonymized.
+var personalFields = []string{"name", "cpf", "email"}
+
+// FieldChange is the value of a field before and after a mutation, empty when
+// the cliente did not exist. Redacted changes carry no values.
+type FieldChange struct {
+	Field    string
+	Before   string
+	After    string
+	Redacted bool
+}
+
+// AuditEntry records a mutation of a cliente. RecordedAt is set by the
+// repository.
+type AuditEntry struct {
+	ClienteID  ID
+	Action     AuditAction
+	Changes    []FieldChange
+	Actor      string
+	RequestID  string
+	RecordedAt time.Time
+}
+
+// AuditMeta says who is behind the mutations made with a context.
+type AuditMeta struct {
+	Actor     string
+	RequestID string
+}
+
+type auditMetaKey struct{}
+
+func WithAuditMeta(ctx context.Context, meta AuditMeta) context.Context {
+	return context.WithValue(ctx, auditMetaKey{}, meta)
+}
+
+func AuditMetaFrom(ctx context.Context) AuditMeta {
+	meta, _ := ctx.Value(auditMetaKey{}).(AuditMeta)
+	return meta
+}
+
+// NewAuditEntry records the change from before to after, either may be nil
+// when the cliente is created or deleted. Anonymizations never carry the
+// personal data they erased.
+func NewAuditEntry(ctx context.Context, action AuditAction, before, after *Cliente) AuditEntry {
+	meta := AuditMetaFrom(ctx)
+
+	entry := AuditEntry{
+		Action:    action,
+		Changes:   Diff(before, after),
+		Actor:     meta.Actor,
+		RequestID: meta.RequestID,
+	}
+	if after != nil {
+		entry.ClienteID = after.Id()
+	} else if before != nil {
+		entry.ClienteID = before.Id()
+	}
+
+	// what an anonymization or a purge erases is not kept in the history
+	if action == AuditAnonymized || action == AuditDeleted {
+		entry.Changes = RedactChanges(entry.Changes)
+	}
+
+	return entry
+}
+
+// Diff lists the fields that differ between before and after.
+func Diff(before, after *Cliente) []FieldChange {
+	b, a := auditFields(before), auditFields(after)
+
+	var changes []FieldChange
+	for i := range b {
+		if b[i][1] != a[i][1] {
+			changes = append(changes, FieldChange{Field: b[i][0], Before: b[i][1], After: a[i][1]})
+		}
+	}
+
+	return changes
+}
+
+// RedactChanges returns changes with the values of personal fields removed.
+func RedactChanges(changes []FieldChange) []FieldChange {
+	out := slices.Clone(changes)
+	for i, change := range out {
+		if slices.Contains(personalFields, change.Field) {
+			out[i] = FieldChange{Field: change.Field, Redacted: true}
+		}
+	}
+
+	return out
+}
+
+func auditFields(c *Cliente) [][2]string {
+	if c == nil {
+		c = &Cliente{}
+	}
+
+	active := ""
+	if c.id != (ID{}) {
+		active = strconv.FormatBool(c.active)
+	}
+
+	return [][2]string{
+		{"name", c.name},
+		{"cpf", c.cpf},
+		{"email", c.email},
+		{"active", active},
+		{"deleted_at", exportTime(c.deletedAt)},
+		{"anonymized_at", exportTime(c.anonymizedAt)},
+		{"anonymization_reason", c.anonymizationReason},
+		{"email_verified_at", exportTime(c.emailVerifiedAt)},
+	}
+}
+
+// AuditSection exports the change history of a cliente, one row per changed
+// field.
+func AuditSection(history []AuditEntry) ExportSection {
+	section := ExportSection{
+		Name:    "history",
+		Columns: []string{"recorded_at", "action", "actor", "request_id", "field", "before", "after"},
+		Rows:    make([][]string, 0, len(history)),
+	}
+	for _, e := range history {
+		prefix := []string{exportTime(e.RecordedAt), string(e.Action), e.Actor, e.RequestID}
+		if len(e.Changes) == 0 {
+			section.Rows = append(section.Rows, append(slices.Clone(prefix), "", "", ""))
+			continue
+		}
+		for _, change := range e.Changes {
+			section.Rows = append(section.Rows, append(slices.Clone(prefix), change.Field, change.Before, change.After))
+		}
+	}
+
+	return section
+}
@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	id := NewID()
+	before, _ := New(id, "Fulano", "52998224725", "fulano@email.com", true)
+	after, _ := New(id, "Ciclano", "52998224725", "fulano@email.com", false)
+
+	t.Run("changed fields", func(t *testing.T) {
+		want := []FieldChange{
+			{Field: "name", Before: "Fulano", After: "Ciclano"},
+			{Field: "active", Before: "true", After: "false"},
+		}
+		if got := Diff(before, after); !slices.Equal(got, want) {
+			t.Errorf("want: %+v, got: %+v", want, got)
+		}
+	})
+
+	t.Run("created cliente", func(t *testing.T) {
+		got := Diff(nil, before)
+		if len(got) != 4 {
+			t.Fatalf("should list name, cpf, email and active, got: %+v", got)
+		}
+		if got[0] != (FieldChange{Field: "name", After: "Fulano"}) {
+			t.Errorf("should have no value before, got: %+v", got[0])
+		}
+	})
+
+	t.Run("unchanged cliente", func(t *testing.T) {
+		if got := Diff(before, before); len(got) != 0 {
+			t.Errorf("should have no changes, got: %+v", got)
+		}
+	})
+}
+
+func TestNewAuditEntry(t *testing.T) {
+	ctx := WithAuditMeta(context.Background(), AuditMeta{Actor: "atendente", RequestID: "req-1"})
+	before, _ := New(NewID(), "Fulano", "52998224725", "fulano@email.com", true)
+
+	t.Run("deleted cliente", func(t *testing.T) {
+		entry := NewAuditEntry(ctx, AuditDeleted, before, nil)
+		if entry.ClienteID != before.Id() || entry.Actor != "atendente" || entry.RequestID != "req-1" {
+			t.Errorf("should keep cliente, actor and request id, got: %+v", entry)
+		}
+		if entry.Changes[0] != (FieldChange{Field: "name", Redacted: true}) {
+			t.Errorf("should redact the name, got: %+v", entry.Changes[0])
+		}
+	})
+
+	t.Run("anonymized cliente", func(t *testing.T) {
+		after, err := before.Anonymize(time.Now().UTC(), "lgpd request")
+		if err != nil {
+			t.Fatalf("anonymizing, got error: %s", err)
+		}
+
+		entry := NewAuditEntry(ctx, AuditAnonymized, before, after)
+		for _, change := range entry.Changes {
+			personal := slices.Contains(personalFields, change.Field)
+			if personal && (!change.Redacted || change.Before != "" || change.After != "") {
+				t.Errorf("should redact %s, got: %+v", change.Field, change)
+			}
+			if !personal && change.Redacted {
+				t.Errorf("should not redact %s", change.Field)
+			}
+		}
+	})
+}
+
+func TestAuditSection(t *testing.T) {
+	history := []AuditEntry{
+		{Action: AuditCreated, Changes: []FieldChange{{Field: "name", After: "Fulano"}, {Field: "active", After: "true"}}},
+		{Action: AuditDeleted},
+	}
+
+	section := AuditSection(history)
+	if section.Name != "history" {
+		t.Errorf("want section history, got: %s", section.Name)
+	}
+	if len(section.Rows) != 3 {
+		t.Fatalf("should have a row per change and one for the entry without changes, got: %d", len(section.Rows))
+	}
+	for _, row := range section.Rows {
+		if len(row) != len(section.Columns) {
+			t.Errorf("should have %d columns, got: %v", len(section.Columns), row)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/mail"
 	"regexp"
 	"strings"
+	"time"
 
 	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
 )
@@ -14,20 +15,81 @@ type Cliente struct {
 	cpf    string
 	email  string
 	active bool
+
+	createdAt time.Time
+	updatedAt time.Time
+	deletedAt time.Time
+	version   int
+
+	anonymizedAt        time.Time
+	anonymizationReason string
+
+	emailVerifiedAt time.Time
+}
+
+// Option sets the state of a Cliente that is kept by the repository rather
+// than provided by the user.
+type Option func(*Cliente)
+
+func WithTimestamps(createdAt, updatedAt time.Time) Option {
+	return func(c *Cliente) {
+		c.createdAt = createdAt
+		c.updatedAt = updatedAt
+	}
+}
+
+// WithVersion sets the version used for optimistic concurrency, 0 means the
+// cliente was not read from a repository.
+func WithVersion(version int) Option {
+	return func(c *Cliente) {
+		c.version = version
+	}
+}
+
+// WithDeletedAt sets when the cliente was deactivated through a removal.
+func WithDeletedAt(deletedAt time.Time) Option {
+	return func(c *Cliente) {
+		c.deletedAt = deletedAt
+	}
 }
 
+// WithAnonymization marks the cliente as anonymized at the given time for the
+// given reason.
+func WithAnonymization(anonymizedAt time.Time, reason string) Option {
+	return func(c *Cliente) {
+		c.anonymizedAt = anonymizedAt
+		c.anonymizationReason = reason
+	}
+}
+
+// WithEmailVerified marks the e-mail of the cliente as verified at the given
+// time.
+func WithEmailVerified(verifiedAt time.Time) Option {
+	return func(c *Cliente) {
+		c.emailVerifiedAt = verifiedAt
+	}
+}
+
+// anonymizedName replaces the name of anonymized clientes, cpf and e-mail get
+// random tokens instead so they stay unique.
+const anonymizedName = "anonymized"
+
 var (
-	cpfPattern = regexp.MustCompile(`^\d{11}$`)
+	cpfPattern   = regexp.MustCompile(`^\d{11}$`)
+	cpfFormatted = regexp.MustCompile(`^\d{3}\.\d{3}\.\d{3}-\d{2}$`)
 )
 
-func New(id ID, name, cpf, email string, active bool) (*Cliente, error) {
+func New(id ID, name, cpf, email string, active bool, opts ...Option) (*Cliente, error) {
 	c := Cliente{
 		id:     id,
 		name:   strings.TrimSpace(name),
-		cpf:    cpf,
+		cpf:    NormalizeCPF(cpf),
 		email:  strings.TrimSpace(email),
 		active: active,
 	}
+	for _, opt := range opts {
+		opt(&c)
+	}
 
 	if err := c.Validate(); err != nil {
 		return nil, err
@@ -36,6 +98,24 @@ func New(id ID, name, cpf, email string, active bool) (*Cliente, error) {
 	return &c, nil
 }
 
+// Restore rebuilds a cliente kept by a repository without validating it: the
+// stored rows may predate the current rules, like the legacy CPFs failing the
+// check digits, and must still be readable. Anything new goes through New.
+func Restore(id ID, name, cpf, email string, active bool, opts ...Option) *Cliente {
+	c := Cliente{
+		id:     id,
+		name:   name,
+		cpf:    cpf,
+		email:  email,
+		active: active,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return &c
+}
+
 func (c *Cliente) Id() ID {
 	return c.id
 }
@@ -56,22 +136,196 @@ func (c *Cliente) Active() bool {
 	return c.active
 }
 
+func (c *Cliente) CreatedAt() time.Time {
+	return c.createdAt
+}
+
+func (c *Cliente) UpdatedAt() time.Time {
+	return c.updatedAt
+}
+
+// DeletedAt is the zero time unless the cliente was removed and not
+// reactivated since.
+func (c *Cliente) DeletedAt() time.Time {
+	return c.deletedAt
+}
+
+func (c *Cliente) Version() int {
+	return c.version
+}
+
+func (c *Cliente) Anonymized() bool {
+	return !c.anonymizedAt.IsZero()
+}
+
+func (c *Cliente) AnonymizedAt() time.Time {
+	return c.anonymizedAt
+}
+
+func (c *Cliente) AnonymizationReason() string {
+	return c.anonymizationReason
+}
+
+// EmailVerified tells whether the cliente proved to own its e-mail, see
+// services.VerificationService.
+func (c *Cliente) EmailVerified() bool {
+	return !c.emailVerifiedAt.IsZero()
+}
+
+func (c *Cliente) EmailVerifiedAt() time.Time {
+	return c.emailVerifiedAt
+}
+
+// Anonymize returns a copy of c with name, cpf and e-mail replaced by tokens
+// that have no relation to the original data, so it cannot be recovered. The
+// cliente is deactivated and no longer accepts changes.
+func (c *Cliente) Anonymize(at time.Time, reason string) (*Cliente, error) {
+	if c.Anonymized() {
+		return nil, entityErr.ErrClienteAnonymized
+	}
+
+	token := strings.ReplaceAll(NewID().String(), "-", "")
+
+	n := *c
+	n.name = anonymizedName
+	n.cpf = "anon-" + token
+	n.email = "anon-" + token + "@anonymized.invalid"
+	n.active = false
+	n.emailVerifiedAt = time.Time{}
+	n.anonymizedAt = at
+	n.anonymizationReason = strings.TrimSpace(reason)
+
+	if err := n.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &n, nil
+}
+
+// ClientePatch is a partial update of a Cliente, nil fields are left as they
+// are. ExpectedVersion, when not 0, must match the current version.
+type ClientePatch struct {
+	Name   *string
+	CPF    *string
+	Email  *string
+	Active *bool
+
+	ExpectedVersion int
+}
+
+// ValidateForOwner rejects the changes clientes cannot make to their own
+// record: their cpf identifies them and only the back office deactivates them.
+func (p ClientePatch) ValidateForOwner() error {
+	var verr entityErr.ValidationError
+
+	if p.CPF != nil {
+		verr.Add("cpf", "not_editable", entityErr.ErrNotEditableByOwner)
+	}
+	if p.Active != nil {
+		verr.Add("active", "not_editable", entityErr.ErrNotEditableByOwner)
+	}
+
+	return verr.Err()
+}
+
+// Apply returns a validated copy of c with the fields present in p replaced.
+func (c *Cliente) Apply(p ClientePatch) (*Cliente, error) {
+	if c.Anonymized() {
+		return nil, entityErr.ErrClienteAnonymized
+	}
+
+	n := *c
+	if p.Name != nil {
+		n.name = strings.TrimSpace(*p.Name)
+	}
+	if p.CPF != nil {
+		n.cpf = NormalizeCPF(*p.CPF)
+	}
+	if p.Email != nil {
+		n.email = strings.TrimSpace(*p.Email)
+	}
+	if p.Active != nil {
+		n.active = *p.Active
+	}
+
+	if err := n.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &n, nil
+}
+
+// Validate checks the cliente data. An anonymized cliente only holds tokens,
+// so the only thing left to check is that the reason was recorded.
 func (c *Cliente) Validate() error {
-	if len(c.name) == 0 {
-		return entityErr.ErrNameRequired
+	var verr entityErr.ValidationError
+
+	if c.Anonymized() {
+		if c.anonymizationReason == "" {
+			verr.Add("reason", "required", entityErr.ErrAnonymizationReasonRequired)
+		}
+
+		return verr.Err()
 	}
 
-	if len(c.name) <= 3 {
-		return entityErr.ErrNameTooShort
+	if len(c.name) == 0 {
+		verr.Add("name", "required", entityErr.ErrNameRequired)
+	} else if len(c.name) <= 3 {
+		verr.Add("name", "min_length", entityErr.ErrNameTooShort)
 	}
 
-	if !(cpfPattern.MatchString(c.cpf)) {
-		return entityErr.ErrInvalidCPF
+	if !validCPF(c.cpf) {
+		verr.Add("cpf", "cpf", entityErr.ErrInvalidCPF)
 	}
 
 	if _, err := mail.ParseAddress(c.email); err != nil {
-		return entityErr.ErrInvalidEmail
+		verr.Add("email", "email", entityErr.ErrInvalidEmail)
+	}
+
+	return verr.Err()
+}
+
+// NormalizeCPF strips the punctuation of a CPF written as 000.000.000-00,
+// anything else is returned untouched for Validate to judge.
+func NormalizeCPF(cpf string) string {
+	cpf = strings.TrimSpace(cpf)
+	if cpfFormatted.MatchString(cpf) {
+		return strings.NewReplacer(".", "", "-", "").Replace(cpf)
+	}
+
+	return cpf
+}
+
+// validCPF checks the format and both check digits of a normalized CPF using
+// the Receita Federal modulo 11 algorithm. Sequences of a single repeated
+// digit pass the check digits but are not valid CPFs.
+func validCPF(cpf string) bool {
+	if !cpfPattern.MatchString(cpf) {
+		return false
+	}
+
+	if strings.Count(cpf, cpf[:1]) == len(cpf) {
+		return false
+	}
+
+	digits := make([]int, len(cpf))
+	for i, r := range cpf {
+		digits[i] = int(r - '0')
+	}
+
+	return cpfCheckDigit(digits[:9]) == digits[9] && cpfCheckDigit(digits[:10]) == digits[10]
+}
+
+func cpfCheckDigit(digits []int) int {
+	sum := 0
+	for i, d := range digits {
+		sum += d * (len(digits) + 1 - i)
+	}
+
+	dv := sum * 10 % 11
+	if dv == 10 {
+		return 0
 	}
 
-	return nil
+	return dv
 }
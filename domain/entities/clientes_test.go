@@ -2,7 +2,9 @@ package entities
 
 import (
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
 )
@@ -11,7 +13,7 @@ func TestCliente(t *testing.T) {
 
 	testId := NewID()
 	testName := "Fulano"
-	testCpf := "12312312312"
+	testCpf := "12312312387"
 	testEmail := "fulano@email.com"
 	testActive := false
 
@@ -57,6 +59,23 @@ func TestCliente(t *testing.T) {
 		}
 	})
 
+	t.Run("cpf check digits", func(t *testing.T) {
+		for _, cpf := range []string{"12312312312", "52998224724", "11111111111", "00000000000"} {
+			_, err := New(testId, testName, cpf, testEmail, testActive)
+			if !errors.Is(err, entityErr.ErrInvalidCPF) {
+				t.Errorf("cpf %s: wanted %s error got %v", cpf, entityErr.ErrInvalidCPF, err)
+			}
+		}
+	})
+
+	t.Run("formatted cpf", func(t *testing.T) {
+		c, err := New(testId, testName, "529.982.247-25", testEmail, testActive)
+		if err != nil {
+			t.Fatalf("wanted no error got %s", err)
+		}
+		assertCorrectString(t, c.CPF(), "52998224725")
+	})
+
 	t.Run("wrong email format", func(t *testing.T) {
 		test2Email := "fulano.c"
 
@@ -70,6 +89,99 @@ func TestCliente(t *testing.T) {
 	})
 }
 
+func TestClienteValidationReport(t *testing.T) {
+	_, err := New(NewID(), "ab", "123", "fulano.c", true)
+
+	var verr *entityErr.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("wanted validation error got %v", err)
+	}
+
+	if len(verr.Fields) != 3 {
+		t.Errorf("wanted 3 violations got %d", len(verr.Fields))
+	}
+
+	for _, want := range []error{entityErr.ErrNameTooShort, entityErr.ErrInvalidCPF, entityErr.ErrInvalidEmail} {
+		if !errors.Is(err, want) {
+			t.Errorf("wanted error to match %s", want)
+		}
+	}
+}
+
+func TestClienteRestore(t *testing.T) {
+	// a legacy row, stored before the check digits were validated
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := Restore(NewID(), "Fulano", "12312312312", "fulano@email.com", true, WithVersion(2), WithTimestamps(at, at))
+
+	assertCorrectString(t, c.CPF(), "12312312312")
+	if c.Version() != 2 || !c.CreatedAt().Equal(at) {
+		t.Errorf("should have kept the stored state, got version %d created at %s", c.Version(), c.CreatedAt())
+	}
+	if err := c.Validate(); !errors.Is(err, entityErr.ErrInvalidCPF) {
+		t.Errorf("wanted %s error got %v", entityErr.ErrInvalidCPF, err)
+	}
+}
+
+func TestClienteAnonymize(t *testing.T) {
+	c, _ := New(NewID(), "Fulano", "12312312387", "fulano@email.com", true, WithVersion(3))
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("anonymizing cliente", func(t *testing.T) {
+		a, err := c.Anonymize(at, "lgpd request 42")
+		if err != nil {
+			t.Fatalf("should not have return error, got: %s", err)
+		}
+
+		for _, v := range []string{a.Name(), a.CPF(), a.Email()} {
+			if strings.Contains(v, "Fulano") || strings.Contains(v, "12312312387") || strings.Contains(v, "fulano") {
+				t.Errorf("should not keep personal data, got: %s", v)
+			}
+		}
+		assertCorrectId(t, a.Id(), c.Id())
+		assertCorrectBoolean(t, a.Active(), false)
+		assertCorrectBoolean(t, a.Anonymized(), true)
+		assertCorrectString(t, a.AnonymizationReason(), "lgpd request 42")
+		if a.Version() != c.Version() {
+			t.Errorf("should keep version for the repository check, want: %d, got: %d", c.Version(), a.Version())
+		}
+
+		b, _ := c.Anonymize(at, "lgpd request 42")
+		if a.CPF() == b.CPF() || a.Email() == b.Email() {
+			t.Errorf("should use a new token each time, got: %s and %s", a.CPF(), b.CPF())
+		}
+	})
+
+	t.Run("anonymizing verified cliente", func(t *testing.T) {
+		v, _ := New(c.Id(), c.Name(), c.CPF(), c.Email(), true, WithEmailVerified(at.Add(-time.Hour)))
+		a, err := v.Anonymize(at, "lgpd request 42")
+		if err != nil {
+			t.Fatalf("should not have return error, got: %s", err)
+		}
+		if a.EmailVerified() || !a.EmailVerifiedAt().IsZero() {
+			t.Errorf("should not keep the verification of the erased e-mail, got: %s", a.EmailVerifiedAt())
+		}
+	})
+
+	t.Run("anonymizing without reason", func(t *testing.T) {
+		_, err := c.Anonymize(at, " ")
+		if !errors.Is(err, entityErr.ErrAnonymizationReasonRequired) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrAnonymizationReasonRequired, err)
+		}
+	})
+
+	t.Run("changing anonymized cliente", func(t *testing.T) {
+		a, _ := c.Anonymize(at, "lgpd request 42")
+		name := "Ciclano"
+
+		if _, err := a.Apply(ClientePatch{Name: &name}); !errors.Is(err, entityErr.ErrClienteAnonymized) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrClienteAnonymized, err)
+		}
+		if _, err := a.Anonymize(at, "again"); !errors.Is(err, entityErr.ErrClienteAnonymized) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrClienteAnonymized, err)
+		}
+	})
+}
+
 func assertCorrectId(t testing.TB, got, want ID) {
 	t.Helper()
 	if got != want {
@@ -0,0 +1,125 @@
+package entities
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+// ConsentPurpose is what a cliente may allow us to do with their data.
+type ConsentPurpose string
+
+const (
+	PurposeMarketingEmail ConsentPurpose = "marketing_email"
+	PurposeSMS            ConsentPurpose = "sms"
+	PurposeDataSharing    ConsentPurpose = "data_sharing"
+)
+
+var ConsentPurposes = []ConsentPurpose{PurposeMarketingEmail, PurposeSMS, PurposeDataSharing}
+
+// ConsentChannel is where the cliente gave or took back a consent.
+type ConsentChannel string
+
+const (
+	ChannelTotem      ConsentChannel = "totem"
+	ChannelApp        ConsentChannel = "app"
+	ChannelWeb        ConsentChannel = "web"
+	ChannelBackOffice ConsentChannel = "backoffice"
+)
+
+var ConsentChannels = []ConsentChannel{ChannelTotem, ChannelApp, ChannelWeb, ChannelBackOffice}
+
+// ConsentRecord is an entry of the append-only consent history of a cliente,
+// granting or revoking one purpose. RecordedAt is set by the repository.
+type ConsentRecord struct {
+	ClienteID     ID
+	Purpose       ConsentPurpose
+	Granted       bool
+	Channel       ConsentChannel
+	PolicyVersion string
+	RecordedAt    time.Time
+}
+
+// Validate checks the record, a grant must say which policy version the
+// cliente agreed to.
+func (r ConsentRecord) Validate() error {
+	var verr entityErr.ValidationError
+
+	if !slices.Contains(ConsentPurposes, r.Purpose) {
+		verr.Add("purpose", "consent_purpose", entityErr.ErrInvalidConsentPurpose)
+	}
+
+	if !slices.Contains(ConsentChannels, r.Channel) {
+		verr.Add("channel", "consent_channel", entityErr.ErrInvalidConsentChannel)
+	}
+
+	if r.Granted && strings.TrimSpace(r.PolicyVersion) == "" {
+		verr.Add("policy_version", "required", entityErr.ErrPolicyVersionRequired)
+	}
+
+	return verr.Err()
+}
+
+// Consent is the current state of one purpose. GrantedAt is when it was last
+// granted and RevokedAt when it was last revoked after that, Channel and
+// PolicyVersion come from the latest record.
+type Consent struct {
+	Purpose       ConsentPurpose
+	Granted       bool
+	GrantedAt     time.Time
+	RevokedAt     time.Time
+	Channel       ConsentChannel
+	PolicyVersion string
+}
+
+// CurrentConsents folds a history, oldest record first, into the state of
+// every purpose it mentions, in the order they first appear.
+func CurrentConsents(history []ConsentRecord) []Consent {
+	var consents []Consent
+	index := make(map[ConsentPurpose]int)
+
+	for _, r := range history {
+		i, ok := index[r.Purpose]
+		if !ok {
+			i = len(consents)
+			index[r.Purpose] = i
+			consents = append(consents, Consent{Purpose: r.Purpose})
+		}
+
+		c := &consents[i]
+		c.Granted = r.Granted
+		c.Channel = r.Channel
+		c.PolicyVersion = r.PolicyVersion
+		if r.Granted {
+			c.GrantedAt = r.RecordedAt
+			c.RevokedAt = time.Time{}
+		} else {
+			c.RevokedAt = r.RecordedAt
+		}
+	}
+
+	return consents
+}
+
+// ConsentSection exports the consent history of a cliente.
+func ConsentSection(history []ConsentRecord) ExportSection {
+	section := ExportSection{
+		Name:    "consents",
+		Columns: []string{"purpose", "granted", "channel", "policy_version", "recorded_at"},
+		Rows:    make([][]string, 0, len(history)),
+	}
+	for _, r := range history {
+		section.Rows = append(section.Rows, []string{
+			string(r.Purpose),
+			strconv.FormatBool(r.Granted),
+			string(r.Channel),
+			r.PolicyVersion,
+			exportTime(r.RecordedAt),
+		})
+	}
+
+	return section
+}
@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+func TestConsentRecordValidate(t *testing.T) {
+	id := NewID()
+
+	tests := []struct {
+		name   string
+		record ConsentRecord
+		want   []error
+	}{
+		{"valid grant", ConsentRecord{ClienteID: id, Purpose: PurposeSMS, Granted: true, Channel: ChannelTotem, PolicyVersion: "v1"}, nil},
+		{"revoke without policy version", ConsentRecord{ClienteID: id, Purpose: PurposeSMS, Channel: ChannelApp}, nil},
+		{"grant without policy version", ConsentRecord{ClienteID: id, Purpose: PurposeSMS, Granted: true, Channel: ChannelApp}, []error{entityErr.ErrPolicyVersionRequired}},
+		{"unknown purpose and channel", ConsentRecord{ClienteID: id, Purpose: "telepathy", Channel: "fax"}, []error{entityErr.ErrInvalidConsentPurpose, entityErr.ErrInvalidConsentChannel}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.record.Validate()
+			if len(tt.want) == 0 && err != nil {
+				t.Errorf("should not have return error, got: %s", err)
+			}
+			for _, want := range tt.want {
+				if !errors.Is(err, want) {
+					t.Errorf("want: %s, got: %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCurrentConsents(t *testing.T) {
+	id := NewID()
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	history := []ConsentRecord{
+		{ClienteID: id, Purpose: PurposeMarketingEmail, Granted: true, Channel: ChannelTotem, PolicyVersion: "v1", RecordedAt: t0},
+		{ClienteID: id, Purpose: PurposeSMS, Granted: true, Channel: ChannelApp, PolicyVersion: "v1", RecordedAt: t0.Add(time.Hour)},
+		{ClienteID: id, Purpose: PurposeMarketingEmail, Granted: false, Channel: ChannelApp, RecordedAt: t0.Add(2 * time.Hour)},
+		{ClienteID: id, Purpose: PurposeSMS, Granted: false, Channel: ChannelWeb, RecordedAt: t0.Add(3 * time.Hour)},
+		{ClienteID: id, Purpose: PurposeSMS, Granted: true, Channel: ChannelWeb, PolicyVersion: "v2", RecordedAt: t0.Add(4 * time.Hour)},
+	}
+
+	consents := CurrentConsents(history)
+	if len(consents) != 2 {
+		t.Fatalf("should have return 2 purposes, got: %d", len(consents))
+	}
+
+	email := consents[0]
+	if email.Purpose != PurposeMarketingEmail || email.Granted || !email.GrantedAt.Equal(t0) || !email.RevokedAt.Equal(t0.Add(2*time.Hour)) {
+		t.Errorf("should have marketing e-mail revoked after grant, got: %+v", email)
+	}
+
+	sms := consents[1]
+	if sms.Purpose != PurposeSMS || !sms.Granted || !sms.GrantedAt.Equal(t0.Add(4*time.Hour)) || !sms.RevokedAt.IsZero() {
+		t.Errorf("should have sms granted again, got: %+v", sms)
+	}
+	if sms.PolicyVersion != "v2" || sms.Channel != ChannelWeb {
+		t.Errorf("should have the latest policy version and channel, got: %+v", sms)
+	}
+}
@@ -0,0 +1,103 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	ClienteCreated EventType = "ClienteCreated"
+	ClienteUpdated EventType = "ClienteUpdated"
+	ClienteRemoved EventType = "ClienteRemoved"
+)
+
+// EventSink is where a relay publishes the events of the outbox to. The outbox
+// tracks every sink apart, so one failing holds back no other.
+type EventSink string
+
+const (
+	SinkBroker   EventSink = "broker"
+	SinkWebhooks EventSink = "webhooks"
+)
+
+// EventSinks are the sinks every event is published to.
+func EventSinks() []EventSink {
+	return []EventSink{SinkBroker, SinkWebhooks}
+}
+
+// Event is a domain event for other services, kept in the outbox with the
+// mutation that raised it until published. OccurredAt is set by the
+// repository.
+type Event struct {
+	ID         ID
+	Type       EventType
+	ClienteID  ID
+	Payload    []byte
+	OccurredAt time.Time
+}
+
+// ClienteEventData is the JSON payload of the cliente events. A purged
+// cliente only carries its id.
+type ClienteEventData struct {
+	ID         ID     `json:"id"`
+	Name       string `json:"name,omitempty"`
+	CPF        string `json:"cpf,omitempty"`
+	Email      string `json:"email,omitempty"`
+	Active     bool   `json:"active"`
+	Version    int    `json:"version,omitempty"`
+	Anonymized bool   `json:"anonymized,omitempty"`
+	Purged     bool   `json:"purged,omitempty"`
+}
+
+// NewClienteEvent raises the event for a mutation, after is nil when the
+// cliente was purged and before is only read then. Deactivating a cliente is
+// a removal for other services.
+func NewClienteEvent(action AuditAction, before, after *Cliente) (Event, error) {
+	var (
+		typ  EventType
+		data ClienteEventData
+	)
+	switch action {
+	case AuditCreated:
+		typ = ClienteCreated
+	case AuditDeactivated, AuditDeleted:
+		typ = ClienteRemoved
+	default:
+		typ = ClienteUpdated
+	}
+
+	if after != nil {
+		data = ClienteEventData{
+			ID:         after.Id(),
+			Name:       after.Name(),
+			CPF:        after.CPF(),
+			Email:      after.Email(),
+			Active:     after.Active(),
+			Version:    after.Version(),
+			Anonymized: after.Anonymized(),
+		}
+	} else {
+		data = ClienteEventData{ID: before.Id(), Purged: true}
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{ID: NewID(), Type: typ, ClienteID: data.ID, Payload: payload}, nil
+}
+
+// RedactEventPayload returns the payload of a cliente event without its
+// personal data, for the events of an anonymized cliente.
+func RedactEventPayload(payload []byte) ([]byte, error) {
+	var data ClienteEventData
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, err
+	}
+	data.Name, data.CPF, data.Email = "", "", ""
+
+	return json.Marshal(data)
+}
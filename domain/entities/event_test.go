@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewClienteEvent(t *testing.T) {
+	c, _ := New(NewID(), "Fulano", "52998224725", "fulano@email.com", true, WithVersion(3))
+
+	tests := []struct {
+		action AuditAction
+		before *Cliente
+		after  *Cliente
+		want   EventType
+	}{
+		{AuditCreated, nil, c, ClienteCreated},
+		{AuditUpdated, c, c, ClienteUpdated},
+		{AuditReactivated, c, c, ClienteUpdated},
+		{AuditAnonymized, c, c, ClienteUpdated},
+		{AuditDeactivated, c, c, ClienteRemoved},
+		{AuditDeleted, c, nil, ClienteRemoved},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.action), func(t *testing.T) {
+			event, err := NewClienteEvent(tt.action, tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("should not have return error, got: %s", err)
+			}
+			if event.Type != tt.want || event.ClienteID != c.Id() {
+				t.Errorf("want %s of cliente %s, got: %s of %s", tt.want, c.Id(), event.Type, event.ClienteID)
+			}
+
+			var data ClienteEventData
+			if err := json.Unmarshal(event.Payload, &data); err != nil {
+				t.Fatalf("decoding payload, got error: %s", err)
+			}
+			if purged := tt.after == nil; data.Purged != purged || (!purged && data.Version != 3) {
+				t.Errorf("unexpected payload: %+v", data)
+			}
+		})
+	}
+}
+
+func TestRedactEventPayload(t *testing.T) {
+	c, _ := New(NewID(), "Fulano", "52998224725", "fulano@email.com", true, WithVersion(3))
+	event, _ := NewClienteEvent(AuditUpdated, c, c)
+
+	payload, err := RedactEventPayload(event.Payload)
+	if err != nil {
+		t.Fatalf("should not have return error, got: %s", err)
+	}
+
+	var data ClienteEventData
+	if err := json.Unmarshal(payload, &data); err != nil {
+		t.Fatalf("decoding payload, got error: %s", err)
+	}
+	if data.Name != "" || data.CPF != "" || data.Email != "" {
+		t.Errorf("should not keep personal data, got: %+v", data)
+	}
+	if data.ID != c.Id() || data.Version != 3 || !data.Active {
+		t.Errorf("should keep the rest of the event, got: %+v", data)
+	}
+}
@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"strconv"
+	"time"
+)
+
+// ClienteExport is everything held about a cliente, handed to them on a
+// data-subject access request.
+type ClienteExport struct {
+	ClienteID   ID
+	GeneratedAt time.Time
+	Sections    []ExportSection
+}
+
+// ExportSection is one kind of data held about a cliente. It is kept as a
+// table so it can be rendered both as JSON and as CSV.
+type ExportSection struct {
+	Name    string
+	Columns []string
+	Rows    [][]string
+}
+
+// ClienteSection exports the cliente record itself.
+func ClienteSection(c *Cliente) ExportSection {
+	return ExportSection{
+		Name: "cliente",
+		Columns: []string{
+			"id", "name", "cpf", "email", "active", "created_at", "updated_at",
+			"deleted_at", "anonymized_at", "anonymization_reason", "version",
+			"email_verified_at",
+		},
+		Rows: [][]string{{
+			c.Id().String(),
+			c.Name(),
+			c.CPF(),
+			c.Email(),
+			strconv.FormatBool(c.Active()),
+			exportTime(c.CreatedAt()),
+			exportTime(c.UpdatedAt()),
+			exportTime(c.DeletedAt()),
+			exportTime(c.AnonymizedAt()),
+			c.AnonymizationReason(),
+			strconv.Itoa(c.Version()),
+			exportTime(c.EmailVerifiedAt()),
+		}},
+	}
+}
+
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"time"
+
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+const maxIdempotencyKeyLen = 255
+
+// IdempotencyRecord is the first request made with an idempotency key and,
+// once it completed, its response, replayed to the retries of the request
+// until ExpiresAt.
+type IdempotencyRecord struct {
+	// Subject is the principal that made the request, each has keys of its
+	// own.
+	Subject string
+	Key     string
+	// RequestHash tells the retries of the request from other requests
+	// reusing its key.
+	RequestHash string
+	StatusCode  int
+	Header      map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	// LockedUntil is when a request still in progress is assumed lost, its
+	// replica gone, and the key may be reserved again.
+	LockedUntil time.Time
+}
+
+// Completed tells whether the response of the request is stored, it is not
+// while the request is in progress.
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+func ValidateIdempotencyKey(key string) error {
+	if key == "" || len(key) > maxIdempotencyKeyLen {
+		return entityErr.ErrInvalidIdempotencyKey
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return entityErr.ErrInvalidIdempotencyKey
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"fmt"
+
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 100
+)
+
+type SortField string
+
+const (
+	SortByName    SortField = "name"
+	SortByCPF     SortField = "cpf"
+	SortByCreated SortField = "created"
+)
+
+// Cursor points right after the last cliente of a page. Key is the value of
+// the sort field for that cliente and ID breaks ties between equal keys.
+type Cursor struct {
+	Sort SortField
+	Key  string
+	ID   ID
+}
+
+// ListOptions selects a page of clientes. Inactive clientes are left out
+// unless IncludeInactive is set or Active asks for them explicitly.
+type ListOptions struct {
+	Limit           int
+	After           *Cursor
+	Sort            SortField
+	Active          *bool
+	IncludeInactive bool
+	NamePrefix      string
+}
+
+// Normalize fills the defaults and checks the options are consistent, a
+// cursor is only valid for the sort it was issued for.
+func (o ListOptions) Normalize() (ListOptions, error) {
+	if o.Sort == "" {
+		o.Sort = SortByCPF
+	}
+
+	if o.Active == nil && !o.IncludeInactive {
+		active := true
+		o.Active = &active
+	}
+
+	switch o.Sort {
+	case SortByName, SortByCPF, SortByCreated:
+	default:
+		return o, fmt.Errorf("%w: unknown sort %q", entityErr.ErrInvalidListOptions, o.Sort)
+	}
+
+	switch {
+	case o.Limit < 0:
+		return o, fmt.Errorf("%w: negative limit", entityErr.ErrInvalidListOptions)
+	case o.Limit == 0:
+		o.Limit = DefaultListLimit
+	case o.Limit > MaxListLimit:
+		o.Limit = MaxListLimit
+	}
+
+	if o.After != nil && o.After.Sort != o.Sort {
+		return o, fmt.Errorf("%w: cursor does not match sort %q", entityErr.ErrInvalidListOptions, o.Sort)
+	}
+
+	return o, nil
+}
+
+type ClientePage struct {
+	Clientes []*Cliente
+	Next     *Cursor
+}
@@ -0,0 +1,100 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+// Operation is what a principal may be allowed to do with the clientes, their
+// consents and the webhooks.
+type Operation string
+
+const (
+	OpCreateCliente      Operation = "clientes:create"
+	OpListClientes       Operation = "clientes:list"
+	OpReadCliente        Operation = "clientes:read"
+	OpFindClienteByCPF   Operation = "clientes:find_by_cpf"
+	OpFindClienteByEmail Operation = "clientes:find_by_email"
+	OpUpdateCliente      Operation = "clientes:update"
+	OpRemoveCliente      Operation = "clientes:remove"
+	OpReactivateCliente  Operation = "clientes:reactivate"
+	OpPurgeCliente       Operation = "clientes:purge"
+	OpAnonymizeCliente   Operation = "clientes:anonymize"
+	OpExportCliente      Operation = "clientes:export"
+	OpClienteHistory     Operation = "clientes:history"
+	OpIdentifyCliente    Operation = "clientes:identify"
+	OpVerifyEmail        Operation = "clientes:verify_email"
+	// OpSelfService lets a cliente see, edit and remove its own record.
+	OpSelfService Operation = "clientes:self"
+
+	OpRecordConsent  Operation = "consents:record"
+	OpListConsents   Operation = "consents:list"
+	OpManageWebhooks Operation = "webhooks:manage"
+
+	// AnyOperation grants every operation.
+	AnyOperation Operation = "*"
+)
+
+var operations = []Operation{
+	OpCreateCliente, OpListClientes, OpReadCliente, OpFindClienteByCPF, OpFindClienteByEmail,
+	OpUpdateCliente, OpRemoveCliente, OpReactivateCliente, OpPurgeCliente, OpAnonymizeCliente,
+	OpExportCliente, OpClienteHistory, OpIdentifyCliente, OpVerifyEmail, OpSelfService,
+	OpRecordConsent, OpListConsents, OpManageWebhooks, AnyOperation,
+}
+
+// Policy grants operations to the principals having a role or a scope, the
+// keys of the policy.
+type Policy map[string][]Operation
+
+// DefaultPolicy lets the totem sign clientes up, identify them and verify
+// their e-mails, the back office look after them, their consents and the
+// webhooks, the clientes manage their own record and only admins delete them.
+// Clientes are not granted OpVerifyEmail: it takes any cliente id, so it
+// would let them send codes to the e-mails of the others.
+func DefaultPolicy() Policy {
+	return Policy{
+		"cliente": {OpSelfService},
+		"totem":   {OpCreateCliente, OpIdentifyCliente, OpVerifyEmail},
+		"backoffice": {
+			OpListClientes, OpReadCliente, OpFindClienteByCPF, OpFindClienteByEmail,
+			OpUpdateCliente, OpClienteHistory, OpVerifyEmail,
+			OpRecordConsent, OpListConsents, OpManageWebhooks,
+		},
+		"admin": {AnyOperation},
+	}
+}
+
+// ParsePolicy reads a policy from a JSON object of roles or scopes to the
+// operations they grant.
+func ParsePolicy(data []byte) (Policy, error) {
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("decoding policy: %w", err)
+	}
+
+	for grant, ops := range p {
+		for _, op := range ops {
+			if !slices.Contains(operations, op) {
+				return nil, fmt.Errorf("%w %q granted to %q", entityErr.ErrUnknownOperation, op, grant)
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// Allows tells whether any of the roles or scopes of the principal grants the
+// operation.
+func (p Policy) Allows(principal Principal, op Operation) bool {
+	for _, grant := range slices.Concat(principal.Roles, principal.Scopes) {
+		ops := p[grant]
+		if slices.Contains(ops, op) || slices.Contains(ops, AnyOperation) {
+			return true
+		}
+	}
+
+	return false
+}
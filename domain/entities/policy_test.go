@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy([]byte(`{"totem":["clientes:create"],"clientes.admin":["*"]}`))
+	if err != nil {
+		t.Fatalf("should not have return error, got: %s", err)
+	}
+	if len(p["totem"]) != 1 || p["clientes.admin"][0] != AnyOperation {
+		t.Errorf("unexpected policy: %v", p)
+	}
+
+	if _, err := ParsePolicy([]byte(`{"totem":["clientes:teleport"]}`)); !errors.Is(err, entityErr.ErrUnknownOperation) {
+		t.Errorf("want: %s, got: %v", entityErr.ErrUnknownOperation, err)
+	}
+	if _, err := ParsePolicy([]byte(`["clientes:create"]`)); err == nil {
+		t.Error("should have return error")
+	}
+}
+
+func TestPolicyAllows(t *testing.T) {
+	policy := DefaultPolicy()
+
+	tests := []struct {
+		name      string
+		principal Principal
+		op        Operation
+		want      bool
+	}{
+		{"totem creates", Principal{Roles: []string{"totem"}}, OpCreateCliente, true},
+		{"totem identifies", Principal{Roles: []string{"totem"}}, OpIdentifyCliente, true},
+		{"totem does not find by cpf", Principal{Roles: []string{"totem"}}, OpFindClienteByCPF, false},
+		{"totem does not list", Principal{Roles: []string{"totem"}}, OpListClientes, false},
+		{"backoffice updates", Principal{Roles: []string{"backoffice"}}, OpUpdateCliente, true},
+		{"backoffice does not delete", Principal{Roles: []string{"backoffice"}}, OpRemoveCliente, false},
+		{"backoffice manages webhooks", Principal{Roles: []string{"backoffice"}}, OpManageWebhooks, true},
+		{"cliente does not manage webhooks", Principal{Roles: []string{"cliente"}}, OpManageWebhooks, false},
+		{"cliente does not record consents", Principal{Roles: []string{"cliente"}}, OpRecordConsent, false},
+		{"granted by scope", Principal{Scopes: []string{"openid", "backoffice"}}, OpListClientes, true},
+		{"any of the roles", Principal{Roles: []string{"totem", "backoffice"}}, OpClienteHistory, true},
+		{"admin purges", Principal{Roles: []string{"admin"}}, OpPurgeCliente, true},
+		{"no roles", Principal{Subject: "someone"}, OpReadCliente, false},
+		{"unknown role", Principal{Roles: []string{"intern"}}, OpReadCliente, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Allows(tt.principal, tt.op); got != tt.want {
+				t.Errorf("want: %t, got: %t", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package entities
+
+import "context"
+
+// Principal is who a request is made by, as told by its access token.
+type Principal struct {
+	Subject string
+	Roles   []string
+	Scopes  []string
+	// CPF is set when the principal is a cliente known by its cpf rather than
+	// by its id.
+	CPF string
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal of the context and false when the
+// request was not authenticated.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
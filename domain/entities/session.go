@@ -0,0 +1,89 @@
+package entities
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+// Identification is how clientes tell who they are at the totem, by cpf or
+// by e-mail.
+type Identification struct {
+	CPF   string
+	Email string
+}
+
+func (i Identification) Validate() error {
+	var verr entityErr.ValidationError
+
+	switch {
+	case i.CPF == "" && i.Email == "":
+		verr.Add("cpf", "required", entityErr.ErrIdentifierRequired)
+	case i.CPF != "" && i.Email != "":
+		verr.Add("email", "exclusive", entityErr.ErrAmbiguousIdentifier)
+	}
+
+	return verr.Err()
+}
+
+// Session lets the other services recognize a cliente identified at the totem
+// until it expires.
+type Session struct {
+	ClienteID ID
+	Token     string
+	ExpiresAt time.Time
+}
+
+// ClienteSummary shows enough of a cliente for them to recognize themselves,
+// without disclosing their personal data to whoever stands at the totem.
+type ClienteSummary struct {
+	ID    ID
+	Name  string
+	CPF   string
+	Email string
+}
+
+// Identified is the cliente identified at the totem and its session.
+type Identified struct {
+	Cliente ClienteSummary
+	Session Session
+}
+
+// Summarize keeps the first name of the cliente and masks its cpf and e-mail.
+// Legacy clientes may have no name, then the summary has none either.
+func Summarize(c *Cliente) ClienteSummary {
+	s := ClienteSummary{
+		ID:    c.Id(),
+		CPF:   MaskCPF(c.CPF()),
+		Email: MaskEmail(c.Email()),
+	}
+	if names := strings.Fields(c.Name()); len(names) > 0 {
+		s.Name = names[0]
+	}
+
+	return s
+}
+
+// MaskCPF shows only the middle six digits of a normalized cpf, as in
+// ***.456.789-**.
+func MaskCPF(cpf string) string {
+	if len(cpf) != 11 {
+		return "***.***.***-**"
+	}
+
+	return "***." + cpf[3:6] + "." + cpf[6:9] + "-**"
+}
+
+// MaskEmail shows only the first letter of the local part of an e-mail and its
+// domain, as in f***@email.com.
+func MaskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+
+	first, _ := utf8.DecodeRuneInString(local)
+	return string(first) + "***@" + domain
+}
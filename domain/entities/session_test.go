@@ -0,0 +1,77 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+func TestIdentificationValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		id   Identification
+		want error
+	}{
+		{"by cpf", Identification{CPF: "11144477735"}, nil},
+		{"by email", Identification{Email: "fulano@email.com"}, nil},
+		{"neither", Identification{}, entityErr.ErrIdentifierRequired},
+		{"both", Identification{CPF: "11144477735", Email: "fulano@email.com"}, entityErr.ErrAmbiguousIdentifier},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.id.Validate()
+			if tt.want == nil && err != nil {
+				t.Errorf("should not have return error, got: %s", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("want: %s, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	c, err := New(NewID(), "Fulano de Tal", "11144477735", "fulano@email.com", true)
+	if err != nil {
+		t.Fatalf("creating cliente, error: %s", err)
+	}
+
+	s := Summarize(c)
+	if s.ID != c.Id() || s.Name != "Fulano" {
+		t.Errorf("want id %s and first name Fulano, got: %s and %s", c.Id(), s.ID, s.Name)
+	}
+	if s.CPF != "***.444.777-**" {
+		t.Errorf("want masked cpf ***.444.777-**, got: %s", s.CPF)
+	}
+	if s.Email != "f***@email.com" {
+		t.Errorf("want masked email f***@email.com, got: %s", s.Email)
+	}
+
+	// legacy rows are restored without validation and may have no name
+	for _, name := range []string{"", "  "} {
+		s := Summarize(Restore(NewID(), name, "11144477735", "fulano@email.com", true))
+		if s.Name != "" || s.CPF != "***.444.777-**" {
+			t.Errorf("want no name and masked cpf, got: %+v", s)
+		}
+	}
+}
+
+func TestMaskEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"fulano@email.com", "f***@email.com"},
+		{"élida@email.com", "é***@email.com"},
+		{"@email.com", "***"},
+		{"fulano", "***"},
+	}
+
+	for _, tt := range tests {
+		if got := MaskEmail(tt.email); got != tt.want {
+			t.Errorf("MaskEmail(%q): want %s, got: %s", tt.email, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package entities
+
+import "time"
+
+// VerificationCode is the one-time code sent to prove the ownership of the
+// e-mail of a cliente. Only its hash is kept.
+type VerificationCode struct {
+	ClienteID ID
+	// Email is the address the code was sent to, it verifies no other.
+	Email     string
+	CodeHash  string
+	Attempts  int
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Notification is a message to a cliente.
+type Notification struct {
+	To      string
+	Subject string
+	Body    string
+}
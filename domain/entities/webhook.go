@@ -0,0 +1,210 @@
+package entities
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+// EventTypes are the events webhooks can subscribe to.
+var EventTypes = []EventType{ClienteCreated, ClienteUpdated, ClienteRemoved}
+
+// WebhookSubscription sends the events of the given types to an HTTPS
+// endpoint, every type when Events is empty. Secret signs the deliveries.
+type WebhookSubscription struct {
+	ID        ID
+	URL       string
+	Events    []EventType
+	Secret    string
+	CreatedAt time.Time
+}
+
+func (s WebhookSubscription) Validate() error {
+	var verr entityErr.ValidationError
+
+	u, err := url.Parse(s.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		verr.Add("url", "https_url", entityErr.ErrInvalidWebhookURL)
+	}
+
+	for _, t := range s.Events {
+		if !slices.Contains(EventTypes, t) {
+			verr.Add("events", "event_type", entityErr.ErrInvalidEventType)
+			break
+		}
+	}
+
+	return verr.Err()
+}
+
+func (s WebhookSubscription) Matches(t EventType) bool {
+	return len(s.Events) == 0 || slices.Contains(s.Events, t)
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead is a delivery that failed every attempt, the dead letters
+	// of a subscription.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+const (
+	// WebhookMaxAttempts is how many times a delivery is tried before it
+	// becomes a dead letter.
+	WebhookMaxAttempts = 8
+	webhookBaseDelay   = 30 * time.Second
+)
+
+// WebhookDelivery is an event sent, or to be sent, to a subscription. It is
+// the delivery log of the subscription.
+type WebhookDelivery struct {
+	ID             ID
+	SubscriptionID ID
+	EventID        ID
+	EventType      EventType
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    time.Time
+}
+
+// webhookBody is what is posted to the endpoints.
+type webhookBody struct {
+	ID         ID              `json:"id"`
+	Type       EventType       `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// NewWebhookDelivery queues the event for the subscription, to be sent at
+// once.
+func NewWebhookDelivery(sub WebhookSubscription, event Event, now time.Time) (WebhookDelivery, error) {
+	payload, err := json.Marshal(webhookBody{
+		ID:         event.ID,
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt,
+		Data:       event.Payload,
+	})
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+
+	return WebhookDelivery{
+		ID:             NewID(),
+		SubscriptionID: sub.ID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        payload,
+		Status:         DeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}, nil
+}
+
+// Delivered records a successful attempt.
+func (d *WebhookDelivery) Delivered(now time.Time, statusCode int) {
+	d.Attempts++
+	d.Status = DeliveryDelivered
+	d.LastStatusCode = statusCode
+	d.LastError = ""
+	d.DeliveredAt = now
+	d.NextAttemptAt = time.Time{}
+}
+
+// Failed records a failed attempt, statusCode is 0 when there was no
+// response. The next attempt waits twice as long as the previous one and the
+// delivery is dead after WebhookMaxAttempts.
+func (d *WebhookDelivery) Failed(now time.Time, statusCode int, reason string) {
+	d.Attempts++
+	d.LastStatusCode = statusCode
+	d.LastError = reason
+
+	if d.Attempts >= WebhookMaxAttempts {
+		d.Status = DeliveryDead
+		d.NextAttemptAt = time.Time{}
+		return
+	}
+
+	d.NextAttemptAt = now.Add(webhookBaseDelay << (d.Attempts - 1))
+}
+
+// Redeliver queues the delivery again with its attempts reset, usually to
+// replay a dead letter.
+func (d *WebhookDelivery) Redeliver(now time.Time) {
+	d.Status = DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+}
+
+// RedactDeliveryPayload returns the payload of a delivery without the
+// personal data of the event it carries, see RedactEventPayload, when the
+// event is one of the given cliente. ok is false for the events of others.
+func RedactDeliveryPayload(payload []byte, clienteID ID) (redacted []byte, ok bool, err error) {
+	var body webhookBody
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, false, err
+	}
+
+	var data ClienteEventData
+	if err := json.Unmarshal(body.Data, &data); err != nil {
+		return nil, false, err
+	}
+	if data.ID != clienteID {
+		return payload, false, nil
+	}
+
+	if body.Data, err = RedactEventPayload(body.Data); err != nil {
+		return nil, false, err
+	}
+	if redacted, err = json.Marshal(body); err != nil {
+		return nil, false, err
+	}
+
+	return redacted, true, nil
+}
+
+// Webhook request headers.
+const (
+	WebhookSignatureHeader = "X-Pedeai-Signature"
+	WebhookTimestampHeader = "X-Pedeai-Timestamp"
+	WebhookEventHeader     = "X-Pedeai-Event"
+	WebhookDeliveryHeader  = "X-Pedeai-Delivery"
+)
+
+// WebhookHeaders are the headers of an attempt made at now. The signature is
+// "sha256=" and the hex HMAC-SHA256, keyed by the secret, of the timestamp, a
+// dot and the body.
+func WebhookHeaders(secret string, d WebhookDelivery, now time.Time) map[string]string {
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	return map[string]string{
+		"Content-Type":         "application/json",
+		WebhookSignatureHeader: SignWebhook(secret, ts, d.Payload),
+		WebhookTimestampHeader: ts,
+		WebhookEventHeader:     string(d.EventType),
+		WebhookDeliveryHeader:  d.ID.String(),
+	}
+}
+
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
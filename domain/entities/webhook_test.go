@@ -0,0 +1,89 @@
+package entities
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookSubscriptionValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		sub   WebhookSubscription
+		valid bool
+	}{
+		{"every event", WebhookSubscription{URL: "https://example.com/hooks"}, true},
+		{"some events", WebhookSubscription{URL: "https://example.com/hooks", Events: []EventType{ClienteCreated, ClienteRemoved}}, true},
+		{"plain http", WebhookSubscription{URL: "http://example.com/hooks"}, false},
+		{"without host", WebhookSubscription{URL: "https:///hooks"}, false},
+		{"unknown event", WebhookSubscription{URL: "https://example.com/hooks", Events: []EventType{"PedidoCreated"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.sub.Validate(); (err == nil) != tt.valid {
+				t.Errorf("want valid %t, got: %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestWebhookDeliveryFailed(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := WebhookDelivery{Status: DeliveryPending}
+
+	for attempt := 1; attempt < WebhookMaxAttempts; attempt++ {
+		d.Failed(now, 503, "unexpected status 503")
+
+		want := now.Add(webhookBaseDelay << (attempt - 1))
+		if d.Status != DeliveryPending || !d.NextAttemptAt.Equal(want) {
+			t.Fatalf("attempt %d should be retried at %s, got: %s %s", attempt, want, d.Status, d.NextAttemptAt)
+		}
+	}
+
+	d.Failed(now, 503, "unexpected status 503")
+	if d.Status != DeliveryDead || !d.NextAttemptAt.IsZero() {
+		t.Errorf("should be dead after %d attempts, got: %+v", WebhookMaxAttempts, d)
+	}
+
+	d.Redeliver(now)
+	if d.Status != DeliveryPending || d.Attempts != 0 || !d.NextAttemptAt.Equal(now) {
+		t.Errorf("should have queued the delivery again, got: %+v", d)
+	}
+}
+
+func TestRedactDeliveryPayload(t *testing.T) {
+	c, _ := New(NewID(), "Fulano", "52998224725", "fulano@email.com", true)
+	event, _ := NewClienteEvent(AuditCreated, nil, c)
+	d, _ := NewWebhookDelivery(WebhookSubscription{ID: NewID()}, event, time.Now())
+
+	if _, ok, err := RedactDeliveryPayload(d.Payload, NewID()); ok || err != nil {
+		t.Errorf("should leave the events of other clientes, got: %t %v", ok, err)
+	}
+
+	payload, ok, err := RedactDeliveryPayload(d.Payload, c.Id())
+	if !ok || err != nil {
+		t.Fatalf("should have redacted the event, got: %t %v", ok, err)
+	}
+	if strings.Contains(string(payload), "Fulano") || strings.Contains(string(payload), "52998224725") {
+		t.Errorf("should not keep personal data, got: %s", payload)
+	}
+	if !strings.Contains(string(payload), event.ID.String()) || !strings.Contains(string(payload), c.Id().String()) {
+		t.Errorf("should keep the rest of the delivery, got: %s", payload)
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := SignWebhook("whsec_test", "1700000000", body); got != want {
+		t.Errorf("want %s, got: %s", want, got)
+	}
+}
@@ -8,7 +8,31 @@ var (
 	ErrNameTooShort                 = errors.New("name must be at least 3 characters")
 	ErrInvalidEmail                 = errors.New("invalid e-mail format")
 	ErrInvalidCPF                   = errors.New("invalid cpf format")
+	ErrActiveRequired               = errors.New("active must be provided")
 	ErrClienteAlreadyExistsForID    = errors.New("cliente with the provided id already exists")
 	ErrClienteAlreadyExistsForCPF   = errors.New("cliente with the provided cpf already exists")
 	ErrClienteAlreadyExistsForEmail = errors.New("cliente with the provided email already exists")
+	ErrInvalidListOptions           = errors.New("invalid list options")
+	ErrConcurrentModification       = errors.New("cliente was modified concurrently")
+	ErrClienteAnonymized            = errors.New("cliente is anonymized")
+	ErrAnonymizationReasonRequired  = errors.New("anonymization reason must not be empty")
+	ErrInvalidConsentPurpose        = errors.New("invalid consent purpose")
+	ErrInvalidConsentChannel        = errors.New("invalid consent channel")
+	ErrPolicyVersionRequired        = errors.New("policy version must be provided when granting consent")
+	ErrGrantedRequired              = errors.New("granted must be provided")
+	ErrInvalidWebhookURL            = errors.New("webhook url must be an absolute https url")
+	ErrInvalidEventType             = errors.New("invalid event type")
+	ErrInvalidIdempotencyKey        = errors.New("idempotency key must have 1 to 255 printable ascii characters")
+	ErrIdempotencyKeyReused         = errors.New("idempotency key was already used for another request")
+	ErrIdempotencyKeyInProgress     = errors.New("a request with the same idempotency key is in progress")
+	ErrForbidden                    = errors.New("operation not allowed")
+	ErrUnknownOperation             = errors.New("unknown operation")
+	ErrNotEditableByOwner           = errors.New("cannot be changed by the cliente")
+	ErrIdentifierRequired           = errors.New("cpf or email must be provided")
+	ErrAmbiguousIdentifier          = errors.New("only one of cpf or email must be provided")
+	ErrEmailAlreadyVerified         = errors.New("e-mail is already verified")
+	ErrVerificationRequestedTooSoon = errors.New("a verification code was sent moments ago")
+	ErrInvalidVerificationCode      = errors.New("invalid verification code")
+	ErrVerificationCodeExpired      = errors.New("verification code expired or was never sent")
+	ErrTooManyVerificationAttempts  = errors.New("too many attempts with the verification code")
 )
@@ -0,0 +1,61 @@
+package errors
+
+import "strings"
+
+// FieldError is a single rule violated by a field. Err holds the sentinel of
+// the violation so callers can keep matching with errors.Is.
+type FieldError struct {
+	Field   string
+	Rule    string
+	Message string
+	Err     error
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError gathers every violation found while validating an entity.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Add(field, rule string, err error) {
+	e.Fields = append(e.Fields, FieldError{
+		Field:   field,
+		Rule:    rule,
+		Message: err.Error(),
+		Err:     err,
+	})
+}
+
+// Err returns nil when no violation was added, so it can be returned as is.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Fields))
+	for i, f := range e.Fields {
+		errs[i] = f
+	}
+
+	return errs
+}
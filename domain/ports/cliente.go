@@ -1,15 +1,56 @@
 package ports
 
 import (
+	"context"
+	"time"
+
 	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
 )
 
+// Repository keeps the clientes. Every mutation appends an AuditEntry to the
+// history of the cliente in the same transaction, with the AuditMeta of the
+// context. The events of the mutations are raised by the services, which
+// write them with WriteEvent within the transaction of the mutation.
 type Repository interface {
-	Create(cliente entities.Cliente) error
-	List() ([]*entities.Cliente, error)
-	GetClienteById(id entities.ID) (*entities.Cliente, error)
-	GetClienteByCPF(cpf string) (*entities.Cliente, error)
-	GetClienteByEmail(email string) (*entities.Cliente, error)
-	Update(cliente entities.Cliente) error
-	Remove(id entities.ID) error
+	Create(ctx context.Context, cliente entities.Cliente) error
+	List(ctx context.Context, opts entities.ListOptions) (entities.ClientePage, error)
+	GetClienteById(ctx context.Context, id entities.ID) (*entities.Cliente, error)
+	GetClienteByCPF(ctx context.Context, cpf string) (*entities.Cliente, error)
+	GetClienteByEmail(ctx context.Context, email string) (*entities.Cliente, error)
+	// Update replaces the cliente only if its version is still the stored one,
+	// failing with ErrConcurrentModification otherwise, and returns it as
+	// stored. Activating a removed cliente clears its removal and changing its
+	// e-mail clears its verification. Anonymized clientes fail with
+	// ErrClienteAnonymized.
+	Update(ctx context.Context, cliente entities.Cliente) (*entities.Cliente, error)
+	// Anonymize stores a cliente returned by Cliente.Anonymize, with the same
+	// version check as Update, and redacts the personal data from its history
+	// and from the events it raised, published or not, and their webhook
+	// deliveries, dropping its pending e-mail verification code.
+	Anonymize(ctx context.Context, cliente entities.Cliente) (*entities.Cliente, error)
+	// VerifyEmail marks the e-mail of the cliente as verified at the given
+	// time, failing with ErrConcurrentModification when it is no longer the
+	// cliente's e-mail.
+	VerifyEmail(ctx context.Context, id entities.ID, email string, at time.Time) (*entities.Cliente, error)
+	// Deactivate marks the cliente inactive and records when it was removed,
+	// keeping the row for the services that still reference it.
+	Deactivate(ctx context.Context, id entities.ID) (*entities.Cliente, error)
+	// Reactivate undoes Deactivate, anonymized clientes stay inactive.
+	Reactivate(ctx context.Context, id entities.ID) (*entities.Cliente, error)
+	// Remove deletes the cliente for good, its history is kept.
+	Remove(ctx context.Context, id entities.ID) error
+	// History returns the audit entries of the cliente, oldest first.
+	History(ctx context.Context, id entities.ID) ([]entities.AuditEntry, error)
+	// WriteEvent adds the event to the Outbox, pending for every sink, with
+	// the time it occurred. It is only kept if the transaction it was written
+	// in commits.
+	WriteEvent(ctx context.Context, event entities.Event) error
+
+	Transactor
+}
+
+// ExportSource adds a section to the data export of a cliente, see
+// services.WithExportSources.
+type ExportSource interface {
+	ExportSection(ctx context.Context, id entities.ID) (entities.ExportSection, error)
 }
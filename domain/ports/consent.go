@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// ConsentRepository keeps the consent history of the clientes, records are
+// never changed once appended.
+type ConsentRepository interface {
+	// AppendConsent stores the record, failing with ErrNotFound if the cliente
+	// does not exist, and returns it with RecordedAt set.
+	AppendConsent(ctx context.Context, record entities.ConsentRecord) (entities.ConsentRecord, error)
+	// ConsentHistory returns the records of the cliente, oldest first.
+	ConsentHistory(ctx context.Context, clienteID entities.ID) ([]entities.ConsentRecord, error)
+}
@@ -0,0 +1,30 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// EventPublisher delivers domain events to other services. The same event may
+// be published more than once, consumers tell them apart by ID.
+type EventPublisher interface {
+	Publish(ctx context.Context, event entities.Event) error
+}
+
+// Outbox keeps the events raised by the Repository mutations, written in the
+// same transaction, until they are published to each of the
+// entities.EventSinks.
+type Outbox interface {
+	// PublishPending claims up to limit events not yet published to sink,
+	// oldest first, and calls publish with each of them in order until it
+	// fails, returning how many were published and that failure. The events
+	// published are marked so for sink only, before the claim is released.
+	// Events claimed for the same sink by another caller are skipped, so
+	// several relays never publish the same event at once.
+	PublishPending(ctx context.Context, sink entities.EventSink, limit int, publish func(ctx context.Context, event entities.Event) error) (int, error)
+	// DeletePublished deletes the events that occurred before the given time
+	// and were published to every sink, and returns how many.
+	DeletePublished(ctx context.Context, before time.Time) (int, error)
+}
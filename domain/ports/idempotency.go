@@ -0,0 +1,26 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// IdempotencyStore keeps the requests made with an idempotency key and their
+// responses. A key is identified by its subject and itself, so subjects
+// picking the same key do not collide. Expired records are treated as absent.
+type IdempotencyStore interface {
+	// ReserveKey stores the record, without response, unless its key is
+	// already stored, not expired and, without response, still locked at the
+	// record's CreatedAt. It returns the stored record and whether it is the
+	// one given.
+	ReserveKey(ctx context.Context, rec entities.IdempotencyRecord) (*entities.IdempotencyRecord, bool, error)
+	// CompleteKey stores the response of the record reserved for the key.
+	CompleteKey(ctx context.Context, rec entities.IdempotencyRecord) error
+	// ReleaseKey deletes the record of the key of the subject, so the request
+	// can be made again.
+	ReleaseKey(ctx context.Context, subject, key string) error
+	// DeleteExpiredKeys deletes the records expired at now.
+	DeleteExpiredKeys(ctx context.Context, now time.Time) error
+}
@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// SessionIssuer signs the sessions of the clientes identified at the totem.
+type SessionIssuer interface {
+	IssueSession(ctx context.Context, clienteID entities.ID) (entities.Session, error)
+}
@@ -0,0 +1,53 @@
+package ports
+
+import (
+	"context"
+)
+
+// IsolationLevel of a transaction, the empty one is the default of the
+// database.
+type IsolationLevel string
+
+const (
+	ReadCommitted  IsolationLevel = "read committed"
+	RepeatableRead IsolationLevel = "repeatable read"
+	Serializable   IsolationLevel = "serializable"
+)
+
+type TxOptions struct {
+	Isolation IsolationLevel
+	ReadOnly  bool
+}
+
+type TxOption func(*TxOptions)
+
+func WithIsolation(level IsolationLevel) TxOption {
+	return func(o *TxOptions) {
+		o.Isolation = level
+	}
+}
+
+// ReadOnly refuses writes in the transaction.
+func ReadOnly() TxOption {
+	return func(o *TxOptions) {
+		o.ReadOnly = true
+	}
+}
+
+func NewTxOptions(opts ...TxOption) TxOptions {
+	var o TxOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// Transactor is the unit of work of a Repository. WithinTx runs fn with a
+// repository whose operations all happen in one transaction, committed when
+// fn returns nil and rolled back otherwise. fn must only use the repository
+// it is given. A WithinTx inside another joins the outer transaction, keeping
+// its options, and only rolls back its own changes.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(repo Repository) error, opts ...TxOption) error
+}
@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// VerificationStore keeps the last verification code sent to each cliente.
+// Codes go away with their cliente.
+type VerificationStore interface {
+	// SaveVerificationCode replaces the code of the cliente.
+	SaveVerificationCode(ctx context.Context, code entities.VerificationCode) error
+	// GetVerificationCode fails with ErrNotFound when the cliente has no code.
+	GetVerificationCode(ctx context.Context, clienteID entities.ID) (*entities.VerificationCode, error)
+	// AddVerificationAttempt counts an attempt at the code of the cliente and
+	// returns the attempts made so far, this one included.
+	AddVerificationAttempt(ctx context.Context, clienteID entities.ID) (int, error)
+	DeleteVerificationCode(ctx context.Context, clienteID entities.ID) error
+}
+
+// Notifier sends messages to the clientes.
+type Notifier interface {
+	Notify(ctx context.Context, n entities.Notification) error
+}
@@ -0,0 +1,43 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+// WebhookRepository keeps the webhook subscriptions and their deliveries.
+// Deleting a subscription deletes its deliveries.
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub entities.WebhookSubscription) (entities.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]entities.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id entities.ID) (*entities.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id entities.ID) error
+
+	// EnqueueDelivery stores a new delivery, ignoring it when the
+	// subscription already has one for the same event.
+	EnqueueDelivery(ctx context.Context, d entities.WebhookDelivery) error
+	// ClaimDueDeliveries claims up to limit pending deliveries whose next
+	// attempt is not after now, the most overdue ones, and returns them. Their
+	// next attempt is moved to until, so no one else claims them in the
+	// meantime; they are claimed again then if the outcome was not stored.
+	ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]entities.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id entities.ID) (*entities.WebhookDelivery, error)
+	// UpdateDelivery stores the outcome of an attempt, or a redelivery, only
+	// if the stored delivery still has the given attempts, failing with
+	// ErrConcurrentModification otherwise.
+	UpdateDelivery(ctx context.Context, d entities.WebhookDelivery, attempts int) error
+	// ListDeliveries returns the deliveries of the subscription, newest first,
+	// only those with the given status unless it is empty.
+	ListDeliveries(ctx context.Context, subscriptionID entities.ID, status entities.DeliveryStatus) ([]entities.WebhookDelivery, error)
+	// DeleteFinishedDeliveries deletes the delivered and dead deliveries
+	// created before the given time and returns how many were deleted.
+	DeleteFinishedDeliveries(ctx context.Context, before time.Time) (int, error)
+}
+
+// WebhookSender posts a delivery to an endpoint and returns the status code
+// of the response.
+type WebhookSender interface {
+	Send(ctx context.Context, url string, header map[string]string, body []byte) (int, error)
+}
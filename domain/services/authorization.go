@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/usecases"
+	"github.com/google/uuid"
+)
+
+// AuthorizedClientes lets the principal of the context manage the clientes
+// only as far as the policy allows. Every other call fails with ErrForbidden,
+// the calls without principal too.
+type AuthorizedClientes struct {
+	next   usecases.ClienteUseCase
+	policy entities.Policy
+}
+
+func NewAuthorizedClientes(next usecases.ClienteUseCase, policy entities.Policy) *AuthorizedClientes {
+	return &AuthorizedClientes{next: next, policy: policy}
+}
+
+func (a *AuthorizedClientes) authorize(ctx context.Context, op entities.Operation) error {
+	return authorize(ctx, a.policy, op)
+}
+
+func authorize(ctx context.Context, policy entities.Policy, op entities.Operation) error {
+	p, ok := entities.PrincipalFrom(ctx)
+	if !ok || !policy.Allows(p, op) {
+		return fmt.Errorf("%w: %s", entityErr.ErrForbidden, op)
+	}
+
+	return nil
+}
+
+func (a *AuthorizedClientes) Create(ctx context.Context, cliente entities.Cliente) (entities.ID, error) {
+	if err := a.authorize(ctx, entities.OpCreateCliente); err != nil {
+		return entities.ID{}, err
+	}
+	return a.next.Create(ctx, cliente)
+}
+
+func (a *AuthorizedClientes) List(ctx context.Context, opts entities.ListOptions) (entities.ClientePage, error) {
+	if err := a.authorize(ctx, entities.OpListClientes); err != nil {
+		return entities.ClientePage{}, err
+	}
+	return a.next.List(ctx, opts)
+}
+
+func (a *AuthorizedClientes) GetClienteById(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	if err := a.authorize(ctx, entities.OpReadCliente); err != nil {
+		return nil, err
+	}
+	return a.next.GetClienteById(ctx, id)
+}
+
+func (a *AuthorizedClientes) GetClienteByCPF(ctx context.Context, cpf string) (*entities.Cliente, error) {
+	if err := a.authorize(ctx, entities.OpFindClienteByCPF); err != nil {
+		return nil, err
+	}
+	return a.next.GetClienteByCPF(ctx, cpf)
+}
+
+func (a *AuthorizedClientes) GetClienteByEmail(ctx context.Context, email string) (*entities.Cliente, error) {
+	if err := a.authorize(ctx, entities.OpFindClienteByEmail); err != nil {
+		return nil, err
+	}
+	return a.next.GetClienteByEmail(ctx, email)
+}
+
+func (a *AuthorizedClientes) Update(ctx context.Context, cliente entities.Cliente) (*entities.Cliente, error) {
+	if err := a.authorize(ctx, entities.OpUpdateCliente); err != nil {
+		return nil, err
+	}
+	return a.next.Update(ctx, cliente)
+}
+
+func (a *AuthorizedClientes) Patch(ctx context.Context, id entities.ID, patch entities.ClientePatch) (*entities.Cliente, error) {
+	if err := a.authorize(ctx, entities.OpUpdateCliente); err != nil {
+		return nil, err
+	}
+	return a.next.Patch(ctx, id, patch)
+}
+
+func (a *AuthorizedClientes) Remove(ctx context.Context, id entities.ID) error {
+	if err := a.authorize(ctx, entities.OpRemoveCliente); err != nil {
+		return err
+	}
+	return a.next.Remove(ctx, id)
+}
+
+func (a *AuthorizedClientes) Reactivate(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	if err := a.authorize(ctx, entities.OpReactivateCliente); err != nil {
+		return nil, err
+	}
+	return a.next.Reactivate(ctx, id)
+}
+
+func (a *AuthorizedClientes) Purge(ctx context.Context, id entities.ID) error {
+	if err := a.authorize(ctx, entities.OpPurgeCliente); err != nil {
+		return err
+	}
+	return a.next.Purge(ctx, id)
+}
+
+func (a *AuthorizedClientes) Anonymize(ctx context.Context, id entities.ID, reason string) (*entities.Cliente, error) {
+	if err := a.authorize(ctx, entities.OpAnonymizeCliente); err != nil {
+		return nil, err
+	}
+	return a.next.Anonymize(ctx, id, reason)
+}
+
+func (a *AuthorizedClientes) Export(ctx context.Context, id entities.ID) (*entities.ClienteExport, error) {
+	if err := a.authorize(ctx, entities.OpExportCliente); err != nil {
+		return nil, err
+	}
+	return a.next.Export(ctx, id)
+}
+
+func (a *AuthorizedClientes) History(ctx context.Context, id entities.ID) ([]entities.AuditEntry, error) {
+	if err := a.authorize(ctx, entities.OpClienteHistory); err != nil {
+		return nil, err
+	}
+	return a.next.History(ctx, id)
+}
+
+func (a *AuthorizedClientes) Identify(ctx context.Context, id entities.Identification) (entities.Identified, error) {
+	if err := a.authorize(ctx, entities.OpIdentifyCliente); err != nil {
+		return entities.Identified{}, err
+	}
+	return a.next.Identify(ctx, id)
+}
+
+func (a *AuthorizedClientes) Me(ctx context.Context) (*entities.Cliente, error) {
+	if err := a.authorize(ctx, entities.OpSelfService); err != nil {
+		return nil, err
+	}
+	return a.next.Me(ctx)
+}
+
+func (a *AuthorizedClientes) PatchMe(ctx context.Context, patch entities.ClientePatch) (*entities.Cliente, error) {
+	if err := a.authorize(ctx, entities.OpSelfService); err != nil {
+		return nil, err
+	}
+	return a.next.PatchMe(ctx, patch)
+}
+
+func (a *AuthorizedClientes) RemoveMe(ctx context.Context) error {
+	if err := a.authorize(ctx, entities.OpSelfService); err != nil {
+		return err
+	}
+	return a.next.RemoveMe(ctx)
+}
+
+// AuthorizedVerification lets the principal of the context verify e-mails only
+// when the policy grants OpVerifyEmail.
+type AuthorizedVerification struct {
+	next   usecases.VerificationUseCase
+	policy entities.Policy
+}
+
+func NewAuthorizedVerification(next usecases.VerificationUseCase, policy entities.Policy) *AuthorizedVerification {
+	return &AuthorizedVerification{next: next, policy: policy}
+}
+
+func (a *AuthorizedVerification) RequestEmailVerification(ctx context.Context, id entities.ID) error {
+	if err := authorize(ctx, a.policy, entities.OpVerifyEmail); err != nil {
+		return err
+	}
+	return a.next.RequestEmailVerification(ctx, id)
+}
+
+func (a *AuthorizedVerification) ConfirmEmailVerification(ctx context.Context, id entities.ID, code string) (*entities.Cliente, error) {
+	if err := authorize(ctx, a.policy, entities.OpVerifyEmail); err != nil {
+		return nil, err
+	}
+	return a.next.ConfirmEmailVerification(ctx, id, code)
+}
+
+// AuthorizedConsents lets the principal of the context record consents only
+// when the policy grants OpRecordConsent, and read them only when it grants
+// OpListConsents.
+type AuthorizedConsents struct {
+	next   usecases.ConsentUseCase
+	policy entities.Policy
+}
+
+func NewAuthorizedConsents(next usecases.ConsentUseCase, policy entities.Policy) *AuthorizedConsents {
+	return &AuthorizedConsents{next: next, policy: policy}
+}
+
+func (a *AuthorizedConsents) Record(ctx context.Context, record entities.ConsentRecord) (entities.Consent, error) {
+	if err := authorize(ctx, a.policy, entities.OpRecordConsent); err != nil {
+		return entities.Consent{}, err
+	}
+	return a.next.Record(ctx, record)
+}
+
+func (a *AuthorizedConsents) Consents(ctx context.Context, clienteID uuid.UUID) ([]entities.Consent, error) {
+	if err := authorize(ctx, a.policy, entities.OpListConsents); err != nil {
+		return nil, err
+	}
+	return a.next.Consents(ctx, clienteID)
+}
+
+func (a *AuthorizedConsents) History(ctx context.Context, clienteID uuid.UUID) ([]entities.ConsentRecord, error) {
+	if err := authorize(ctx, a.policy, entities.OpListConsents); err != nil {
+		return nil, err
+	}
+	return a.next.History(ctx, clienteID)
+}
+
+// AuthorizedWebhooks lets the principal of the context manage the webhooks
+// and their deliveries only when the policy grants OpManageWebhooks.
+type AuthorizedWebhooks struct {
+	next   usecases.WebhookUseCase
+	policy entities.Policy
+}
+
+func NewAuthorizedWebhooks(next usecases.WebhookUseCase, policy entities.Policy) *AuthorizedWebhooks {
+	return &AuthorizedWebhooks{next: next, policy: policy}
+}
+
+func (a *AuthorizedWebhooks) Subscribe(ctx context.Context, sub entities.WebhookSubscription) (entities.WebhookSubscription, error) {
+	if err := authorize(ctx, a.policy, entities.OpManageWebhooks); err != nil {
+		return entities.WebhookSubscription{}, err
+	}
+	return a.next.Subscribe(ctx, sub)
+}
+
+func (a *AuthorizedWebhooks) Subscriptions(ctx context.Context) ([]entities.WebhookSubscription, error) {
+	if err := authorize(ctx, a.policy, entities.OpManageWebhooks); err != nil {
+		return nil, err
+	}
+	return a.next.Subscriptions(ctx)
+}
+
+func (a *AuthorizedWebhooks) Subscription(ctx context.Context, id uuid.UUID) (*entities.WebhookSubscription, error) {
+	if err := authorize(ctx, a.policy, entities.OpManageWebhooks); err != nil {
+		return nil, err
+	}
+	return a.next.Subscription(ctx, id)
+}
+
+func (a *AuthorizedWebhooks) Unsubscribe(ctx context.Context, id uuid.UUID) error {
+	if err := authorize(ctx, a.policy, entities.OpManageWebhooks); err != nil {
+		return err
+	}
+	return a.next.Unsubscribe(ctx, id)
+}
+
+func (a *AuthorizedWebhooks) Deliveries(ctx context.Context, id uuid.UUID, status entities.DeliveryStatus) ([]entities.WebhookDelivery, error) {
+	if err := authorize(ctx, a.policy, entities.OpManageWebhooks); err != nil {
+		return nil, err
+	}
+	return a.next.Deliveries(ctx, id, status)
+}
+
+func (a *AuthorizedWebhooks) Redeliver(ctx context.Context, id, deliveryID uuid.UUID) (*entities.WebhookDelivery, error) {
+	if err := authorize(ctx, a.policy, entities.OpManageWebhooks); err != nil {
+		return nil, err
+	}
+	return a.next.Redeliver(ctx, id, deliveryID)
+}
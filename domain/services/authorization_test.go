@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+func TestAuthorizedClientes(t *testing.T) {
+	repo := &ClienteRepositoryMock{Base: map[entities.ID]*entities.Cliente{}}
+	for id, c := range clienteRepoMock.Base {
+		repo.Base[id] = c
+	}
+	authorized := NewAuthorizedClientes(New(repo), entities.DefaultPolicy())
+
+	as := func(roles ...string) context.Context {
+		return entities.WithPrincipal(context.Background(), entities.Principal{Subject: "test", Roles: roles})
+	}
+	id, _ := entities.StringToID(existentClientID)
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"totem finds by cpf", func() error {
+			_, err := authorized.GetClienteByCPF(as("totem"), existentClientCPF)
+			return err
+		}, entityErr.ErrForbidden},
+		{"backoffice finds by cpf", func() error {
+			_, err := authorized.GetClienteByCPF(as("backoffice"), existentClientCPF)
+			return err
+		}, nil},
+		{"totem lists", func() error {
+			_, err := authorized.List(as("totem"), entities.ListOptions{})
+			return err
+		}, entityErr.ErrForbidden},
+		{"backoffice lists", func() error {
+			_, err := authorized.List(as("backoffice"), entities.ListOptions{})
+			return err
+		}, nil},
+		{"backoffice removes", func() error { return authorized.Remove(as("backoffice"), id) }, entityErr.ErrForbidden},
+		{"backoffice purges", func() error { return authorized.Purge(as("backoffice"), id) }, entityErr.ErrForbidden},
+		{"no principal", func() error {
+			_, err := authorized.GetClienteById(context.Background(), id)
+			return err
+		}, entityErr.ErrForbidden},
+		{"cliente sees itself", func() error {
+			_, err := authorized.Me(entities.WithPrincipal(context.Background(), entities.Principal{Subject: existentClientID, Roles: []string{"cliente"}}))
+			return err
+		}, nil},
+		{"cliente reads by id", func() error {
+			_, err := authorized.GetClienteById(as("cliente"), id)
+			return err
+		}, entityErr.ErrForbidden},
+		{"admin purges", func() error { return authorized.Purge(as("admin"), id) }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if tt.want == nil && err != nil {
+				t.Errorf("should not have return error, got: %s", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("want: %s, got: %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
 	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
@@ -10,146 +13,398 @@ import (
 	"github.com/google/uuid"
 )
 
+// Service manages the clientes. Each of its mutations raises a domain event,
+// written to the outbox in the transaction of the change itself.
 type Service struct {
-	repo ports.Repository
+	repo          ports.Repository
+	exportSources []ports.ExportSource
+	sessions      ports.SessionIssuer
 }
 
-func New(repository ports.Repository) *Service {
-	return &Service{repository}
+type Option func(*Service)
+
+// WithExportSources adds data sources to the export of a cliente, after the
+// cliente record itself.
+func WithExportSources(sources ...ports.ExportSource) Option {
+	return func(s *Service) {
+		s.exportSources = append(s.exportSources, sources...)
+	}
+}
+
+// WithSessionIssuer signs the sessions of the clientes identified with
+// Identify, which fails without one.
+func WithSessionIssuer(issuer ports.SessionIssuer) Option {
+	return func(s *Service) {
+		s.sessions = issuer
+	}
 }
 
-func (s *Service) Create(cliente entities.Cliente) (entities.ID, error) {
-	c, err := s.repo.GetClienteByCPF(cliente.CPF())
+func New(repository ports.Repository, opts ...Option) *Service {
+	s := &Service{repo: repository}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *Service) Create(ctx context.Context, cliente entities.Cliente) (entities.ID, error) {
+	id := entities.NewID()
+
+	c2, err := entities.New(id, cliente.Name(), cliente.CPF(), cliente.Email(), true)
 	if err != nil {
-		if !errors.Is(err, entityErr.ErrNotFound) {
-			return uuid.Nil, err
+		return uuid.Nil, fmt.Errorf("creating new cliente: %w", err)
+	}
+
+	err = s.repo.WithinTx(ctx, func(repo ports.Repository) error {
+		// uniqueness of cpf and email is enforced by the repository
+		if err := repo.Create(ctx, *c2); err != nil {
+			return err
 		}
+
+		// the event carries the cliente as stored
+		stored, err := repo.GetClienteById(ctx, id)
+		if err != nil {
+			return err
+		}
+		return raise(ctx, repo, entities.AuditCreated, nil, stored)
+	})
+	if err != nil {
+		return uuid.Nil, err
 	}
-	if c != nil {
-		return uuid.Nil, entityErr.ErrClienteAlreadyExistsForCPF
+
+	return id, nil
+}
+
+func (s *Service) List(ctx context.Context, opts entities.ListOptions) (entities.ClientePage, error) {
+	opts, err := opts.Normalize()
+	if err != nil {
+		return entities.ClientePage{}, err
 	}
 
-	c, err = s.repo.GetClienteByEmail(cliente.Email())
+	page, err := s.repo.List(ctx, opts)
 	if err != nil {
-		if !errors.Is(err, entityErr.ErrNotFound) {
-			return uuid.Nil, err
-		}
+		return entities.ClientePage{}, err
 	}
-	if c != nil {
-		return uuid.Nil, entityErr.ErrClienteAlreadyExistsForEmail
+
+	return page, nil
+}
+
+func (s *Service) GetClienteById(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	c, err := s.repo.GetClienteById(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	id := entities.NewID()
+	return c, nil
+}
 
-	c2, err := entities.New(id, cliente.Name(), cliente.CPF(), cliente.Email(), true)
+func (s *Service) GetClienteByCPF(ctx context.Context, cpf string) (*entities.Cliente, error) {
+	c, err := s.repo.GetClienteByCPF(ctx, entities.NormalizeCPF(cpf))
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("creating new cliente: %s", err)
+		return nil, err
 	}
 
-	s.repo.Create(*c2)
+	return c, nil
+}
 
-	if err == nil {
-		buff := make([]byte, 10)
-		b, _ := c2.Id().MarshalBinary()
-		_ = fmt.Sprintf("%s, %s", buff, b)
-		_ = c2.Validate()
+func (s *Service) GetClienteByEmail(ctx context.Context, email string) (*entities.Cliente, error) {
+	c, err := s.repo.GetClienteByEmail(ctx, email)
+	if err != nil {
+		return nil, err
 	}
 
-	return id, nil
+	return c, nil
 }
 
-func (s *Service) List() ([]*entities.Cliente, error) {
-	c, err := s.repo.List()
+// Update replaces the cliente. A cliente without version is updated over
+// whatever version is stored, otherwise the version must still be current.
+func (s *Service) Update(ctx context.Context, cliente entities.Cliente) (*entities.Cliente, error) {
+	if err := cliente.Validate(); err != nil {
+		return nil, err
+	}
+
+	var updated *entities.Cliente
+	err := s.repo.WithinTx(ctx, func(repo ports.Repository) error {
+		c := &cliente
+		if cliente.Version() == 0 {
+			current, err := repo.GetClienteById(ctx, cliente.Id())
+			if err != nil {
+				return err
+			}
+
+			c, err = entities.New(cliente.Id(), cliente.Name(), cliente.CPF(), cliente.Email(), cliente.Active(),
+				entities.WithVersion(current.Version()))
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if updated, err = repo.Update(ctx, *c); err != nil {
+			return err
+		}
+		return raise(ctx, repo, entities.AuditUpdated, nil, updated)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if err == nil {
-		buff := make([]byte, 10)
-		b, _ := entities.NewID().MarshalBinary()
-		_ = fmt.Sprintf("%s, %s", buff, b)
+	return updated, nil
+}
+
+// Anonymize irreversibly erases the personal data of the cliente to honor an
+// LGPD deletion request, keeping the record orders point to.
+func (s *Service) Anonymize(ctx context.Context, id entities.ID, reason string) (*entities.Cliente, error) {
+	var anonymized *entities.Cliente
+	err := s.repo.WithinTx(ctx, func(repo ports.Repository) error {
+		current, err := repo.GetClienteById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		c, err := current.Anonymize(time.Now().UTC(), reason)
+		if err != nil {
+			return err
+		}
+
+		if anonymized, err = repo.Anonymize(ctx, *c); err != nil {
+			return err
+		}
+		return raise(ctx, repo, entities.AuditAnonymized, current, anonymized)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return c, nil
+	return anonymized, nil
 }
 
-func (s *Service) GetClienteById(id entities.ID) (*entities.Cliente, error) {
-	c, err := s.repo.GetClienteById(id)
+// Export gathers everything held about the cliente for a data-subject access
+// request.
+func (s *Service) Export(ctx context.Context, id entities.ID) (*entities.ClienteExport, error) {
+	var (
+		c       *entities.Cliente
+		history []entities.AuditEntry
+	)
+	// the cliente and its history as of the same moment
+	err := s.repo.WithinTx(ctx, func(repo ports.Repository) error {
+		var err error
+		if c, err = repo.GetClienteById(ctx, id); err != nil {
+			return err
+		}
+		if history, err = repo.History(ctx, id); err != nil {
+			return fmt.Errorf("exporting cliente %s: %w", id, err)
+		}
+		return nil
+	}, ports.WithIsolation(ports.RepeatableRead), ports.ReadOnly())
 	if err != nil {
 		return nil, err
 	}
 
-	if err == nil {
-		buff := make([]byte, 10)
-		b, _ := c.Id().MarshalBinary()
-		_ = fmt.Sprintf("%s, %s", buff, b)
-		_ = c.Validate()
+	export := entities.ClienteExport{
+		ClienteID:   id,
+		GeneratedAt: time.Now().UTC(),
+		Sections:    []entities.ExportSection{entities.ClienteSection(c), entities.AuditSection(history)},
+	}
+	for _, src := range s.exportSources {
+		section, err := src.ExportSection(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("exporting cliente %s: %w", id, err)
+		}
+		export.Sections = append(export.Sections, section)
 	}
 
-	return c, nil
+	return &export, nil
 }
 
-func (s *Service) GetClienteByCPF(cpf string) (*entities.Cliente, error) {
-	c, err := s.repo.GetClienteByCPF(cpf)
+// History lists the mutations of a cliente, oldest first. It outlives a
+// purged cliente.
+func (s *Service) History(ctx context.Context, id entities.ID) ([]entities.AuditEntry, error) {
+	history, err := s.repo.History(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if len(history) == 0 {
+		return nil, entityErr.ErrNotFound
+	}
+
+	return history, nil
+}
 
-	if err == nil {
-		buff := make([]byte, 10)
-		b, _ := c.Id().MarshalBinary()
-		_ = fmt.Sprintf("%s, %s", buff, b)
-		_ = c.Validate()
+// Remove deactivates the cliente, orders in other services still reference
+// it. Purge is the one that actually deletes.
+func (s *Service) Remove(ctx context.Context, id entities.ID) error {
+	return s.repo.WithinTx(ctx, func(repo ports.Repository) error {
+		c, err := repo.Deactivate(ctx, id)
+		if err != nil {
+			return err
+		}
+		return raise(ctx, repo, entities.AuditDeactivated, nil, c)
+	})
+}
+
+func (s *Service) Reactivate(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	var c *entities.Cliente
+	err := s.repo.WithinTx(ctx, func(repo ports.Repository) error {
+		var err error
+		if c, err = repo.Reactivate(ctx, id); err != nil {
+			return err
+		}
+		return raise(ctx, repo, entities.AuditReactivated, nil, c)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return c, nil
 }
 
-func (s *Service) GetClienteByEmail(email string) (*entities.Cliente, error) {
-	c, err := s.repo.GetClienteByEmail(email)
+func (s *Service) Purge(ctx context.Context, id entities.ID) error {
+	return s.repo.WithinTx(ctx, func(repo ports.Repository) error {
+		current, err := repo.GetClienteById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := repo.Remove(ctx, id); err != nil {
+			return err
+		}
+		return raise(ctx, repo, entities.AuditDeleted, current, nil)
+	})
+}
+
+func (s *Service) Patch(ctx context.Context, id entities.ID, patch entities.ClientePatch) (*entities.Cliente, error) {
+	var patched *entities.Cliente
+	err := s.repo.WithinTx(ctx, func(repo ports.Repository) error {
+		current, err := repo.GetClienteById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if patch.ExpectedVersion != 0 && patch.ExpectedVersion != current.Version() {
+			return entityErr.ErrConcurrentModification
+		}
+
+		c, err := current.Apply(patch)
+		if err != nil {
+			return err
+		}
+
+		if patched, err = repo.Update(ctx, *c); err != nil {
+			return err
+		}
+		return raise(ctx, repo, entities.AuditUpdated, current, patched)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if err == nil {
-		buff := make([]byte, 10)
-		b, _ := c.Id().MarshalBinary()
-		_ = fmt.Sprintf("%s, %s", buff, b)
-		_ = c.Validate()
+	return patched, nil
+}
+
+// raise writes the event of the change of the cliente from before to after
+// with repo, the transaction of the change.
+func raise(ctx context.Context, repo ports.Repository, action entities.AuditAction, before, after *entities.Cliente) error {
+	event, err := entities.NewClienteEvent(action, before, after)
+	if err != nil {
+		return fmt.Errorf("raising %s event: %w", action, err)
 	}
 
-	return c, nil
+	return repo.WriteEvent(ctx, event)
 }
 
-func (s *Service) Update(cliente entities.Cliente) error {
-	if err := cliente.Validate(); err != nil {
-		return err
+// Me returns the cliente authenticated in ctx, the one whose id is the
+// subject of the principal or else whose cpf is the cpf of the principal.
+// Removed and anonymized clientes are not found.
+func (s *Service) Me(ctx context.Context) (*entities.Cliente, error) {
+	p, ok := entities.PrincipalFrom(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: not authenticated", entityErr.ErrForbidden)
 	}
-	err := s.repo.Update(cliente)
+
+	me, err := s.findPrincipal(ctx, p)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !me.Active() || me.Anonymized() {
+		return nil, entityErr.ErrNotFound
 	}
 
-	if err == nil {
-		buff := make([]byte, 10)
-		b, _ := entities.NewID().MarshalBinary()
-		_ = fmt.Sprintf("%s, %s", buff, b)
+	return me, nil
+}
+
+// findPrincipal looks the cliente up by the subject of the principal and, when
+// the subject is not the id of a cliente, by its cpf.
+func (s *Service) findPrincipal(ctx context.Context, p entities.Principal) (*entities.Cliente, error) {
+	if id, err := entities.StringToID(p.Subject); err == nil {
+		c, err := s.repo.GetClienteById(ctx, id)
+		if !errors.Is(err, entityErr.ErrNotFound) {
+			return c, err
+		}
+	}
+	if p.CPF != "" {
+		return s.repo.GetClienteByCPF(ctx, entities.NormalizeCPF(p.CPF))
 	}
 
-	return nil
+	return nil, entityErr.ErrNotFound
 }
 
-func (s *Service) Remove(id entities.ID) error {
-	err := s.repo.Remove(id)
+// PatchMe patches the cliente authenticated in ctx with the changes clientes
+// can make to their own record.
+func (s *Service) PatchMe(ctx context.Context, patch entities.ClientePatch) (*entities.Cliente, error) {
+	if err := patch.ValidateForOwner(); err != nil {
+		return nil, err
+	}
+
+	me, err := s.Me(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Patch(ctx, me.Id(), patch)
+}
+
+// RemoveMe deactivates the cliente authenticated in ctx.
+func (s *Service) RemoveMe(ctx context.Context) error {
+	me, err := s.Me(ctx)
 	if err != nil {
 		return err
 	}
 
-	if err == nil {
-		buff := make([]byte, 10)
-		b, _ := entities.NewID().MarshalBinary()
-		_ = fmt.Sprintf("%s, %s", buff, b)
+	return s.Remove(ctx, me.Id())
+}
+
+// Identify finds the active cliente with the cpf or e-mail and opens a
+// session for it. Only a summary of the cliente is returned, since whoever
+// makes the request is not known to be the cliente.
+func (s *Service) Identify(ctx context.Context, id entities.Identification) (entities.Identified, error) {
+	if err := id.Validate(); err != nil {
+		return entities.Identified{}, err
+	}
+	if s.sessions == nil {
+		return entities.Identified{}, errors.New("identifying cliente: no session issuer")
+	}
+
+	var (
+		c   *entities.Cliente
+		err error
+	)
+	if id.CPF != "" {
+		c, err = s.repo.GetClienteByCPF(ctx, entities.NormalizeCPF(id.CPF))
+	} else {
+		c, err = s.repo.GetClienteByEmail(ctx, strings.TrimSpace(id.Email))
+	}
+	if err != nil {
+		return entities.Identified{}, err
+	}
+	if !c.Active() || c.Anonymized() {
+		return entities.Identified{}, entityErr.ErrNotFound
+	}
+
+	session, err := s.sessions.IssueSession(ctx, c.Id())
+	if err != nil {
+		return entities.Identified{}, fmt.Errorf("identifying cliente %s: %w", c.Id(), err)
 	}
 
-	return nil
+	return entities.Identified{Cliente: entities.Summarize(c), Session: session}, nil
 }
@@ -1,27 +1,36 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"maps"
 	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
 	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
 	"github.com/google/uuid"
 )
 
 var existentClientID string = "db6c3a54-541f-472c-8810-13508c930070"
-var existentClientCPF string = "12312312312"
+var existentClientCPF string = "12312312387"
 var existentClientEmail string = "fulano@email.com"
 
 var clienteIdError string = "db6c3a54-541f-7777-8810-13508c930070"
-var clienteCpfError string = "90867562301"
+var clienteCpfError string = "90867562390"
 var clienteEmailError string = "error@email.com"
 var flagListClienteError bool = false
 
 type ClienteRepositoryMock struct {
 	Base map[entities.ID]*entities.Cliente
+	// Txs has the options of every WithinTx call
+	Txs []ports.TxOptions
+	// Events has the events written, oldest first
+	Events []entities.Event
 }
 
 var clienteRepoMock ClienteRepositoryMock
@@ -44,7 +53,7 @@ func init() {
 	clienteRepoMock = repoMock
 }
 
-func (c *ClienteRepositoryMock) Create(cliente entities.Cliente) error {
+func (c *ClienteRepositoryMock) Create(ctx context.Context, cliente entities.Cliente) error {
 	if cliente.Id().String() == clienteIdError || cliente.CPF() == clienteCpfError || cliente.Email() == clienteEmailError {
 		return errors.New("repo mock error")
 	}
@@ -65,15 +74,15 @@ func (c *ClienteRepositoryMock) Create(cliente entities.Cliente) error {
 	return nil
 }
 
-func (c *ClienteRepositoryMock) List() ([]*entities.Cliente, error) {
+func (c *ClienteRepositoryMock) List(ctx context.Context, opts entities.ListOptions) (entities.ClientePage, error) {
 	if flagListClienteError {
-		return nil, errors.New("new mock error")
+		return entities.ClientePage{}, errors.New("new mock error")
 	}
 
-	return slices.Collect(maps.Values(c.Base)), nil
+	return entities.ClientePage{Clientes: slices.Collect(maps.Values(c.Base))}, nil
 }
 
-func (c *ClienteRepositoryMock) GetClienteById(id entities.ID) (*entities.Cliente, error) {
+func (c *ClienteRepositoryMock) GetClienteById(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
 	errCUuid, _ := entities.StringToID(clienteIdError)
 	if id == errCUuid {
 		return nil, errors.New("new mock error")
@@ -86,7 +95,7 @@ func (c *ClienteRepositoryMock) GetClienteById(id entities.ID) (*entities.Client
 	return cliente, nil
 }
 
-func (c *ClienteRepositoryMock) GetClienteByCPF(cpf string) (*entities.Cliente, error) {
+func (c *ClienteRepositoryMock) GetClienteByCPF(ctx context.Context, cpf string) (*entities.Cliente, error) {
 	if cpf == clienteCpfError {
 		return nil, errors.New("new mock error")
 	}
@@ -100,7 +109,7 @@ func (c *ClienteRepositoryMock) GetClienteByCPF(cpf string) (*entities.Cliente,
 	return nil, entityErr.ErrNotFound
 }
 
-func (c *ClienteRepositoryMock) GetClienteByEmail(email string) (*entities.Cliente, error) {
+func (c *ClienteRepositoryMock) GetClienteByEmail(ctx context.Context, email string) (*entities.Cliente, error) {
 	if email == clienteEmailError {
 		return nil, errors.New("new mock error")
 	}
@@ -114,22 +123,100 @@ func (c *ClienteRepositoryMock) GetClienteByEmail(email string) (*entities.Clien
 	return nil, entityErr.ErrNotFound
 }
 
-func (c *ClienteRepositoryMock) Update(cliente entities.Cliente) error {
+func (c *ClienteRepositoryMock) Update(ctx context.Context, cliente entities.Cliente) (*entities.Cliente, error) {
 	errCUuid, _ := entities.StringToID(clienteIdError)
 	if cliente.Id() == errCUuid {
-		return errors.New("new mock error")
+		return nil, errors.New("new mock error")
 	}
 
-	if _, ok := c.Base[cliente.Id()]; !ok {
-		return entityErr.ErrNotFound
+	stored, ok := c.Base[cliente.Id()]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	if stored.Version() != cliente.Version() {
+		return nil, entityErr.ErrConcurrentModification
+	}
+
+	for k, v := range c.Base {
+		if k == cliente.Id() {
+			continue
+		}
+		if v.CPF() == cliente.CPF() {
+			return nil, entityErr.ErrClienteAlreadyExistsForCPF
+		}
+		if v.Email() == cliente.Email() {
+			return nil, entityErr.ErrClienteAlreadyExistsForEmail
+		}
+	}
+
+	updated, _ := entities.New(cliente.Id(), cliente.Name(), cliente.CPF(), cliente.Email(), cliente.Active(),
+		entities.WithVersion(cliente.Version()+1))
+	c.Base[cliente.Id()] = updated
+
+	return updated, nil
+}
+
+func (c *ClienteRepositoryMock) Anonymize(ctx context.Context, cliente entities.Cliente) (*entities.Cliente, error) {
+	stored, ok := c.Base[cliente.Id()]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	if stored.Anonymized() {
+		return nil, entityErr.ErrClienteAnonymized
 	}
 
 	c.Base[cliente.Id()] = &cliente
+	return &cliente, nil
+}
 
-	return nil
+func (c *ClienteRepositoryMock) VerifyEmail(ctx context.Context, id entities.ID, email string, at time.Time) (*entities.Cliente, error) {
+	stored, ok := c.Base[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	if !strings.EqualFold(stored.Email(), email) {
+		return nil, entityErr.ErrConcurrentModification
+	}
+
+	verified, _ := entities.New(id, stored.Name(), stored.CPF(), stored.Email(), stored.Active(),
+		entities.WithVersion(stored.Version()+1), entities.WithEmailVerified(at))
+	c.Base[id] = verified
+
+	return verified, nil
+}
+
+func (c *ClienteRepositoryMock) Deactivate(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	return c.setActive(id, false)
+}
+
+func (c *ClienteRepositoryMock) Reactivate(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	return c.setActive(id, true)
+}
+
+func (c *ClienteRepositoryMock) setActive(id entities.ID, active bool) (*entities.Cliente, error) {
+	errCUuid, _ := entities.StringToID(clienteIdError)
+	if id == errCUuid {
+		return nil, errors.New("new mock error")
+	}
+
+	stored, ok := c.Base[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+
+	var deletedAt time.Time
+	if !active {
+		deletedAt = time.Now()
+	}
+
+	updated, _ := entities.New(id, stored.Name(), stored.CPF(), stored.Email(), active,
+		entities.WithDeletedAt(deletedAt), entities.WithVersion(stored.Version()+1))
+	c.Base[id] = updated
+
+	return updated, nil
 }
 
-func (c *ClienteRepositoryMock) Remove(id entities.ID) error {
+func (c *ClienteRepositoryMock) Remove(ctx context.Context, id entities.ID) error {
 	errCUuid, _ := entities.StringToID(clienteIdError)
 	if id == errCUuid {
 		return errors.New("new mock error")
@@ -145,12 +232,413 @@ func (c *ClienteRepositoryMock) Remove(id entities.ID) error {
 
 }
 
+func (c *ClienteRepositoryMock) History(ctx context.Context, id entities.ID) ([]entities.AuditEntry, error) {
+	if _, ok := c.Base[id]; !ok {
+		return nil, nil
+	}
+
+	return []entities.AuditEntry{{ClienteID: id, Action: entities.AuditCreated}}, nil
+}
+
+func (c *ClienteRepositoryMock) WriteEvent(ctx context.Context, event entities.Event) error {
+	c.Events = append(c.Events, event)
+	return nil
+}
+
+func (c *ClienteRepositoryMock) WithinTx(ctx context.Context, fn func(repo ports.Repository) error, opts ...ports.TxOption) error {
+	c.Txs = append(c.Txs, ports.NewTxOptions(opts...))
+	return fn(c)
+}
+
+var errRepoFailure = errors.New("repository failure")
+
+// failingRepository fails every call, the service must not hide it.
+type failingRepository struct{}
+
+func (failingRepository) Create(ctx context.Context, cliente entities.Cliente) error {
+	return errRepoFailure
+}
+
+func (failingRepository) List(ctx context.Context, opts entities.ListOptions) (entities.ClientePage, error) {
+	return entities.ClientePage{}, errRepoFailure
+}
+
+func (failingRepository) GetClienteById(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	return nil, errRepoFailure
+}
+
+func (failingRepository) GetClienteByCPF(ctx context.Context, cpf string) (*entities.Cliente, error) {
+	return nil, errRepoFailure
+}
+
+func (failingRepository) GetClienteByEmail(ctx context.Context, email string) (*entities.Cliente, error) {
+	return nil, errRepoFailure
+}
+
+func (failingRepository) Update(ctx context.Context, cliente entities.Cliente) (*entities.Cliente, error) {
+	return nil, errRepoFailure
+}
+
+func (failingRepository) Anonymize(ctx context.Context, cliente entities.Cliente) (*entities.Cliente, error) {
+	return nil, errRepoFailure
+}
+
+func (failingRepository) VerifyEmail(ctx context.Context, id entities.ID, email string, at time.Time) (*entities.Cliente, error) {
+	return nil, errRepoFailure
+}
+
+func (failingRepository) Deactivate(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	return nil, errRepoFailure
+}
+
+func (failingRepository) Reactivate(ctx context.Context, id entities.ID) (*entities.Cliente, error) {
+	return nil, errRepoFailure
+}
+
+func (failingRepository) Remove(ctx context.Context, id entities.ID) error {
+	return errRepoFailure
+}
+
+func (failingRepository) History(ctx context.Context, id entities.ID) ([]entities.AuditEntry, error) {
+	return nil, errRepoFailure
+}
+
+func (failingRepository) WriteEvent(ctx context.Context, event entities.Event) error {
+	return errRepoFailure
+}
+
+func (failingRepository) WithinTx(ctx context.Context, fn func(repo ports.Repository) error, opts ...ports.TxOption) error {
+	return fn(failingRepository{})
+}
+
+func TestServiceRepositoryFailures(t *testing.T) {
+	service := New(failingRepository{})
+	ctx := context.Background()
+	c, _ := entities.New(entities.NewID(), "Fulano", "52998224725", "fulano@email.com", true)
+	name := "Ciclano"
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"create", func() error {
+			id, err := service.Create(ctx, *c)
+			if id != uuid.Nil {
+				t.Errorf("should not return an id on failure, got: %s", id)
+			}
+			return err
+		}},
+		{"list", func() error { _, err := service.List(ctx, entities.ListOptions{}); return err }},
+		{"get by id", func() error { _, err := service.GetClienteById(ctx, c.Id()); return err }},
+		{"get by cpf", func() error { _, err := service.GetClienteByCPF(ctx, c.CPF()); return err }},
+		{"get by email", func() error { _, err := service.GetClienteByEmail(ctx, c.Email()); return err }},
+		{"update", func() error { _, err := service.Update(ctx, *c); return err }},
+		{"patch", func() error { _, err := service.Patch(ctx, c.Id(), entities.ClientePatch{Name: &name}); return err }},
+		{"remove", func() error { return service.Remove(ctx, c.Id()) }},
+		{"reactivate", func() error { _, err := service.Reactivate(ctx, c.Id()); return err }},
+		{"purge", func() error { return service.Purge(ctx, c.Id()) }},
+		{"anonymize", func() error { _, err := service.Anonymize(ctx, c.Id(), "lgpd"); return err }},
+		{"history", func() error { _, err := service.History(ctx, c.Id()); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, errRepoFailure) {
+				t.Errorf("want: %s, got: %v", errRepoFailure, err)
+			}
+		})
+	}
+}
+
+func TestServiceEvents(t *testing.T) {
+	repo := &ClienteRepositoryMock{Base: make(map[entities.ID]*entities.Cliente)}
+	service := New(repo)
+	ctx := context.Background()
+
+	c, _ := entities.New(uuid.Nil, "Fulano", "52998224725", "fulano@email.com", true)
+	id, err := service.Create(ctx, *c)
+	if err != nil {
+		t.Fatalf("should have not return errors, got: %s", err)
+	}
+	name := "Ciclano"
+	if _, err := service.Patch(ctx, id, entities.ClientePatch{Name: &name}); err != nil {
+		t.Fatalf("should have not return errors, got: %s", err)
+	}
+	if err := service.Remove(ctx, id); err != nil {
+		t.Fatalf("should have not return errors, got: %s", err)
+	}
+	if _, err := service.Reactivate(ctx, id); err != nil {
+		t.Fatalf("should have not return errors, got: %s", err)
+	}
+	if err := service.Purge(ctx, id); err != nil {
+		t.Fatalf("should have not return errors, got: %s", err)
+	}
+	if err := service.Purge(ctx, id); !errors.Is(err, entityErr.ErrNotFound) {
+		t.Fatalf("want: %s, got: %v", entityErr.ErrNotFound, err)
+	}
+
+	want := []entities.EventType{entities.ClienteCreated, entities.ClienteUpdated, entities.ClienteRemoved, entities.ClienteUpdated, entities.ClienteRemoved}
+	if len(repo.Events) != len(want) {
+		t.Fatalf("want %d events, got: %d", len(want), len(repo.Events))
+	}
+	for i, e := range repo.Events {
+		if e.Type != want[i] || e.ClienteID != id {
+			t.Errorf("event %d: want %s of cliente %s, got: %s of %s", i, want[i], id, e.Type, e.ClienteID)
+		}
+	}
+
+	var data entities.ClienteEventData
+	if err := json.Unmarshal(repo.Events[1].Payload, &data); err != nil {
+		t.Fatalf("decoding payload, got error: %s", err)
+	}
+	if data.Name != "Ciclano" || data.Version != 1 {
+		t.Errorf("should carry the patched cliente, got: %+v", data)
+	}
+	if err := json.Unmarshal(repo.Events[4].Payload, &data); err != nil {
+		t.Fatalf("decoding payload, got error: %s", err)
+	}
+	if !data.Purged {
+		t.Errorf("should have raised the purge, got: %+v", data)
+	}
+}
+
+type exportSourceFunc func(ctx context.Context, id entities.ID) (entities.ExportSection, error)
+
+func (f exportSourceFunc) ExportSection(ctx context.Context, id entities.ID) (entities.ExportSection, error) {
+	return f(ctx, id)
+}
+
+func TestServiceExport(t *testing.T) {
+	repo := ClienteRepositoryMock{Base: make(map[entities.ID]*entities.Cliente)}
+	c, _ := entities.New(entities.NewID(), "Fulano", "55588811194", "export@email.com", true)
+	repo.Base[c.Id()] = c
+
+	consents := exportSourceFunc(func(ctx context.Context, id entities.ID) (entities.ExportSection, error) {
+		return entities.ExportSection{Name: "consents", Columns: []string{"purpose"}, Rows: [][]string{{"marketing_email"}}}, nil
+	})
+
+	t.Run("exporting cliente with sources", func(t *testing.T) {
+		service := New(&repo, WithExportSources(consents))
+
+		export, err := service.Export(context.Background(), c.Id())
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+
+		var names []string
+		for _, section := range export.Sections {
+			names = append(names, section.Name)
+		}
+		if !slices.Equal(names, []string{"cliente", "history", "consents"}) {
+			t.Errorf("should have return cliente, history and consents sections, got: %v", names)
+		}
+		if cpf := export.Sections[0].Rows[0][2]; cpf != c.CPF() {
+			t.Errorf("should have exported cpf %s, got: %s", c.CPF(), cpf)
+		}
+		want := ports.TxOptions{Isolation: ports.RepeatableRead, ReadOnly: true}
+		if len(repo.Txs) == 0 || repo.Txs[len(repo.Txs)-1] != want {
+			t.Errorf("should have read a snapshot, got: %+v", repo.Txs)
+		}
+	})
+
+	t.Run("exporting inexistent cliente", func(t *testing.T) {
+		service := New(&repo, WithExportSources(consents))
+
+		if _, err := service.Export(context.Background(), entities.NewID()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("failing export source", func(t *testing.T) {
+		failing := exportSourceFunc(func(ctx context.Context, id entities.ID) (entities.ExportSection, error) {
+			return entities.ExportSection{}, errRepoFailure
+		})
+		service := New(&repo, WithExportSources(consents, failing))
+
+		if _, err := service.Export(context.Background(), c.Id()); !errors.Is(err, errRepoFailure) {
+			t.Errorf("want: %s, got: %v", errRepoFailure, err)
+		}
+	})
+}
+
+func TestServiceHistory(t *testing.T) {
+	repo := ClienteRepositoryMock{Base: make(map[entities.ID]*entities.Cliente)}
+	c, _ := entities.New(entities.NewID(), "Fulano", "55588811194", "history@email.com", true)
+	repo.Base[c.Id()] = c
+	service := New(&repo)
+
+	t.Run("history of cliente", func(t *testing.T) {
+		history, err := service.History(context.Background(), c.Id())
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if len(history) != 1 || history[0].Action != entities.AuditCreated {
+			t.Errorf("should have return the created entry, got: %+v", history)
+		}
+	})
+
+	t.Run("history of inexistent cliente", func(t *testing.T) {
+		if _, err := service.History(context.Background(), entities.NewID()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+}
+
+type SessionIssuerMock struct {
+	Issued []entities.ID
+}
+
+func (s *SessionIssuerMock) IssueSession(ctx context.Context, clienteID entities.ID) (entities.Session, error) {
+	s.Issued = append(s.Issued, clienteID)
+	return entities.Session{ClienteID: clienteID, Token: "token-" + clienteID.String(), ExpiresAt: time.Now().Add(time.Minute)}, nil
+}
+
+func TestServiceIdentify(t *testing.T) {
+	repo := ClienteRepositoryMock{Base: make(map[entities.ID]*entities.Cliente)}
+	c, _ := entities.New(entities.NewID(), "Fulano de Tal", "55588811194", "identify@email.com", true)
+	inactive, _ := entities.New(entities.NewID(), "Ciclano", "71428793860", "inactive@email.com", false)
+	repo.Base[c.Id()], repo.Base[inactive.Id()] = c, inactive
+
+	sessions := &SessionIssuerMock{}
+	service := New(&repo, WithSessionIssuer(sessions))
+
+	for _, id := range []entities.Identification{{CPF: "555.888.111-94"}, {Email: "identify@email.com"}} {
+		identified, err := service.Identify(context.Background(), id)
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if identified.Cliente.ID != c.Id() || identified.Cliente.CPF != "***.888.111-**" {
+			t.Errorf("want masked summary of cliente %s, got: %+v", c.Id(), identified.Cliente)
+		}
+		if identified.Session.Token != "token-"+c.Id().String() {
+			t.Errorf("want session of cliente %s, got: %+v", c.Id(), identified.Session)
+		}
+	}
+
+	t.Run("inactive cliente", func(t *testing.T) {
+		_, err := service.Identify(context.Background(), entities.Identification{CPF: inactive.CPF()})
+		if !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("without identifier", func(t *testing.T) {
+		_, err := service.Identify(context.Background(), entities.Identification{})
+		if !errors.Is(err, entityErr.ErrIdentifierRequired) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrIdentifierRequired, err)
+		}
+	})
+
+	if len(sessions.Issued) != 2 {
+		t.Errorf("should have issued 2 sessions, got: %d", len(sessions.Issued))
+	}
+}
+
+func TestServiceMe(t *testing.T) {
+	repo := ClienteRepositoryMock{Base: make(map[entities.ID]*entities.Cliente)}
+	c, _ := entities.New(entities.NewID(), "Fulano", "55588811194", "me@email.com", true)
+	repo.Base[c.Id()] = c
+	service := New(&repo)
+
+	bySubject := entities.WithPrincipal(context.Background(), entities.Principal{Subject: c.Id().String()})
+	byCPF := entities.WithPrincipal(context.Background(), entities.Principal{Subject: "app|42", CPF: "555.888.111-94"})
+
+	t.Run("me by subject and by cpf", func(t *testing.T) {
+		for _, ctx := range []context.Context{bySubject, byCPF} {
+			me, err := service.Me(ctx)
+			if err != nil {
+				t.Fatalf("should have not return errors, got: %s", err)
+			}
+			if me.Id() != c.Id() {
+				t.Errorf("want cliente %s, got: %s", c.Id(), me.Id())
+			}
+		}
+	})
+
+	t.Run("me by cpf when the subject is not a cliente", func(t *testing.T) {
+		ctx := entities.WithPrincipal(context.Background(), entities.Principal{Subject: entities.NewID().String(), CPF: "555.888.111-94"})
+		me, err := service.Me(ctx)
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if me.Id() != c.Id() {
+			t.Errorf("want cliente %s, got: %s", c.Id(), me.Id())
+		}
+	})
+
+	t.Run("me without cliente", func(t *testing.T) {
+		ctx := entities.WithPrincipal(context.Background(), entities.Principal{Subject: "backoffice-app"})
+		if _, err := service.Me(ctx); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+		if _, err := service.Me(context.Background()); !errors.Is(err, entityErr.ErrForbidden) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrForbidden, err)
+		}
+	})
+
+	t.Run("patch me", func(t *testing.T) {
+		name := "Fulano Silva"
+		patched, err := service.PatchMe(byCPF, entities.ClientePatch{Name: &name})
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if patched.Name() != name {
+			t.Errorf("want name %s, got: %s", name, patched.Name())
+		}
+	})
+
+	t.Run("patch me not editable", func(t *testing.T) {
+		active := false
+		_, err := service.PatchMe(bySubject, entities.ClientePatch{Active: &active})
+		if !errors.Is(err, entityErr.ErrNotEditableByOwner) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotEditableByOwner, err)
+		}
+	})
+
+	t.Run("remove me", func(t *testing.T) {
+		if err := service.RemoveMe(bySubject); err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if repo.Base[c.Id()].Active() {
+			t.Error("should have deactivated the cliente")
+		}
+	})
+
+	t.Run("me after removal", func(t *testing.T) {
+		if _, err := service.Me(bySubject); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+		name := "Fulano"
+		if _, err := service.PatchMe(byCPF, entities.ClientePatch{Name: &name}); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("me anonymized", func(t *testing.T) {
+		anonymous, err := repo.Base[c.Id()].Anonymize(time.Now(), "lgpd request")
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		// active, so that only the anonymization hides it
+		reactivated, err := entities.New(c.Id(), anonymous.Name(), anonymous.CPF(), anonymous.Email(), true,
+			entities.WithAnonymization(anonymous.AnonymizedAt(), anonymous.AnonymizationReason()))
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		repo.Base[c.Id()] = reactivated
+
+		if _, err := service.Me(bySubject); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+}
+
 func TestService(t *testing.T) {
 	service := New(&clienteRepoMock)
 
 	t.Run("create cliente", func(t *testing.T) {
-		c, _ := entities.New(uuid.Nil, "Fulano", "11111111111", "outro@email.com", true)
-		cUUID, err := service.Create(*c)
+		c, _ := entities.New(uuid.Nil, "Fulano", "52998224725", "outro@email.com", true)
+		cUUID, err := service.Create(context.Background(), *c)
 		if err != nil {
 			t.Errorf("should not have errors, got: %s", err)
 		}
@@ -160,8 +648,8 @@ func TestService(t *testing.T) {
 	})
 
 	t.Run("creating cliente with existent CPF", func(t *testing.T) {
-		c, _ := entities.New(uuid.Nil, "Fulano", existentClientCPF, "outro@email.com", true)
-		_, err := service.Create(*c)
+		c, _ := entities.New(uuid.Nil, "Fulano", existentClientCPF, "unico@email.com", true)
+		_, err := service.Create(context.Background(), *c)
 		if !errors.Is(err, entityErr.ErrClienteAlreadyExistsForCPF) {
 			t.Errorf("want: %s, got: %s", entityErr.ErrClienteAlreadyExistsForCPF, err)
 		}
@@ -171,8 +659,8 @@ func TestService(t *testing.T) {
 	})
 
 	t.Run("creating cliente with existent Email", func(t *testing.T) {
-		c, _ := entities.New(uuid.Nil, "Fulano", "22222222222", existentClientEmail, true)
-		_, err := service.Create(*c)
+		c, _ := entities.New(uuid.Nil, "Fulano", "16899535009", existentClientEmail, true)
+		_, err := service.Create(context.Background(), *c)
 		if !errors.Is(err, entityErr.ErrClienteAlreadyExistsForEmail) {
 			t.Errorf("want: %s, got: %s", entityErr.ErrClienteAlreadyExistsForEmail, err)
 		}
@@ -192,7 +680,7 @@ func TestService(t *testing.T) {
 	})
 
 	t.Run("creating cliente with invalid Email", func(t *testing.T) {
-		_, err := entities.New(uuid.Nil, "Fulano", "12312312312", "emailZuado.com", true)
+		_, err := entities.New(uuid.Nil, "Fulano", "12312312387", "emailZuado.com", true)
 		if !errors.Is(err, entityErr.ErrInvalidEmail) {
 			t.Errorf("want: %s, got: %s", entityErr.ErrInvalidEmail, err)
 		}
@@ -202,18 +690,25 @@ func TestService(t *testing.T) {
 	})
 
 	t.Run("listing clientes", func(t *testing.T) {
-		c, err := service.List()
+		c, err := service.List(context.Background(), entities.ListOptions{})
 		if err != nil {
 			t.Errorf("should not have any errors, got: %s", err)
 		}
-		if len(c) != 2 {
-			t.Errorf("should have return 2 clientes, got: %d", len(c))
+		if len(c.Clientes) != 2 {
+			t.Errorf("should have return 2 clientes, got: %d", len(c.Clientes))
+		}
+	})
+
+	t.Run("listing clientes with invalid options", func(t *testing.T) {
+		_, err := service.List(context.Background(), entities.ListOptions{Sort: "email"})
+		if !errors.Is(err, entityErr.ErrInvalidListOptions) {
+			t.Errorf("want: %s, got: %s", entityErr.ErrInvalidListOptions, err)
 		}
 	})
 
 	t.Run("getting inexistent cliente by id", func(t *testing.T) {
 		cUUID, _ := entities.StringToID("db6c3a54-541f-472c-8810-13508c930aaa")
-		c, err := service.GetClienteById(cUUID)
+		c, err := service.GetClienteById(context.Background(), cUUID)
 		if !errors.Is(err, entityErr.ErrNotFound) {
 			t.Errorf("should have not found any cliente, got: %s", c.Id())
 		}
@@ -224,7 +719,7 @@ func TestService(t *testing.T) {
 
 	t.Run("getting existent cliente by id", func(t *testing.T) {
 		cUUID, _ := entities.StringToID(existentClientID)
-		c, err := service.GetClienteById(cUUID)
+		c, err := service.GetClienteById(context.Background(), cUUID)
 		if err != nil {
 			t.Errorf("should have not return any error, got: %s", err)
 		}
@@ -234,7 +729,7 @@ func TestService(t *testing.T) {
 	})
 
 	t.Run("getting inexistent cliente by CPF", func(t *testing.T) {
-		c, err := service.GetClienteByCPF("98765432112")
+		c, err := service.GetClienteByCPF(context.Background(), "98765432100")
 		if !errors.Is(err, entityErr.ErrNotFound) {
 			t.Errorf("should have not return any error, got: %s", err)
 		}
@@ -244,7 +739,7 @@ func TestService(t *testing.T) {
 	})
 
 	t.Run("getting existent cliente by CPF", func(t *testing.T) {
-		c, err := service.GetClienteByCPF(existentClientCPF)
+		c, err := service.GetClienteByCPF(context.Background(), existentClientCPF)
 		if err != nil {
 			t.Errorf("should have found cliente, got error: %s", err)
 		}
@@ -254,7 +749,7 @@ func TestService(t *testing.T) {
 	})
 
 	t.Run("getting inexistent cliente by Email", func(t *testing.T) {
-		c, err := service.GetClienteByEmail("hello@email.com")
+		c, err := service.GetClienteByEmail(context.Background(), "hello@email.com")
 		if !errors.Is(err, entityErr.ErrNotFound) {
 			t.Errorf("should have not return any error, got: %s", err)
 		}
@@ -264,7 +759,7 @@ func TestService(t *testing.T) {
 	})
 
 	t.Run("getting existent cliente by Email", func(t *testing.T) {
-		c, err := service.GetClienteByEmail(existentClientEmail)
+		c, err := service.GetClienteByEmail(context.Background(), existentClientEmail)
 		if err != nil {
 			t.Errorf("should have found cliente, got error: %s", err)
 		}
@@ -274,8 +769,8 @@ func TestService(t *testing.T) {
 	})
 
 	t.Run("updating non existing cliente", func(t *testing.T) {
-		c, _ := entities.New(uuid.Nil, "Fulano", "84738941021", existentClientEmail, true)
-		if err := service.Update(*c); err != nil {
+		c, _ := entities.New(uuid.Nil, "Fulano", "84738941038", existentClientEmail, true)
+		if _, err := service.Update(context.Background(), *c); err != nil {
 			if !errors.Is(err, entityErr.ErrNotFound) {
 				t.Error("should not have found cliente")
 			}
@@ -286,15 +781,78 @@ func TestService(t *testing.T) {
 
 	t.Run("updating existing cliente", func(t *testing.T) {
 		cUuid, _ := entities.StringToID(existentClientID)
-		c, _ := service.GetClienteById(cUuid)
+		c, _ := service.GetClienteById(context.Background(), cUuid)
 		c2, _ := entities.New(c.Id(), c.Name(), c.CPF(), "outro2@email.com", c.Active())
-		if err := service.Update(*c2); err != nil {
-			t.Errorf("should have not return errors, got: %s", err)
+		updated, err := service.Update(context.Background(), *c2)
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if updated.Version() != c.Version()+1 {
+			t.Errorf("should have bumped version, want: %d, got: %d", c.Version()+1, updated.Version())
+		}
+	})
+
+	t.Run("updating cliente with stale version", func(t *testing.T) {
+		cUuid, _ := entities.StringToID(existentClientID)
+		c, _ := service.GetClienteById(context.Background(), cUuid)
+		c2, _ := entities.New(c.Id(), c.Name(), c.CPF(), c.Email(), c.Active(), entities.WithVersion(c.Version()+5))
+		if _, err := service.Update(context.Background(), *c2); !errors.Is(err, entityErr.ErrConcurrentModification) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrConcurrentModification, err)
+		}
+	})
+
+	t.Run("patching existing cliente", func(t *testing.T) {
+		cUuid, _ := entities.StringToID(existentClientID)
+		name := "Fulano de Tal"
+		c, err := service.Patch(context.Background(), cUuid, entities.ClientePatch{Name: &name})
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if c.Name() != name {
+			t.Errorf("should have patched name, want: %s, got: %s", name, c.Name())
+		}
+		if c.CPF() != existentClientCPF {
+			t.Errorf("should have kept cpf, want: %s, got: %s", existentClientCPF, c.CPF())
+		}
+	})
+
+	t.Run("patching cliente with stale version", func(t *testing.T) {
+		cUuid, _ := entities.StringToID(existentClientID)
+		c, _ := service.GetClienteById(context.Background(), cUuid)
+		name := "Fulano Stale"
+		_, err := service.Patch(context.Background(), cUuid, entities.ClientePatch{Name: &name, ExpectedVersion: c.Version() + 1})
+		if !errors.Is(err, entityErr.ErrConcurrentModification) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrConcurrentModification, err)
+		}
+	})
+
+	t.Run("patching cliente with existent Email", func(t *testing.T) {
+		cUuid, _ := entities.StringToID(existentClientID)
+		email := "outro@email.com"
+		_, err := service.Patch(context.Background(), cUuid, entities.ClientePatch{Email: &email})
+		if !errors.Is(err, entityErr.ErrClienteAlreadyExistsForEmail) {
+			t.Errorf("want: %s, got: %s", entityErr.ErrClienteAlreadyExistsForEmail, err)
+		}
+	})
+
+	t.Run("patching cliente with invalid CPF", func(t *testing.T) {
+		cUuid, _ := entities.StringToID(existentClientID)
+		cpf := "12345678900"
+		_, err := service.Patch(context.Background(), cUuid, entities.ClientePatch{CPF: &cpf})
+		if !errors.Is(err, entityErr.ErrInvalidCPF) {
+			t.Errorf("want: %s, got: %s", entityErr.ErrInvalidCPF, err)
+		}
+	})
+
+	t.Run("patching non existing cliente", func(t *testing.T) {
+		_, err := service.Patch(context.Background(), entities.NewID(), entities.ClientePatch{})
+		if !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %s", entityErr.ErrNotFound, err)
 		}
 	})
 
 	t.Run("deleting non existing cliente", func(t *testing.T) {
-		if err := service.Remove(entities.NewID()); err != nil {
+		if err := service.Remove(context.Background(), entities.NewID()); err != nil {
 			if !errors.Is(err, entityErr.ErrNotFound) {
 				t.Error("should not have found cliente")
 			}
@@ -305,15 +863,73 @@ func TestService(t *testing.T) {
 
 	t.Run("deleting existing cliente", func(t *testing.T) {
 		cUuid, _ := entities.StringToID(existentClientID)
-		if err := service.Remove(cUuid); err != nil {
+		if err := service.Remove(context.Background(), cUuid); err != nil {
 			t.Errorf("should have not return errors, got: %s", err)
 		}
+
+		c, err := service.GetClienteById(context.Background(), cUuid)
+		if err != nil {
+			t.Fatalf("should have kept the cliente, got: %s", err)
+		}
+		if c.Active() || c.DeletedAt().IsZero() {
+			t.Errorf("should have deactivated the cliente, got active: %t, deleted at: %s", c.Active(), c.DeletedAt())
+		}
+	})
+
+	t.Run("reactivating deleted cliente", func(t *testing.T) {
+		cUuid, _ := entities.StringToID(existentClientID)
+		c, err := service.Reactivate(context.Background(), cUuid)
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if !c.Active() || !c.DeletedAt().IsZero() {
+			t.Errorf("should have reactivated the cliente, got active: %t, deleted at: %s", c.Active(), c.DeletedAt())
+		}
+	})
+
+	t.Run("anonymizing cliente", func(t *testing.T) {
+		c, _ := entities.New(entities.NewID(), "Fulano Anonimo", "44477700083", "anonimo@email.com", true)
+		id, err := service.Create(context.Background(), *c)
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+
+		anonymous, err := service.Anonymize(context.Background(), id, "lgpd request")
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if !anonymous.Anonymized() || anonymous.CPF() == c.CPF() {
+			t.Errorf("should have anonymized cliente, got: %+v", *anonymous)
+		}
+
+		name := "Fulano De Novo"
+		if _, err := service.Patch(context.Background(), id, entities.ClientePatch{Name: &name}); !errors.Is(err, entityErr.ErrClienteAnonymized) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrClienteAnonymized, err)
+		}
+		if _, err := service.Anonymize(context.Background(), id, "lgpd request"); !errors.Is(err, entityErr.ErrClienteAnonymized) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrClienteAnonymized, err)
+		}
+	})
+
+	t.Run("purging cliente", func(t *testing.T) {
+		c, _ := entities.New(entities.NewID(), "Fulano Purge", "33366699957", "purge@email.com", true)
+		id, err := service.Create(context.Background(), *c)
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+
+		if err := service.Purge(context.Background(), id); err != nil {
+			t.Errorf("should have not return errors, got: %s", err)
+		}
+		if _, err := service.GetClienteById(context.Background(), id); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
 	})
 
 	t.Run("error creating cliente", func(t *testing.T) {
 		errCuui, _ := entities.StringToID(clienteIdError)
 		c, _ := entities.New(errCuui, "Fulano", clienteCpfError, clienteEmailError, true)
-		_, err := service.Create(*c)
+		_, err := service.Create(context.Background(), *c)
 		if err == nil {
 			t.Errorf("should have return error")
 		}
@@ -322,7 +938,7 @@ func TestService(t *testing.T) {
 	t.Run("error listing cliente", func(t *testing.T) {
 		flagListClienteError = true
 
-		if _, err := service.List(); err == nil {
+		if _, err := service.List(context.Background(), entities.ListOptions{}); err == nil {
 			t.Errorf("should have return error")
 		}
 
@@ -331,19 +947,19 @@ func TestService(t *testing.T) {
 
 	t.Run("error getting cliente by id", func(t *testing.T) {
 		cUuid, _ := entities.StringToID(clienteIdError)
-		if _, err := service.GetClienteById(cUuid); err == nil {
+		if _, err := service.GetClienteById(context.Background(), cUuid); err == nil {
 			t.Errorf("should have return error")
 		}
 	})
 
 	t.Run("error getting cliente by cpf", func(t *testing.T) {
-		if _, err := service.GetClienteByCPF(clienteCpfError); err == nil {
+		if _, err := service.GetClienteByCPF(context.Background(), clienteCpfError); err == nil {
 			t.Errorf("should have return error")
 		}
 	})
 
 	t.Run("error getting cliente by email", func(t *testing.T) {
-		if _, err := service.GetClienteByEmail(clienteEmailError); err == nil {
+		if _, err := service.GetClienteByEmail(context.Background(), clienteEmailError); err == nil {
 			t.Errorf("should have return error")
 		}
 	})
@@ -351,14 +967,14 @@ func TestService(t *testing.T) {
 	t.Run("error updating cliente", func(t *testing.T) {
 		cUuid, _ := entities.StringToID(clienteIdError)
 		c, _ := entities.New(cUuid, "Fulano", clienteCpfError, clienteEmailError, true)
-		if err := service.Update(*c); err == nil {
+		if _, err := service.Update(context.Background(), *c); err == nil {
 			t.Errorf("should have return error")
 		}
 	})
 
 	t.Run("error removing cliente", func(t *testing.T) {
 		cUuid, _ := entities.StringToID(clienteIdError)
-		if err := service.Remove(cUuid); err == nil {
+		if err := service.Remove(context.Background(), cUuid); err == nil {
 			t.Errorf("should have return error")
 		}
 	})
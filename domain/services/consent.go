@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+)
+
+type ConsentService struct {
+	clientes ports.Repository
+	consents ports.ConsentRepository
+}
+
+func NewConsentService(clientes ports.Repository, consents ports.ConsentRepository) *ConsentService {
+	return &ConsentService{clientes: clientes, consents: consents}
+}
+
+// Record appends a grant or revocation and returns the resulting state of its
+// purpose. Anonymized clientes can only revoke.
+func (s *ConsentService) Record(ctx context.Context, record entities.ConsentRecord) (entities.Consent, error) {
+	if err := record.Validate(); err != nil {
+		return entities.Consent{}, err
+	}
+
+	c, err := s.clientes.GetClienteById(ctx, record.ClienteID)
+	if err != nil {
+		return entities.Consent{}, err
+	}
+	if record.Granted && c.Anonymized() {
+		return entities.Consent{}, entityErr.ErrClienteAnonymized
+	}
+
+	if _, err := s.consents.AppendConsent(ctx, record); err != nil {
+		return entities.Consent{}, err
+	}
+
+	consents, err := s.Consents(ctx, record.ClienteID)
+	if err != nil {
+		return entities.Consent{}, err
+	}
+	for _, consent := range consents {
+		if consent.Purpose == record.Purpose {
+			return consent, nil
+		}
+	}
+
+	return entities.Consent{}, entityErr.ErrNotFound
+}
+
+func (s *ConsentService) Consents(ctx context.Context, clienteID entities.ID) ([]entities.Consent, error) {
+	history, err := s.History(ctx, clienteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.CurrentConsents(history), nil
+}
+
+func (s *ConsentService) History(ctx context.Context, clienteID entities.ID) ([]entities.ConsentRecord, error) {
+	if _, err := s.clientes.GetClienteById(ctx, clienteID); err != nil {
+		return nil, err
+	}
+
+	history, err := s.consents.ConsentHistory(ctx, clienteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// ExportSection adds the consent history to the cliente export, see
+// WithExportSources.
+func (s *ConsentService) ExportSection(ctx context.Context, clienteID entities.ID) (entities.ExportSection, error) {
+	history, err := s.consents.ConsentHistory(ctx, clienteID)
+	if err != nil {
+		return entities.ExportSection{}, err
+	}
+
+	return entities.ConsentSection(history), nil
+}
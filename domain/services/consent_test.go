@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+type ConsentRepositoryMock struct {
+	History map[entities.ID][]entities.ConsentRecord
+}
+
+func (c *ConsentRepositoryMock) AppendConsent(ctx context.Context, record entities.ConsentRecord) (entities.ConsentRecord, error) {
+	record.RecordedAt = time.Now()
+	c.History[record.ClienteID] = append(c.History[record.ClienteID], record)
+	return record, nil
+}
+
+func (c *ConsentRepositoryMock) ConsentHistory(ctx context.Context, clienteID entities.ID) ([]entities.ConsentRecord, error) {
+	return c.History[clienteID], nil
+}
+
+func TestConsentService(t *testing.T) {
+	clientes := ClienteRepositoryMock{Base: make(map[entities.ID]*entities.Cliente)}
+	c, _ := entities.New(entities.NewID(), "Fulano", "66699922203", "consent@email.com", true)
+	clientes.Base[c.Id()] = c
+	c2, _ := entities.New(entities.NewID(), "Ciclano", "52998224725", "anonimo@email.com", true)
+	anonymous, _ := c2.Anonymize(time.Now(), "lgpd")
+	clientes.Base[anonymous.Id()] = anonymous
+
+	consents := ConsentRepositoryMock{History: make(map[entities.ID][]entities.ConsentRecord)}
+	service := NewConsentService(&clientes, &consents)
+	ctx := context.Background()
+
+	t.Run("granting and revoking consent", func(t *testing.T) {
+		granted, err := service.Record(ctx, entities.ConsentRecord{
+			ClienteID: c.Id(), Purpose: entities.PurposeMarketingEmail, Granted: true, Channel: entities.ChannelTotem, PolicyVersion: "v1",
+		})
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if !granted.Granted || granted.GrantedAt.IsZero() {
+			t.Errorf("should have granted consent, got: %+v", granted)
+		}
+
+		revoked, err := service.Record(ctx, entities.ConsentRecord{
+			ClienteID: c.Id(), Purpose: entities.PurposeMarketingEmail, Granted: false, Channel: entities.ChannelApp,
+		})
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if revoked.Granted || revoked.RevokedAt.IsZero() || !revoked.GrantedAt.Equal(granted.GrantedAt) {
+			t.Errorf("should have revoked consent keeping when it was granted, got: %+v", revoked)
+		}
+
+		history, err := service.History(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if len(history) != 2 {
+			t.Errorf("should have kept both records, got: %d", len(history))
+		}
+	})
+
+	t.Run("invalid consent", func(t *testing.T) {
+		_, err := service.Record(ctx, entities.ConsentRecord{ClienteID: c.Id(), Purpose: "telepathy", Channel: entities.ChannelApp})
+		if !errors.Is(err, entityErr.ErrInvalidConsentPurpose) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrInvalidConsentPurpose, err)
+		}
+	})
+
+	t.Run("consents of inexistent cliente", func(t *testing.T) {
+		if _, err := service.Consents(ctx, entities.NewID()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+		_, err := service.Record(ctx, entities.ConsentRecord{ClienteID: entities.NewID(), Purpose: entities.PurposeSMS, Channel: entities.ChannelApp})
+		if !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrNotFound, err)
+		}
+	})
+
+	t.Run("anonymized cliente can only revoke", func(t *testing.T) {
+		_, err := service.Record(ctx, entities.ConsentRecord{
+			ClienteID: anonymous.Id(), Purpose: entities.PurposeSMS, Granted: true, Channel: entities.ChannelApp, PolicyVersion: "v1",
+		})
+		if !errors.Is(err, entityErr.ErrClienteAnonymized) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrClienteAnonymized, err)
+		}
+
+		if _, err := service.Record(ctx, entities.ConsentRecord{
+			ClienteID: anonymous.Id(), Purpose: entities.PurposeSMS, Granted: false, Channel: entities.ChannelBackOffice,
+		}); err != nil {
+			t.Errorf("should have not return errors, got: %s", err)
+		}
+	})
+
+	t.Run("exporting consents", func(t *testing.T) {
+		section, err := service.ExportSection(ctx, c.Id())
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if section.Name != "consents" || len(section.Rows) != 2 {
+			t.Errorf("should have exported the consent history, got: %+v", section)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+)
+
+const (
+	defaultIdempotencyTTL   = 24 * time.Hour
+	defaultIdempotencyLease = time.Minute
+	idempotencyAttempts     = 3
+	idempotencyRetryDelay   = 50 * time.Millisecond
+)
+
+// Idempotency lets clients retry a request without repeating its effect: the
+// response to the first request made with a key is replayed to the others
+// until it expires. Keys are scoped by the subject of the principal in the
+// context, so callers picking the same key do not collide.
+type Idempotency struct {
+	store  ports.IdempotencyStore
+	logger *slog.Logger
+	ttl    time.Duration
+	lease  time.Duration
+	now    func() time.Time
+}
+
+type IdempotencyOption func(*Idempotency)
+
+// WithIdempotencyTTL sets for how long a response is replayed.
+func WithIdempotencyTTL(d time.Duration) IdempotencyOption {
+	return func(i *Idempotency) {
+		i.ttl = d
+	}
+}
+
+// WithIdempotencyLease sets for how long a request in progress holds its key,
+// it must outlast the requests. Past it the request is assumed lost and a
+// retry takes the key over.
+func WithIdempotencyLease(d time.Duration) IdempotencyOption {
+	return func(i *Idempotency) {
+		i.lease = d
+	}
+}
+
+func NewIdempotency(store ports.IdempotencyStore, logger *slog.Logger, opts ...IdempotencyOption) *Idempotency {
+	i := &Idempotency{
+		store:  store,
+		logger: logger,
+		ttl:    defaultIdempotencyTTL,
+		lease:  defaultIdempotencyLease,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i
+}
+
+// Begin reserves the key for the request. It returns nil when the request
+// must be handled, then finished with Complete or Abandon, and the record to
+// replay when it is a retry of a completed request.
+func (i *Idempotency) Begin(ctx context.Context, key, requestHash string) (*entities.IdempotencyRecord, error) {
+	if err := entities.ValidateIdempotencyKey(key); err != nil {
+		return nil, err
+	}
+
+	now := i.now().UTC()
+	stored, reserved, err := i.store.ReserveKey(ctx, entities.IdempotencyRecord{
+		Subject:     principalSubject(ctx),
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(i.ttl),
+		LockedUntil: now.Add(i.lease),
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case reserved:
+		return nil, nil
+	case stored.RequestHash != requestHash:
+		return nil, entityErr.ErrIdempotencyKeyReused
+	case !stored.Completed():
+		return nil, entityErr.ErrIdempotencyKeyInProgress
+	}
+
+	return stored, nil
+}
+
+// Complete stores the response of a request begun with Begin, trying a few
+// times. When it still fails the request must not be abandoned, it had its
+// effect: the key stays in progress until its lease expires.
+func (i *Idempotency) Complete(ctx context.Context, rec entities.IdempotencyRecord) error {
+	rec.Subject = principalSubject(ctx)
+
+	var err error
+	for attempt := range idempotencyAttempts {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * idempotencyRetryDelay):
+			}
+		}
+
+		err = i.store.CompleteKey(ctx, rec)
+		// a key not found was taken over, there is nothing to complete
+		if err == nil || errors.Is(err, entityErr.ErrNotFound) {
+			return err
+		}
+	}
+	i.logger.Error("storing idempotent response", "key", rec.Key, "error", err)
+
+	return err
+}
+
+// Abandon frees the key of a request begun with Begin that had no effect, so
+// it can be retried.
+func (i *Idempotency) Abandon(ctx context.Context, key string) error {
+	return i.store.ReleaseKey(ctx, principalSubject(ctx), key)
+}
+
+// principalSubject is the subject of the principal in ctx, none when unauthenticated.
+func principalSubject(ctx context.Context) string {
+	p, _ := entities.PrincipalFrom(ctx)
+	return p.Subject
+}
+
+// Run deletes the expired keys every hour until ctx is done.
+func (i *Idempotency) Run(ctx context.Context) {
+	for {
+		if err := i.store.DeleteExpiredKeys(ctx, i.now().UTC()); err != nil {
+			i.logger.Error("deleting expired idempotency keys", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Hour):
+		}
+	}
+}
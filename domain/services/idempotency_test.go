@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+// idempotencyID is the subject and the key a record is stored by.
+type idempotencyID struct {
+	Subject string
+	Key     string
+}
+
+type IdempotencyStoreMock struct {
+	Keys map[idempotencyID]entities.IdempotencyRecord
+	// CompleteFailures is how many of the next CompleteKey calls fail
+	CompleteFailures int
+}
+
+func (i *IdempotencyStoreMock) ReserveKey(ctx context.Context, rec entities.IdempotencyRecord) (*entities.IdempotencyRecord, bool, error) {
+	stored, ok := i.Keys[idempotencyID{rec.Subject, rec.Key}]
+	if ok && stored.ExpiresAt.After(rec.CreatedAt) && (stored.Completed() || stored.LockedUntil.After(rec.CreatedAt)) {
+		return &stored, false, nil
+	}
+	i.Keys[idempotencyID{rec.Subject, rec.Key}] = rec
+	return &rec, true, nil
+}
+
+func (i *IdempotencyStoreMock) CompleteKey(ctx context.Context, rec entities.IdempotencyRecord) error {
+	if i.CompleteFailures > 0 {
+		i.CompleteFailures--
+		return errors.New("database unavailable")
+	}
+	stored, ok := i.Keys[idempotencyID{rec.Subject, rec.Key}]
+	if !ok {
+		return entityErr.ErrNotFound
+	}
+	stored.StatusCode, stored.Header, stored.Body = rec.StatusCode, rec.Header, rec.Body
+	i.Keys[idempotencyID{rec.Subject, rec.Key}] = stored
+	return nil
+}
+
+func (i *IdempotencyStoreMock) ReleaseKey(ctx context.Context, subject, key string) error {
+	delete(i.Keys, idempotencyID{subject, key})
+	return nil
+}
+
+func (i *IdempotencyStoreMock) DeleteExpiredKeys(ctx context.Context, now time.Time) error {
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	store := &IdempotencyStoreMock{Keys: make(map[idempotencyID]entities.IdempotencyRecord)}
+	service := NewIdempotency(store, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithIdempotencyTTL(time.Minute), WithIdempotencyLease(10*time.Second))
+	ctx := context.Background()
+
+	t.Run("first request reserves the key", func(t *testing.T) {
+		rec, err := service.Begin(ctx, "key-1", "hash-1")
+		if err != nil || rec != nil {
+			t.Fatalf("should have reserved the key, got: %+v, %v", rec, err)
+		}
+		if stored := store.Keys[idempotencyID{Key: "key-1"}]; stored.ExpiresAt.Sub(stored.CreatedAt) != time.Minute {
+			t.Errorf("should expire after the ttl, got: %+v", stored)
+		}
+		if stored := store.Keys[idempotencyID{Key: "key-1"}]; stored.LockedUntil.Sub(stored.CreatedAt) != 10*time.Second {
+			t.Errorf("should lock the key for the lease, got: %+v", stored)
+		}
+	})
+
+	t.Run("retry in progress", func(t *testing.T) {
+		if _, err := service.Begin(ctx, "key-1", "hash-1"); !errors.Is(err, entityErr.ErrIdempotencyKeyInProgress) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrIdempotencyKeyInProgress, err)
+		}
+	})
+
+	t.Run("retry of completed request", func(t *testing.T) {
+		if err := service.Complete(ctx, entities.IdempotencyRecord{Key: "key-1", StatusCode: 201, Body: []byte("1")}); err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+
+		rec, err := service.Begin(ctx, "key-1", "hash-1")
+		if err != nil || rec == nil || rec.StatusCode != 201 || string(rec.Body) != "1" {
+			t.Errorf("should return the response to replay, got: %+v, %v", rec, err)
+		}
+	})
+
+	t.Run("key reused for another request", func(t *testing.T) {
+		if _, err := service.Begin(ctx, "key-1", "hash-2"); !errors.Is(err, entityErr.ErrIdempotencyKeyReused) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrIdempotencyKeyReused, err)
+		}
+	})
+
+	t.Run("abandoned key", func(t *testing.T) {
+		_, _ = service.Begin(ctx, "key-2", "hash-1")
+		if err := service.Abandon(ctx, "key-2"); err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if rec, err := service.Begin(ctx, "key-2", "hash-2"); err != nil || rec != nil {
+			t.Errorf("should reserve the key again, got: %+v, %v", rec, err)
+		}
+	})
+
+	t.Run("lost request", func(t *testing.T) {
+		_, _ = service.Begin(ctx, "key-3", "hash-1")
+
+		now := time.Now()
+		service.now = func() time.Time { return now.Add(11 * time.Second) }
+		t.Cleanup(func() { service.now = time.Now })
+
+		if rec, err := service.Begin(ctx, "key-3", "hash-1"); err != nil || rec != nil {
+			t.Errorf("should take over the key once the lease expired, got: %+v, %v", rec, err)
+		}
+	})
+
+	t.Run("complete is retried", func(t *testing.T) {
+		_, _ = service.Begin(ctx, "key-5", "hash-1")
+		store.CompleteFailures = 2
+
+		if err := service.Complete(ctx, entities.IdempotencyRecord{Key: "key-5", StatusCode: 201}); err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if rec, err := service.Begin(ctx, "key-5", "hash-1"); err != nil || rec == nil {
+			t.Errorf("should return the response to replay, got: %+v, %v", rec, err)
+		}
+	})
+
+	t.Run("failed complete keeps the key", func(t *testing.T) {
+		_, _ = service.Begin(ctx, "key-6", "hash-1")
+		store.CompleteFailures = 3
+		t.Cleanup(func() { store.CompleteFailures = 0 })
+
+		if err := service.Complete(ctx, entities.IdempotencyRecord{Key: "key-6", StatusCode: 201}); err == nil {
+			t.Fatal("should have return error")
+		}
+		if _, err := service.Begin(ctx, "key-6", "hash-1"); !errors.Is(err, entityErr.ErrIdempotencyKeyInProgress) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrIdempotencyKeyInProgress, err)
+		}
+	})
+
+	t.Run("keys of two principals", func(t *testing.T) {
+		fulano := entities.WithPrincipal(ctx, entities.Principal{Subject: "fulano"})
+		ciclano := entities.WithPrincipal(ctx, entities.Principal{Subject: "ciclano"})
+
+		if rec, err := service.Begin(fulano, "key-4", "hash-1"); err != nil || rec != nil {
+			t.Fatalf("should have reserved the key, got: %+v, %v", rec, err)
+		}
+		if rec, err := service.Begin(ciclano, "key-4", "hash-2"); err != nil || rec != nil {
+			t.Fatalf("should have reserved the same key for the other principal, got: %+v, %v", rec, err)
+		}
+
+		if err := service.Complete(ciclano, entities.IdempotencyRecord{Key: "key-4", StatusCode: 201, Body: []byte("2")}); err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if err := service.Abandon(fulano, "key-4"); err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+
+		rec, err := service.Begin(ciclano, "key-4", "hash-2")
+		if err != nil || rec == nil || string(rec.Body) != "2" {
+			t.Errorf("should replay the response of the principal's own request, got: %+v, %v", rec, err)
+		}
+		if rec, err := service.Begin(fulano, "key-4", "hash-1"); err != nil || rec != nil {
+			t.Errorf("should reserve the abandoned key again, got: %+v, %v", rec, err)
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		if _, err := service.Begin(ctx, "key\n", "hash-1"); !errors.Is(err, entityErr.ErrInvalidIdempotencyKey) {
+			t.Errorf("want: %s, got: %v", entityErr.ErrInvalidIdempotencyKey, err)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+)
+
+const (
+	defaultRelayInterval  = time.Second
+	defaultRelayBatch     = 100
+	defaultRelayRetention = 7 * 24 * time.Hour
+	relayPruneInterval    = time.Hour
+)
+
+// Relay publishes the events of the outbox to one sink, oldest first. An
+// event is only marked published once the publisher took it, so it is
+// delivered at least once: a crash in between publishes it again. Every
+// replica runs one per sink, the outbox claims hand each of them different
+// events, and a sink failing never holds back the others.
+type Relay struct {
+	outbox    ports.Outbox
+	sink      entities.EventSink
+	publisher ports.EventPublisher
+	logger    *slog.Logger
+	interval  time.Duration
+	batch     int
+	retention time.Duration
+	now       func() time.Time
+}
+
+type RelayOption func(*Relay)
+
+// WithRelayInterval sets how long the relay waits when the outbox is empty
+// or publishing failed.
+func WithRelayInterval(d time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.interval = d
+	}
+}
+
+// WithRelayBatch sets how many events are read from the outbox at once.
+func WithRelayBatch(n int) RelayOption {
+	return func(r *Relay) {
+		r.batch = n
+	}
+}
+
+// WithRelayRetention sets for how long the published events are kept.
+func WithRelayRetention(d time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.retention = d
+	}
+}
+
+func NewRelay(outbox ports.Outbox, sink entities.EventSink, publisher ports.EventPublisher, logger *slog.Logger, opts ...RelayOption) *Relay {
+	r := &Relay{
+		outbox:    outbox,
+		sink:      sink,
+		publisher: publisher,
+		logger:    logger,
+		interval:  defaultRelayInterval,
+		batch:     defaultRelayBatch,
+		retention: defaultRelayRetention,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Run flushes the outbox until ctx is done, pruning it every hour.
+func (r *Relay) Run(ctx context.Context) {
+	var pruned time.Time
+	for {
+		if now := r.now(); now.Sub(pruned) >= relayPruneInterval {
+			if err := r.Prune(ctx); err != nil {
+				r.logger.Error("pruning outbox", "error", err)
+			}
+			pruned = now
+		}
+
+		n, err := r.Flush(ctx)
+		if err != nil {
+			r.logger.Error("relaying outbox events", "sink", r.sink, "error", err)
+		}
+
+		// a full batch means there is probably more to publish
+		if err == nil && n == r.batch && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.interval):
+		}
+	}
+}
+
+// Flush publishes one batch of the events pending for the sink and returns
+// how many were published. It stops at the first failure, so events keep
+// their order.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	return r.outbox.PublishPending(ctx, r.sink, r.batch, func(ctx context.Context, event entities.Event) error {
+		if err := r.publisher.Publish(ctx, event); err != nil {
+			return fmt.Errorf("publishing %s event %s: %w", event.Type, event.ID, err)
+		}
+		return nil
+	})
+}
+
+// Prune deletes the events published to every sink that occurred longer
+// than the retention ago.
+func (r *Relay) Prune(ctx context.Context) error {
+	n, err := r.outbox.DeletePublished(ctx, r.now().UTC().Add(-r.retention))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.logger.Info("pruned outbox", "events", n)
+	}
+
+	return nil
+}
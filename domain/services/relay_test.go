@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+type OutboxMock struct {
+	mu     sync.Mutex
+	Events []entities.Event
+	// PrunedBefore is the time given to the last DeletePublished
+	PrunedBefore time.Time
+	// Sink is the sink given to the last PublishPending
+	Sink entities.EventSink
+}
+
+func (o *OutboxMock) PublishPending(ctx context.Context, sink entities.EventSink, limit int, publish func(ctx context.Context, event entities.Event) error) (int, error) {
+	o.mu.Lock()
+	o.Sink = sink
+	events := slices.Clone(o.Events[:min(limit, len(o.Events))])
+	o.mu.Unlock()
+
+	for i, event := range events {
+		if err := publish(ctx, event); err != nil {
+			return i, err
+		}
+		o.mu.Lock()
+		o.Events = slices.DeleteFunc(o.Events, func(e entities.Event) bool { return e.ID == event.ID })
+		o.mu.Unlock()
+	}
+	return len(events), nil
+}
+
+func (o *OutboxMock) DeletePublished(ctx context.Context, before time.Time) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.PrunedBefore = before
+	return 0, nil
+}
+
+type PublisherMock struct {
+	mu        sync.Mutex
+	Published []entities.Event
+	FailOn    entities.ID
+}
+
+func (p *PublisherMock) Publish(ctx context.Context, event entities.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if event.ID == p.FailOn {
+		return errors.New("broker unavailable")
+	}
+	p.Published = append(p.Published, event)
+	return nil
+}
+
+func newEvents(n int) []entities.Event {
+	events := make([]entities.Event, n)
+	for i := range events {
+		events[i] = entities.Event{ID: entities.NewID(), Type: entities.ClienteCreated, ClienteID: entities.NewID()}
+	}
+	return events
+}
+
+func eventIDs(events []entities.Event) []entities.ID {
+	ids := make([]entities.ID, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestRelay(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("flush publishes in order", func(t *testing.T) {
+		events := newEvents(3)
+		outbox := &OutboxMock{Events: slices.Clone(events)}
+		publisher := &PublisherMock{}
+		relay := NewRelay(outbox, entities.SinkBroker, publisher, logger, WithRelayBatch(2))
+
+		n, err := relay.Flush(context.Background())
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if n != 2 || !slices.Equal(eventIDs(publisher.Published), eventIDs(events[:2])) {
+			t.Errorf("should have published the first batch, got: %d", n)
+		}
+		if len(outbox.Events) != 1 {
+			t.Errorf("should have marked the batch published, pending: %d", len(outbox.Events))
+		}
+		if outbox.Sink != entities.SinkBroker {
+			t.Errorf("should have published to the %s sink, got: %s", entities.SinkBroker, outbox.Sink)
+		}
+	})
+
+	t.Run("flush stops at the first failure", func(t *testing.T) {
+		events := newEvents(3)
+		outbox := &OutboxMock{Events: slices.Clone(events)}
+		publisher := &PublisherMock{FailOn: events[1].ID}
+		relay := NewRelay(outbox, entities.SinkBroker, publisher, logger)
+
+		n, err := relay.Flush(context.Background())
+		if err == nil {
+			t.Fatal("should have return error")
+		}
+		if n != 1 || !slices.Equal(eventIDs(outbox.Events), eventIDs(events[1:])) {
+			t.Errorf("should keep the failed event and the ones after it pending, got: %d published", n)
+		}
+	})
+
+	t.Run("prune keeps the retention", func(t *testing.T) {
+		outbox := &OutboxMock{}
+		now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+		relay := NewRelay(outbox, entities.SinkBroker, &PublisherMock{}, logger, WithRelayRetention(48*time.Hour))
+		relay.now = func() time.Time { return now }
+
+		if err := relay.Prune(context.Background()); err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if want := now.Add(-48 * time.Hour); !outbox.PrunedBefore.Equal(want) {
+			t.Errorf("should have pruned the events published before %s, got: %s", want, outbox.PrunedBefore)
+		}
+	})
+
+	t.Run("run until cancelled", func(t *testing.T) {
+		events := newEvents(5)
+		outbox := &OutboxMock{Events: slices.Clone(events)}
+		publisher := &PublisherMock{}
+		relay := NewRelay(outbox, entities.SinkBroker, publisher, logger, WithRelayBatch(2), WithRelayInterval(time.Millisecond))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			relay.Run(ctx)
+			close(done)
+		}()
+
+		deadline := time.After(time.Second)
+		for {
+			publisher.mu.Lock()
+			published := len(publisher.Published)
+			publisher.mu.Unlock()
+			if published == len(events) {
+				break
+			}
+			select {
+			case <-deadline:
+				t.Fatalf("should have published every event, got: %d", published)
+			case <-time.After(time.Millisecond):
+			}
+		}
+
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("should have stopped once cancelled")
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+)
+
+const (
+	defaultVerificationTTL  = 15 * time.Minute
+	verificationCooldown    = time.Minute
+	maxVerificationAttempts = 5
+	verificationCodeDigits  = 6
+)
+
+// VerificationService proves the clientes own their e-mails: a one-time code
+// is sent to the address and typed back. Codes are kept hashed, expire and
+// are burnt after a few wrong attempts.
+type VerificationService struct {
+	clientes ports.Repository
+	codes    ports.VerificationStore
+	notifier ports.Notifier
+	secret   []byte
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+type VerificationOption func(*VerificationService)
+
+// WithVerificationSecret sets the key the codes are hashed with. A random one
+// is used by default, so the codes sent are lost on restart.
+func WithVerificationSecret(secret []byte) VerificationOption {
+	return func(s *VerificationService) {
+		s.secret = secret
+	}
+}
+
+// WithVerificationTTL sets for how long a code is valid.
+func WithVerificationTTL(d time.Duration) VerificationOption {
+	return func(s *VerificationService) {
+		s.ttl = d
+	}
+}
+
+func NewVerificationService(clientes ports.Repository, codes ports.VerificationStore, notifier ports.Notifier, opts ...VerificationOption) (*VerificationService, error) {
+	s := &VerificationService{
+		clientes: clientes,
+		codes:    codes,
+		notifier: notifier,
+		ttl:      defaultVerificationTTL,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if len(s.secret) == 0 {
+		s.secret = make([]byte, sha256.Size)
+		if _, err := rand.Read(s.secret); err != nil {
+			return nil, fmt.Errorf("generating verification secret: %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+// RequestEmailVerification sends a new code to the e-mail of the cliente,
+// replacing the last one. Codes are sent at most once a minute.
+func (s *VerificationService) RequestEmailVerification(ctx context.Context, id entities.ID) error {
+	c, err := s.clientes.GetClienteById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !c.Active() || c.Anonymized() {
+		return entityErr.ErrNotFound
+	}
+	if c.EmailVerified() {
+		return entityErr.ErrEmailAlreadyVerified
+	}
+
+	now := s.now().UTC()
+	last, err := s.codes.GetVerificationCode(ctx, id)
+	switch {
+	case err == nil && now.Before(last.CreatedAt.Add(verificationCooldown)):
+		return entityErr.ErrVerificationRequestedTooSoon
+	case err != nil && !errors.Is(err, entityErr.ErrNotFound):
+		return err
+	}
+
+	code, err := newVerificationCode()
+	if err != nil {
+		return fmt.Errorf("generating verification code: %w", err)
+	}
+
+	err = s.codes.SaveVerificationCode(ctx, entities.VerificationCode{
+		ClienteID: id,
+		Email:     c.Email(),
+		CodeHash:  s.hash(id, c.Email(), code),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.notifier.Notify(ctx, verificationNotification(c, code, s.ttl)); err != nil {
+		// the cliente never got it, so it may ask for another right away
+		_ = s.codes.DeleteVerificationCode(context.WithoutCancel(ctx), id)
+		return fmt.Errorf("sending verification code to cliente %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// ConfirmEmailVerification marks the e-mail of the cliente as verified when
+// code is the last one sent to it.
+func (s *VerificationService) ConfirmEmailVerification(ctx context.Context, id entities.ID, code string) (*entities.Cliente, error) {
+	stored, err := s.codes.GetVerificationCode(ctx, id)
+	if errors.Is(err, entityErr.ErrNotFound) {
+		return nil, entityErr.ErrVerificationCodeExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	if !now.Before(stored.ExpiresAt) {
+		return nil, entityErr.ErrVerificationCodeExpired
+	}
+
+	// counted before comparing, so concurrent guesses cannot get past the
+	// limit
+	attempts, err := s.codes.AddVerificationAttempt(ctx, id)
+	if errors.Is(err, entityErr.ErrNotFound) {
+		return nil, entityErr.ErrVerificationCodeExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	match := attempts <= maxVerificationAttempts &&
+		hmac.Equal([]byte(s.hash(id, stored.Email, strings.TrimSpace(code))), []byte(stored.CodeHash))
+	if !match {
+		if attempts >= maxVerificationAttempts {
+			_ = s.codes.DeleteVerificationCode(ctx, id)
+			return nil, entityErr.ErrTooManyVerificationAttempts
+		}
+		return nil, entityErr.ErrInvalidVerificationCode
+	}
+
+	var c *entities.Cliente
+	err = s.clientes.WithinTx(ctx, func(repo ports.Repository) error {
+		var err error
+		if c, err = repo.VerifyEmail(ctx, id, stored.Email, now); err != nil {
+			return err
+		}
+		return raise(ctx, repo, entities.AuditUpdated, nil, c)
+	})
+	if errors.Is(err, entityErr.ErrConcurrentModification) {
+		// the e-mail changed after the code was sent
+		_ = s.codes.DeleteVerificationCode(ctx, id)
+		return nil, entityErr.ErrVerificationCodeExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// a code left behind would only verify the same e-mail again
+	_ = s.codes.DeleteVerificationCode(ctx, id)
+
+	return c, nil
+}
+
+// hash binds the code to the cliente and the e-mail it was sent to.
+func (s *VerificationService) hash(id entities.ID, email, code string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s", id, strings.ToLower(email), code)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newVerificationCode() (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(verificationCodeDigits), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", verificationCodeDigits, n), nil
+}
+
+func verificationNotification(c *entities.Cliente, code string, ttl time.Duration) entities.Notification {
+	return entities.Notification{
+		To:      c.Email(),
+		Subject: "Seu código de verificação PedeAí",
+		Body: fmt.Sprintf("Olá, %s!\n\n"+
+			"Use o código %s para confirmar seu e-mail. Ele vale por %d minutos.\n\n"+
+			"Se você não pediu este código, ignore esta mensagem.\n",
+			c.Name(), code, int(ttl.Minutes())),
+	}
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+type VerificationStoreMock struct {
+	Codes map[entities.ID]entities.VerificationCode
+}
+
+func (v *VerificationStoreMock) SaveVerificationCode(ctx context.Context, code entities.VerificationCode) error {
+	v.Codes[code.ClienteID] = code
+	return nil
+}
+
+func (v *VerificationStoreMock) GetVerificationCode(ctx context.Context, clienteID entities.ID) (*entities.VerificationCode, error) {
+	code, ok := v.Codes[clienteID]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	return &code, nil
+}
+
+func (v *VerificationStoreMock) AddVerificationAttempt(ctx context.Context, clienteID entities.ID) (int, error) {
+	code, ok := v.Codes[clienteID]
+	if !ok {
+		return 0, entityErr.ErrNotFound
+	}
+	code.Attempts++
+	v.Codes[clienteID] = code
+	return code.Attempts, nil
+}
+
+func (v *VerificationStoreMock) DeleteVerificationCode(ctx context.Context, clienteID entities.ID) error {
+	delete(v.Codes, clienteID)
+	return nil
+}
+
+type NotifierMock struct {
+	Sent []entities.Notification
+	Err  error
+}
+
+func (n *NotifierMock) Notify(ctx context.Context, notification entities.Notification) error {
+	if n.Err != nil {
+		return n.Err
+	}
+	n.Sent = append(n.Sent, notification)
+	return nil
+}
+
+var sentCode = regexp.MustCompile(`\b\d{6}\b`)
+
+// lastCode is the code of the last notification sent.
+func (n *NotifierMock) lastCode(t *testing.T) string {
+	t.Helper()
+
+	if len(n.Sent) == 0 {
+		t.Fatal("should have sent a code")
+	}
+	code := sentCode.FindString(n.Sent[len(n.Sent)-1].Body)
+	if code == "" {
+		t.Fatalf("should have sent a 6 digit code, got: %q", n.Sent[len(n.Sent)-1].Body)
+	}
+
+	return code
+}
+
+func TestVerificationService(t *testing.T) {
+	ctx := context.Background()
+	id, _ := entities.StringToID(existentClientID)
+
+	type fixture struct {
+		svc      *VerificationService
+		repo     *ClienteRepositoryMock
+		codes    *VerificationStoreMock
+		notifier *NotifierMock
+		now      time.Time
+	}
+	setup := func(t *testing.T) *fixture {
+		t.Helper()
+
+		c, _ := entities.New(id, "Fulano", existentClientCPF, existentClientEmail, true)
+		f := &fixture{
+			repo:     &ClienteRepositoryMock{Base: map[entities.ID]*entities.Cliente{id: c}},
+			codes:    &VerificationStoreMock{Codes: make(map[entities.ID]entities.VerificationCode)},
+			notifier: &NotifierMock{},
+			now:      time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		}
+		svc, err := NewVerificationService(f.repo, f.codes, f.notifier)
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		svc.now = func() time.Time { return f.now }
+		f.svc = svc
+
+		return f
+	}
+
+	t.Run("request and confirm", func(t *testing.T) {
+		f := setup(t)
+
+		if err := f.svc.RequestEmailVerification(ctx, id); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if f.notifier.Sent[0].To != existentClientEmail {
+			t.Errorf("should have sent the code to %s, got: %s", existentClientEmail, f.notifier.Sent[0].To)
+		}
+		code := f.notifier.lastCode(t)
+		if stored := f.codes.Codes[id]; stored.CodeHash == code || !stored.ExpiresAt.Equal(f.now.Add(15*time.Minute)) {
+			t.Errorf("should have kept the hash of the code for 15 minutes, got: %+v", stored)
+		}
+
+		c, err := f.svc.ConfirmEmailVerification(ctx, id, " "+code+" ")
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if !c.EmailVerified() || !c.EmailVerifiedAt().Equal(f.now) {
+			t.Errorf("should have verified the e-mail, got: %s", c.EmailVerifiedAt())
+		}
+		if len(f.repo.Events) != 1 || f.repo.Events[0].Type != entities.ClienteUpdated {
+			t.Errorf("should have raised a %s event, got: %+v", entities.ClienteUpdated, f.repo.Events)
+		}
+		if _, ok := f.codes.Codes[id]; ok {
+			t.Error("should have deleted the used code")
+		}
+
+		if err := f.svc.RequestEmailVerification(ctx, id); !errors.Is(err, entityErr.ErrEmailAlreadyVerified) {
+			t.Errorf("should have return ErrEmailAlreadyVerified, got: %v", err)
+		}
+	})
+
+	t.Run("request too soon", func(t *testing.T) {
+		f := setup(t)
+
+		if err := f.svc.RequestEmailVerification(ctx, id); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if err := f.svc.RequestEmailVerification(ctx, id); !errors.Is(err, entityErr.ErrVerificationRequestedTooSoon) {
+			t.Errorf("should have return ErrVerificationRequestedTooSoon, got: %v", err)
+		}
+
+		f.now = f.now.Add(time.Minute)
+		if err := f.svc.RequestEmailVerification(ctx, id); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		if len(f.notifier.Sent) != 2 {
+			t.Errorf("should have sent 2 codes, got: %d", len(f.notifier.Sent))
+		}
+	})
+
+	t.Run("request for missing or inactive cliente", func(t *testing.T) {
+		f := setup(t)
+
+		if err := f.svc.RequestEmailVerification(ctx, entities.NewID()); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("should have return ErrNotFound, got: %v", err)
+		}
+
+		if _, err := f.repo.Deactivate(ctx, id); err != nil {
+			t.Fatalf("deactivating cliente, got error: %s", err)
+		}
+		if err := f.svc.RequestEmailVerification(ctx, id); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("should have return ErrNotFound, got: %v", err)
+		}
+		if len(f.notifier.Sent) != 0 {
+			t.Errorf("should not have sent any code, got: %d", len(f.notifier.Sent))
+		}
+	})
+
+	t.Run("code not sent can be requested again", func(t *testing.T) {
+		f := setup(t)
+		f.notifier.Err = errors.New("smtp down")
+
+		if err := f.svc.RequestEmailVerification(ctx, id); !errors.Is(err, f.notifier.Err) {
+			t.Errorf("should have return the notifier error, got: %v", err)
+		}
+		if _, ok := f.codes.Codes[id]; ok {
+			t.Error("should have deleted the code not sent")
+		}
+
+		f.notifier.Err = nil
+		if err := f.svc.RequestEmailVerification(ctx, id); err != nil {
+			t.Errorf("should not have return any error, got: %s", err)
+		}
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		f := setup(t)
+
+		if err := f.svc.RequestEmailVerification(ctx, id); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		code := f.notifier.lastCode(t)
+		wrong := "000000"
+		if code == wrong {
+			wrong = "111111"
+		}
+
+		for range maxVerificationAttempts - 1 {
+			if _, err := f.svc.ConfirmEmailVerification(ctx, id, wrong); !errors.Is(err, entityErr.ErrInvalidVerificationCode) {
+				t.Fatalf("should have return ErrInvalidVerificationCode, got: %v", err)
+			}
+		}
+		if _, err := f.svc.ConfirmEmailVerification(ctx, id, wrong); !errors.Is(err, entityErr.ErrTooManyVerificationAttempts) {
+			t.Errorf("should have return ErrTooManyVerificationAttempts, got: %v", err)
+		}
+		// the code is burnt, even the right one no longer works
+		if _, err := f.svc.ConfirmEmailVerification(ctx, id, code); !errors.Is(err, entityErr.ErrVerificationCodeExpired) {
+			t.Errorf("should have return ErrVerificationCodeExpired, got: %v", err)
+		}
+	})
+
+	t.Run("expired code", func(t *testing.T) {
+		f := setup(t)
+
+		if err := f.svc.RequestEmailVerification(ctx, id); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		code := f.notifier.lastCode(t)
+
+		f.now = f.now.Add(15 * time.Minute)
+		if _, err := f.svc.ConfirmEmailVerification(ctx, id, code); !errors.Is(err, entityErr.ErrVerificationCodeExpired) {
+			t.Errorf("should have return ErrVerificationCodeExpired, got: %v", err)
+		}
+	})
+
+	t.Run("code never sent", func(t *testing.T) {
+		f := setup(t)
+
+		if _, err := f.svc.ConfirmEmailVerification(ctx, id, "123456"); !errors.Is(err, entityErr.ErrVerificationCodeExpired) {
+			t.Errorf("should have return ErrVerificationCodeExpired, got: %v", err)
+		}
+	})
+
+	t.Run("e-mail changed after the code was sent", func(t *testing.T) {
+		f := setup(t)
+
+		if err := f.svc.RequestEmailVerification(ctx, id); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		code := f.notifier.lastCode(t)
+
+		changed, _ := entities.New(id, "Fulano", existentClientCPF, "outro@email.com", true)
+		if _, err := f.repo.Update(ctx, *changed); err != nil {
+			t.Fatalf("updating cliente, got error: %s", err)
+		}
+
+		if _, err := f.svc.ConfirmEmailVerification(ctx, id, code); !errors.Is(err, entityErr.ErrVerificationCodeExpired) {
+			t.Errorf("should have return ErrVerificationCodeExpired, got: %v", err)
+		}
+		if f.repo.Base[id].EmailVerified() {
+			t.Error("should not have verified the new e-mail")
+		}
+	})
+
+	t.Run("codes are bound to the secret", func(t *testing.T) {
+		f := setup(t)
+
+		if err := f.svc.RequestEmailVerification(ctx, id); err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		code := f.notifier.lastCode(t)
+
+		other, err := NewVerificationService(f.repo, f.codes, f.notifier, WithVerificationSecret([]byte("another secret")))
+		if err != nil {
+			t.Fatalf("should not have return any error, got: %s", err)
+		}
+		other.now = f.svc.now
+		if _, err := other.ConfirmEmailVerification(ctx, id, code); !errors.Is(err, entityErr.ErrInvalidVerificationCode) {
+			t.Errorf("should have return ErrInvalidVerificationCode, got: %v", err)
+		}
+	})
+}
+
+func TestAuthorizedVerification(t *testing.T) {
+	id, _ := entities.StringToID(existentClientID)
+	c, _ := entities.New(id, "Fulano", existentClientCPF, existentClientEmail, true)
+	svc, err := NewVerificationService(
+		&ClienteRepositoryMock{Base: map[entities.ID]*entities.Cliente{id: c}},
+		&VerificationStoreMock{Codes: make(map[entities.ID]entities.VerificationCode)},
+		&NotifierMock{})
+	if err != nil {
+		t.Fatalf("should not have return any error, got: %s", err)
+	}
+	authorized := NewAuthorizedVerification(svc, entities.DefaultPolicy())
+
+	as := func(roles ...string) context.Context {
+		return entities.WithPrincipal(context.Background(), entities.Principal{Subject: "test", Roles: roles})
+	}
+
+	if err := authorized.RequestEmailVerification(as("cliente"), id); !errors.Is(err, entityErr.ErrForbidden) {
+		t.Errorf("should have return ErrForbidden, got: %v", err)
+	}
+	if err := authorized.RequestEmailVerification(as("totem"), id); err != nil {
+		t.Errorf("should not have return any error, got: %s", err)
+	}
+	if _, err := authorized.ConfirmEmailVerification(context.Background(), id, "123456"); !errors.Is(err, entityErr.ErrForbidden) {
+		t.Errorf("should have return ErrForbidden, got: %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
+)
+
+const (
+	defaultWebhookInterval  = 5 * time.Second
+	defaultWebhookBatch     = 50
+	defaultWebhookRetention = 30 * 24 * time.Hour
+	webhookPruneInterval    = time.Hour
+	// webhookClaimLease is for how long a batch of deliveries is claimed, it
+	// must outlast the attempts of the batch, one at a time.
+	webhookClaimLease = 15 * time.Minute
+)
+
+// WebhookService manages the webhook subscriptions and delivers the events to
+// them. It is an EventPublisher, the Relay hands it the events of the outbox
+// and Run sends them.
+type WebhookService struct {
+	repo      ports.WebhookRepository
+	sender    ports.WebhookSender
+	logger    *slog.Logger
+	interval  time.Duration
+	batch     int
+	retention time.Duration
+	now       func() time.Time
+}
+
+type WebhookOption func(*WebhookService)
+
+// WithWebhookInterval sets how often Run looks for due deliveries.
+func WithWebhookInterval(d time.Duration) WebhookOption {
+	return func(s *WebhookService) {
+		s.interval = d
+	}
+}
+
+// WithWebhookRetention sets for how long the delivered and dead deliveries
+// are kept.
+func WithWebhookRetention(d time.Duration) WebhookOption {
+	return func(s *WebhookService) {
+		s.retention = d
+	}
+}
+
+func NewWebhookService(repo ports.WebhookRepository, sender ports.WebhookSender, logger *slog.Logger, opts ...WebhookOption) *WebhookService {
+	s := &WebhookService{
+		repo:      repo,
+		sender:    sender,
+		logger:    logger,
+		interval:  defaultWebhookInterval,
+		batch:     defaultWebhookBatch,
+		retention: defaultWebhookRetention,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Subscribe registers the endpoint with a new secret, only returned here.
+func (s *WebhookService) Subscribe(ctx context.Context, sub entities.WebhookSubscription) (entities.WebhookSubscription, error) {
+	if err := sub.Validate(); err != nil {
+		return entities.WebhookSubscription{}, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return entities.WebhookSubscription{}, fmt.Errorf("generating webhook secret: %w", err)
+	}
+
+	sub.ID = entities.NewID()
+	sub.Secret = "whsec_" + hex.EncodeToString(secret)
+
+	return s.repo.CreateSubscription(ctx, sub)
+}
+
+func (s *WebhookService) Subscriptions(ctx context.Context) ([]entities.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(ctx)
+}
+
+func (s *WebhookService) Subscription(ctx context.Context, id entities.ID) (*entities.WebhookSubscription, error) {
+	return s.repo.GetSubscription(ctx, id)
+}
+
+func (s *WebhookService) Unsubscribe(ctx context.Context, id entities.ID) error {
+	return s.repo.DeleteSubscription(ctx, id)
+}
+
+// Deliveries is the delivery log of the subscription, the dead letters with
+// status DeliveryDead.
+func (s *WebhookService) Deliveries(ctx context.Context, id entities.ID, status entities.DeliveryStatus) ([]entities.WebhookDelivery, error) {
+	if _, err := s.repo.GetSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListDeliveries(ctx, id, status)
+}
+
+// Redeliver queues a delivery of the subscription again, with its attempts
+// reset.
+func (s *WebhookService) Redeliver(ctx context.Context, id, deliveryID entities.ID) (*entities.WebhookDelivery, error) {
+	d, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if d.SubscriptionID != id {
+		return nil, entityErr.ErrNotFound
+	}
+
+	attempts := d.Attempts
+	d.Redeliver(s.now().UTC())
+	if err := s.repo.UpdateDelivery(ctx, *d, attempts); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Publish queues the event for every subscription interested in it.
+func (s *WebhookService) Publish(ctx context.Context, event entities.Event) error {
+	subs, err := s.repo.ListSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if !sub.Matches(event.Type) {
+			continue
+		}
+
+		d, err := entities.NewWebhookDelivery(sub, event, s.now().UTC())
+		if err != nil {
+			return err
+		}
+		if err := s.repo.EnqueueDelivery(ctx, d); err != nil {
+			return fmt.Errorf("queueing %s event %s for webhook %s: %w", event.Type, event.ID, sub.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// Run sends the due deliveries until ctx is done, pruning the delivery log
+// every hour.
+func (s *WebhookService) Run(ctx context.Context) {
+	var pruned time.Time
+	for {
+		if now := s.now(); now.Sub(pruned) >= webhookPruneInterval {
+			if err := s.Prune(ctx); err != nil {
+				s.logger.Error("pruning webhook deliveries", "error", err)
+			}
+			pruned = now
+		}
+
+		n, err := s.Deliver(ctx)
+		if err != nil {
+			s.logger.Error("delivering webhooks", "error", err)
+		}
+
+		if err == nil && n == s.batch && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.interval):
+		}
+	}
+}
+
+// Deliver claims a batch of due deliveries, makes one attempt at each and
+// returns how many were claimed. An outcome is dropped when the delivery
+// changed in the meantime, redelivered or attempted by another replica after
+// the claim expired.
+func (s *WebhookService) Deliver(ctx context.Context) (int, error) {
+	now := s.now().UTC()
+	due, err := s.repo.ClaimDueDeliveries(ctx, now, now.Add(webhookClaimLease), s.batch)
+	if err != nil {
+		return 0, fmt.Errorf("claiming due deliveries: %w", err)
+	}
+
+	subs := make(map[entities.ID]*entities.WebhookSubscription)
+	for i, d := range due {
+		sub, ok := subs[d.SubscriptionID]
+		if !ok {
+			sub, err = s.repo.GetSubscription(ctx, d.SubscriptionID)
+			if errors.Is(err, entityErr.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return i, err
+			}
+			subs[d.SubscriptionID] = sub
+		}
+
+		attempts := d.Attempts
+		s.attempt(ctx, *sub, &d)
+		err := s.repo.UpdateDelivery(ctx, d, attempts)
+		if errors.Is(err, entityErr.ErrConcurrentModification) {
+			s.logger.Warn("webhook delivery changed while attempted", "subscription", sub.ID, "delivery", d.ID)
+			continue
+		}
+		if err != nil {
+			return i, fmt.Errorf("recording delivery %s: %w", d.ID, err)
+		}
+	}
+
+	return len(due), nil
+}
+
+// Prune deletes the delivered and dead deliveries created longer than the
+// retention ago.
+func (s *WebhookService) Prune(ctx context.Context) error {
+	n, err := s.repo.DeleteFinishedDeliveries(ctx, s.now().UTC().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		s.logger.Info("pruned webhook deliveries", "deliveries", n)
+	}
+
+	return nil
+}
+
+func (s *WebhookService) attempt(ctx context.Context, sub entities.WebhookSubscription, d *entities.WebhookDelivery) {
+	now := s.now().UTC()
+
+	status, err := s.sender.Send(ctx, sub.URL, entities.WebhookHeaders(sub.Secret, *d, now), d.Payload)
+	switch {
+	case err != nil:
+		d.Failed(now, status, err.Error())
+	case status < 200 || status > 299:
+		d.Failed(now, status, fmt.Sprintf("unexpected status %d", status))
+	default:
+		d.Delivered(now, status)
+	}
+
+	if d.Status == entities.DeliveryDead {
+		s.logger.Warn("webhook delivery is dead", "subscription", sub.ID, "delivery", d.ID, "error", d.LastError)
+	}
+}
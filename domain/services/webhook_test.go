@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	entityErr "github.com/filipeandrade6/fiap-pedeai-clientes/domain/errors"
+)
+
+type WebhookRepositoryMock struct {
+	Subs map[entities.ID]entities.WebhookSubscription
+	Sent map[entities.ID]entities.WebhookDelivery
+
+	PrunedBefore time.Time
+}
+
+func (w *WebhookRepositoryMock) CreateSubscription(ctx context.Context, sub entities.WebhookSubscription) (entities.WebhookSubscription, error) {
+	w.Subs[sub.ID] = sub
+	return sub, nil
+}
+
+func (w *WebhookRepositoryMock) ListSubscriptions(ctx context.Context) ([]entities.WebhookSubscription, error) {
+	subs := make([]entities.WebhookSubscription, 0, len(w.Subs))
+	for _, s := range w.Subs {
+		subs = append(subs, s)
+	}
+	return subs, nil
+}
+
+func (w *WebhookRepositoryMock) GetSubscription(ctx context.Context, id entities.ID) (*entities.WebhookSubscription, error) {
+	s, ok := w.Subs[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	return &s, nil
+}
+
+func (w *WebhookRepositoryMock) DeleteSubscription(ctx context.Context, id entities.ID) error {
+	delete(w.Subs, id)
+	return nil
+}
+
+func (w *WebhookRepositoryMock) EnqueueDelivery(ctx context.Context, d entities.WebhookDelivery) error {
+	for _, other := range w.Sent {
+		if other.SubscriptionID == d.SubscriptionID && other.EventID == d.EventID {
+			return nil
+		}
+	}
+	w.Sent[d.ID] = d
+	return nil
+}
+
+func (w *WebhookRepositoryMock) ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]entities.WebhookDelivery, error) {
+	var due []entities.WebhookDelivery
+	for _, d := range w.Sent {
+		if d.Status == entities.DeliveryPending && !d.NextAttemptAt.After(now) && len(due) < limit {
+			d.NextAttemptAt = until
+			w.Sent[d.ID] = d
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (w *WebhookRepositoryMock) GetDelivery(ctx context.Context, id entities.ID) (*entities.WebhookDelivery, error) {
+	d, ok := w.Sent[id]
+	if !ok {
+		return nil, entityErr.ErrNotFound
+	}
+	return &d, nil
+}
+
+func (w *WebhookRepositoryMock) UpdateDelivery(ctx context.Context, d entities.WebhookDelivery, attempts int) error {
+	if w.Sent[d.ID].Attempts != attempts {
+		return entityErr.ErrConcurrentModification
+	}
+	w.Sent[d.ID] = d
+	return nil
+}
+
+func (w *WebhookRepositoryMock) ListDeliveries(ctx context.Context, subscriptionID entities.ID, status entities.DeliveryStatus) ([]entities.WebhookDelivery, error) {
+	var deliveries []entities.WebhookDelivery
+	for _, d := range w.Sent {
+		if d.SubscriptionID == subscriptionID && (status == "" || d.Status == status) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+func (w *WebhookRepositoryMock) DeleteFinishedDeliveries(ctx context.Context, before time.Time) (int, error) {
+	w.PrunedBefore = before
+	return 0, nil
+}
+
+type SenderMock struct {
+	Status int
+	Err    error
+	Header map[string]string
+	Body   []byte
+
+	// OnSend runs while the request is in flight.
+	OnSend func()
+}
+
+func (s *SenderMock) Send(ctx context.Context, url string, header map[string]string, body []byte) (int, error) {
+	s.Header, s.Body = header, body
+	if s.OnSend != nil {
+		s.OnSend()
+	}
+	return s.Status, s.Err
+}
+
+func TestWebhookService(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	newService := func(sender *SenderMock) (*WebhookService, *WebhookRepositoryMock) {
+		repo := &WebhookRepositoryMock{
+			Subs: make(map[entities.ID]entities.WebhookSubscription),
+			Sent: make(map[entities.ID]entities.WebhookDelivery),
+		}
+		return NewWebhookService(repo, sender, logger), repo
+	}
+
+	t.Run("subscribe generates a secret", func(t *testing.T) {
+		service, _ := newService(&SenderMock{})
+
+		sub, err := service.Subscribe(ctx, entities.WebhookSubscription{URL: "https://example.com/hooks"})
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if sub.ID == (entities.ID{}) || !strings.HasPrefix(sub.Secret, "whsec_") {
+			t.Errorf("should have set the id and secret, got: %+v", sub)
+		}
+
+		_, err = service.Subscribe(ctx, entities.WebhookSubscription{URL: "http://example.com/hooks"})
+		if !errors.Is(err, entityErr.ErrInvalidWebhookURL) {
+			t.Errorf("should have refused a plain http url, got: %v", err)
+		}
+	})
+
+	t.Run("publish queues matching subscriptions once", func(t *testing.T) {
+		service, repo := newService(&SenderMock{})
+		created, _ := service.Subscribe(ctx, entities.WebhookSubscription{URL: "https://example.com/created", Events: []entities.EventType{entities.ClienteCreated}})
+		removed, _ := service.Subscribe(ctx, entities.WebhookSubscription{URL: "https://example.com/removed", Events: []entities.EventType{entities.ClienteRemoved}})
+		all, _ := service.Subscribe(ctx, entities.WebhookSubscription{URL: "https://example.com/all"})
+
+		event := entities.Event{ID: entities.NewID(), Type: entities.ClienteCreated, ClienteID: entities.NewID(), Payload: []byte(`{}`)}
+		for range 2 {
+			if err := service.Publish(ctx, event); err != nil {
+				t.Fatalf("should have not return errors, got: %s", err)
+			}
+		}
+
+		if len(repo.Sent) != 2 {
+			t.Fatalf("should have queued one delivery for each matching subscription, got: %d", len(repo.Sent))
+		}
+		for _, d := range repo.Sent {
+			if d.SubscriptionID == removed.ID || (d.SubscriptionID != created.ID && d.SubscriptionID != all.ID) {
+				t.Errorf("should not have queued for subscription %s", d.SubscriptionID)
+			}
+		}
+	})
+
+	t.Run("deliver signs the request", func(t *testing.T) {
+		sender := &SenderMock{Status: 204}
+		service, repo := newService(sender)
+		sub, _ := service.Subscribe(ctx, entities.WebhookSubscription{URL: "https://example.com/hooks"})
+		_ = service.Publish(ctx, entities.Event{ID: entities.NewID(), Type: entities.ClienteUpdated, Payload: []byte(`{}`)})
+
+		n, err := service.Deliver(ctx)
+		if err != nil || n != 1 {
+			t.Fatalf("should have attempted one delivery, got: %d, %v", n, err)
+		}
+		for _, d := range repo.Sent {
+			if d.Status != entities.DeliveryDelivered || d.Attempts != 1 || d.LastStatusCode != 204 {
+				t.Errorf("should have been delivered, got: %+v", d)
+			}
+		}
+
+		ts := sender.Header[entities.WebhookTimestampHeader]
+		if _, err := strconv.ParseInt(ts, 10, 64); err != nil {
+			t.Errorf("should have sent a unix timestamp, got: %q", ts)
+		}
+		if sig := sender.Header[entities.WebhookSignatureHeader]; sig != entities.SignWebhook(sub.Secret, ts, sender.Body) {
+			t.Errorf("should have signed the body with the secret, got: %q", sig)
+		}
+	})
+
+	t.Run("failed delivery is retried later", func(t *testing.T) {
+		service, repo := newService(&SenderMock{Status: 500})
+		_, _ = service.Subscribe(ctx, entities.WebhookSubscription{URL: "https://example.com/hooks"})
+		_ = service.Publish(ctx, entities.Event{ID: entities.NewID(), Type: entities.ClienteUpdated, Payload: []byte(`{}`)})
+
+		if _, err := service.Deliver(ctx); err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		for _, d := range repo.Sent {
+			if d.Status != entities.DeliveryPending || d.Attempts != 1 || !d.NextAttemptAt.After(time.Now()) {
+				t.Errorf("should have been scheduled again, got: %+v", d)
+			}
+		}
+
+		if n, _ := service.Deliver(ctx); n != 0 {
+			t.Errorf("should have waited before the next attempt, attempted: %d", n)
+		}
+	})
+
+	t.Run("claimed delivery is not attempted again", func(t *testing.T) {
+		service, _ := newService(&SenderMock{Status: 500})
+		_, _ = service.Subscribe(ctx, entities.WebhookSubscription{URL: "https://example.com/hooks"})
+		_ = service.Publish(ctx, entities.Event{ID: entities.NewID(), Type: entities.ClienteUpdated, Payload: []byte(`{}`)})
+
+		sender := &SenderMock{Status: 204}
+		sender.OnSend = func() {
+			if n, _ := service.Deliver(ctx); n != 0 {
+				t.Errorf("should not claim a delivery in flight, got: %d", n)
+			}
+		}
+		service.sender = sender
+
+		if n, err := service.Deliver(ctx); err != nil || n != 1 {
+			t.Fatalf("should have attempted one delivery, got: %d, %v", n, err)
+		}
+	})
+
+	t.Run("outcome of a changed delivery is dropped", func(t *testing.T) {
+		sender := &SenderMock{Status: 204}
+		service, repo := newService(sender)
+		_, _ = service.Subscribe(ctx, entities.WebhookSubscription{URL: "https://example.com/hooks"})
+		_ = service.Publish(ctx, entities.Event{ID: entities.NewID(), Type: entities.ClienteUpdated, Payload: []byte(`{}`)})
+
+		// another replica records an attempt after the claim expired
+		sender.OnSend = func() {
+			for id, d := range repo.Sent {
+				d.Failed(time.Now(), 500, "unexpected status 500")
+				repo.Sent[id] = d
+			}
+		}
+
+		if n, err := service.Deliver(ctx); err != nil || n != 1 {
+			t.Fatalf("should have attempted one delivery, got: %d, %v", n, err)
+		}
+		for _, d := range repo.Sent {
+			if d.Status != entities.DeliveryPending || d.LastStatusCode != 500 {
+				t.Errorf("should have kept the other attempt, got: %+v", d)
+			}
+		}
+	})
+
+	t.Run("dead letter is redelivered", func(t *testing.T) {
+		service, repo := newService(&SenderMock{Err: errors.New("connection refused")})
+		sub, _ := service.Subscribe(ctx, entities.WebhookSubscription{URL: "https://example.com/hooks"})
+		_ = service.Publish(ctx, entities.Event{ID: entities.NewID(), Type: entities.ClienteUpdated, Payload: []byte(`{}`)})
+
+		// move the clock past every backoff
+		now := time.Now()
+		for range entities.WebhookMaxAttempts {
+			service.now = func() time.Time { return now }
+			_, _ = service.Deliver(ctx)
+			now = now.Add(24 * time.Hour)
+		}
+
+		dead, err := service.Deliveries(ctx, sub.ID, entities.DeliveryDead)
+		if err != nil || len(dead) != 1 {
+			t.Fatalf("should have one dead letter, got: %d, %v", len(dead), err)
+		}
+		if dead[0].Attempts != entities.WebhookMaxAttempts || dead[0].LastError != "connection refused" {
+			t.Errorf("unexpected dead letter: %+v", dead[0])
+		}
+
+		if _, err := service.Redeliver(ctx, entities.NewID(), dead[0].ID); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("should not redeliver through another subscription, got: %v", err)
+		}
+
+		d, err := service.Redeliver(ctx, sub.ID, dead[0].ID)
+		if err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if d.Status != entities.DeliveryPending || d.Attempts != 0 || repo.Sent[d.ID].Status != entities.DeliveryPending {
+			t.Errorf("should have queued the delivery again, got: %+v", d)
+		}
+	})
+
+	t.Run("prune keeps the retention", func(t *testing.T) {
+		repo := &WebhookRepositoryMock{}
+		now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+		service := NewWebhookService(repo, &SenderMock{}, logger, WithWebhookRetention(72*time.Hour))
+		service.now = func() time.Time { return now }
+
+		if err := service.Prune(ctx); err != nil {
+			t.Fatalf("should have not return errors, got: %s", err)
+		}
+		if want := now.Add(-72 * time.Hour); !repo.PrunedBefore.Equal(want) {
+			t.Errorf("should have pruned the deliveries created before %s, got: %s", want, repo.PrunedBefore)
+		}
+	})
+
+	t.Run("deliveries of inexistent subscription", func(t *testing.T) {
+		service, _ := newService(&SenderMock{})
+
+		if _, err := service.Deliveries(ctx, entities.NewID(), ""); !errors.Is(err, entityErr.ErrNotFound) {
+			t.Errorf("should have return not found, got: %v", err)
+		}
+	})
+}
@@ -1,17 +1,32 @@
 package usecases
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 
 	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
 )
 
 type ClienteUseCase interface {
-	Create(cliente entities.Cliente) (uuid.UUID, error)
-	List() ([]*entities.Cliente, error)
-	GetClienteById(id uuid.UUID) (*entities.Cliente, error)
-	GetClienteByCPF(cpf string) (*entities.Cliente, error)
-	GetClienteByEmail(email string) (*entities.Cliente, error)
-	Update(cliente entities.Cliente) error
-	Remove(id uuid.UUID) error
+	Create(ctx context.Context, cliente entities.Cliente) (uuid.UUID, error)
+	List(ctx context.Context, opts entities.ListOptions) (entities.ClientePage, error)
+	GetClienteById(ctx context.Context, id uuid.UUID) (*entities.Cliente, error)
+	GetClienteByCPF(ctx context.Context, cpf string) (*entities.Cliente, error)
+	GetClienteByEmail(ctx context.Context, email string) (*entities.Cliente, error)
+	Update(ctx context.Context, cliente entities.Cliente) (*entities.Cliente, error)
+	Patch(ctx context.Context, id uuid.UUID, patch entities.ClientePatch) (*entities.Cliente, error)
+	Remove(ctx context.Context, id uuid.UUID) error
+	Reactivate(ctx context.Context, id uuid.UUID) (*entities.Cliente, error)
+	Purge(ctx context.Context, id uuid.UUID) error
+	Anonymize(ctx context.Context, id uuid.UUID, reason string) (*entities.Cliente, error)
+	Export(ctx context.Context, id uuid.UUID) (*entities.ClienteExport, error)
+	History(ctx context.Context, id uuid.UUID) ([]entities.AuditEntry, error)
+
+	Identify(ctx context.Context, id entities.Identification) (entities.Identified, error)
+
+	// Me, PatchMe and RemoveMe act on the cliente authenticated in ctx.
+	Me(ctx context.Context) (*entities.Cliente, error)
+	PatchMe(ctx context.Context, patch entities.ClientePatch) (*entities.Cliente, error)
+	RemoveMe(ctx context.Context) error
 }
@@ -0,0 +1,15 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+type ConsentUseCase interface {
+	Record(ctx context.Context, record entities.ConsentRecord) (entities.Consent, error)
+	Consents(ctx context.Context, clienteID uuid.UUID) ([]entities.Consent, error)
+	History(ctx context.Context, clienteID uuid.UUID) ([]entities.ConsentRecord, error)
+}
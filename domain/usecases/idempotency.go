@@ -0,0 +1,13 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+type IdempotencyUseCase interface {
+	Begin(ctx context.Context, key, requestHash string) (*entities.IdempotencyRecord, error)
+	Complete(ctx context.Context, rec entities.IdempotencyRecord) error
+	Abandon(ctx context.Context, key string) error
+}
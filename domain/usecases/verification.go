@@ -0,0 +1,14 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+type VerificationUseCase interface {
+	RequestEmailVerification(ctx context.Context, id uuid.UUID) error
+	ConfirmEmailVerification(ctx context.Context, id uuid.UUID, code string) (*entities.Cliente, error)
+}
@@ -0,0 +1,18 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+)
+
+type WebhookUseCase interface {
+	Subscribe(ctx context.Context, sub entities.WebhookSubscription) (entities.WebhookSubscription, error)
+	Subscriptions(ctx context.Context) ([]entities.WebhookSubscription, error)
+	Subscription(ctx context.Context, id uuid.UUID) (*entities.WebhookSubscription, error)
+	Unsubscribe(ctx context.Context, id uuid.UUID) error
+	Deliveries(ctx context.Context, id uuid.UUID, status entities.DeliveryStatus) ([]entities.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id, deliveryID uuid.UUID) (*entities.WebhookDelivery, error)
+}
@@ -2,14 +2,33 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"time"
 
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/events/local"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/events/rabbitmq"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/jwt"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/notifier/email"
+	notifierlocal "github.com/filipeandrade6/fiap-pedeai-clientes/adapters/notifier/local"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/repository/memory"
 	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/repository/postgresql"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/session"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/adapters/webhook"
 	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/controllers/api/v1/handlers"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/entities"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/ports"
 	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/services"
+	"github.com/filipeandrade6/fiap-pedeai-clientes/domain/usecases"
 )
 
 func main() {
@@ -20,19 +39,160 @@ func main() {
 	// ====================
 	// database
 
-	db, err := postgresql.New(ctx, postgresql.Config{
-		Host:       os.Getenv("DB_HOST"),
-		Port:       os.Getenv("DB_PORT"),
-		User:       os.Getenv("DB_USER"),
-		Password:   os.Getenv("DB_PASS"),
-		Name:       os.Getenv("DB_NAME"),
-		DisableTLS: false,
-	})
+	var (
+		repo     ports.Repository
+		consents ports.ConsentRepository
+		outbox   ports.Outbox
+		webhooks ports.WebhookRepository
+		keys     ports.IdempotencyStore
+		codes    ports.VerificationStore
+	)
+	if os.Getenv("REPOSITORY") == "memory" {
+		logger.Info("using in-memory repository, data will be lost on exit")
+		mem := memory.New()
+		repo, consents, outbox, webhooks, keys, codes = mem, mem, mem, mem, mem, mem
+	} else {
+		db, err := postgresql.New(ctx, postgresql.Config{
+			Host:       os.Getenv("DB_HOST"),
+			Port:       os.Getenv("DB_PORT"),
+			User:       os.Getenv("DB_USER"),
+			Password:   os.Getenv("DB_PASS"),
+			Name:       os.Getenv("DB_NAME"),
+			DisableTLS: false,
+		})
+		if err != nil {
+			logger.Error("connecting to database", "error", err)
+			os.Exit(1)
+		}
+
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			if err := migrate(ctx, db, os.Args[2:]); err != nil {
+				logger.Error("migrating database", "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if err := db.Migrate(ctx); err != nil {
+			logger.Error("migrating database", "error", err)
+			os.Exit(1)
+		}
+		repo, consents, outbox, webhooks, keys, codes = db, db, db, db, db, db
+	}
+
+	// ====================
+	// events
+
+	var publisher ports.EventPublisher
+	if os.Getenv("BROKER_DISABLED") == "true" {
+		logger.Warn("broker disabled, events are only logged")
+		publisher = local.New(logger)
+	} else {
+		url := os.Getenv("BROKER_URL")
+		if url == "" {
+			logger.Error("configuring broker", "error", errors.New("BROKER_URL is required unless BROKER_DISABLED=true"))
+			os.Exit(1)
+		}
+		exchange := os.Getenv("BROKER_EXCHANGE")
+		if exchange == "" {
+			exchange = "pedeai"
+		}
+		broker, err := rabbitmq.New(rabbitmq.Config{URL: url, Exchange: exchange})
+		if err != nil {
+			logger.Error("connecting to broker", "error", err)
+			os.Exit(1)
+		}
+		defer broker.Close()
+		publisher = broker
+	}
+
+	var webhookOpts []services.WebhookOption
+	if retention := os.Getenv("WEBHOOK_RETENTION"); retention != "" {
+		d, err := time.ParseDuration(retention)
+		if err != nil {
+			logger.Error("parsing WEBHOOK_RETENTION", "error", err)
+			os.Exit(1)
+		}
+		webhookOpts = append(webhookOpts, services.WithWebhookRetention(d))
+	}
+	webhookService := services.NewWebhookService(webhooks, webhook.New(0), logger, webhookOpts...)
+	go webhookService.Run(ctx)
+
+	var relayOpts []services.RelayOption
+	if retention := os.Getenv("OUTBOX_RETENTION"); retention != "" {
+		d, err := time.ParseDuration(retention)
+		if err != nil {
+			logger.Error("parsing OUTBOX_RETENTION", "error", err)
+			os.Exit(1)
+		}
+		relayOpts = append(relayOpts, services.WithRelayRetention(d))
+	}
+	// one relay per sink, so a broker outage does not hold back the webhooks
+	go services.NewRelay(outbox, entities.SinkBroker, publisher, logger, relayOpts...).Run(ctx)
+	go services.NewRelay(outbox, entities.SinkWebhooks, webhookService, logger, relayOpts...).Run(ctx)
+
+	signer, err := newSessionSigner(logger)
+	if err != nil {
+		logger.Error("configuring sessions", "error", err)
+		os.Exit(1)
+	}
+
+	consentService := services.NewConsentService(repo, consents)
+	clienteService := services.New(repo,
+		services.WithExportSources(consentService),
+		services.WithSessionIssuer(signer))
+
+	// a request in progress holds its key until it certainly timed out
+	idempotencyOpts := []services.IdempotencyOption{services.WithIdempotencyLease(2 * api.RequestTimeout)}
+	if ttl := os.Getenv("IDEMPOTENCY_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			logger.Error("parsing IDEMPOTENCY_TTL", "error", err)
+			os.Exit(1)
+		}
+		idempotencyOpts = append(idempotencyOpts, services.WithIdempotencyTTL(d))
+	}
+	idempotency := services.NewIdempotency(keys, logger, idempotencyOpts...)
+	go idempotency.Run(ctx)
+
+	verificationService, err := newVerificationService(logger, repo, codes)
 	if err != nil {
-		logger.Error("connecting to database", "error", err)
+		logger.Error("configuring e-mail verification", "error", err)
+		os.Exit(1)
+	}
+
+	// ====================
+	// authentication
+
+	var (
+		verifier       handlers.TokenVerifier
+		clienteUC      usecases.ClienteUseCase      = clienteService
+		consentUC      usecases.ConsentUseCase      = consentService
+		webhookUC      usecases.WebhookUseCase      = webhookService
+		verificationUC usecases.VerificationUseCase = verificationService
+	)
+	if os.Getenv("AUTH_DISABLED") == "true" {
+		logger.Warn("authentication disabled, anyone can call the api")
+	} else {
+		v, err := newVerifier()
+		if err != nil {
+			logger.Error("configuring authentication", "error", err)
+			os.Exit(1)
+		}
+		verifier = v
+
+		policy, err := loadPolicy(os.Getenv("AUTH_POLICY"))
+		if err != nil {
+			logger.Error("loading authorization policy", "error", err)
+			os.Exit(1)
+		}
+		clienteUC = services.NewAuthorizedClientes(clienteService, policy)
+		consentUC = services.NewAuthorizedConsents(consentService, policy)
+		webhookUC = services.NewAuthorizedWebhooks(webhookService, policy)
+		verificationUC = services.NewAuthorizedVerification(verificationService, policy)
 	}
 
-	srv := api.NewServer(logger, services.New(db))
+	srv := api.NewServer(logger, verifier, clienteUC, consentUC, webhookUC, idempotency, verificationUC)
 
 	httpServer := &http.Server{
 		Addr:    ":8081",
@@ -43,3 +203,133 @@ func main() {
 		logger.Info("listening and serving", "error", err)
 	}
 }
+
+// newVerifier verifies the tokens signed with the keys of the JWKS at AUTH_JWKS,
+// a URL or a file, and issued by AUTH_ISSUER for AUTH_AUDIENCE.
+func newVerifier() (*jwt.Verifier, error) {
+	cfg := jwt.Config{
+		Issuer:   os.Getenv("AUTH_ISSUER"),
+		Audience: os.Getenv("AUTH_AUDIENCE"),
+	}
+	source := os.Getenv("AUTH_JWKS")
+	if source == "" || cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, errors.New("AUTH_JWKS, AUTH_ISSUER and AUTH_AUDIENCE are required unless AUTH_DISABLED=true")
+	}
+
+	if skew := os.Getenv("AUTH_CLOCK_SKEW"); skew != "" {
+		d, err := time.ParseDuration(skew)
+		if err != nil {
+			return nil, fmt.Errorf("parsing AUTH_CLOCK_SKEW: %w", err)
+		}
+		cfg.Leeway = d
+	}
+
+	keys, err := jwt.LoadKeys(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwt.New(keys, cfg), nil
+}
+
+// newSessionSigner signs the sessions of the clientes identified at the totem
+// with the key at SESSION_SIGNING_KEY, valid for SESSION_TTL. Without key, the
+// sessions are signed with a random key the other services do not know.
+func newSessionSigner(logger *slog.Logger) (*session.Signer, error) {
+	var ttl time.Duration
+	if s := os.Getenv("SESSION_TTL"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("parsing SESSION_TTL: %w", err)
+		}
+		ttl = d
+	}
+
+	if s := os.Getenv("SESSION_SIGNING_KEY"); s != "" {
+		key, err := session.ParsePrivateKey(s)
+		if err != nil {
+			return nil, err
+		}
+		return session.NewSigner(key, ttl), nil
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	signer := session.NewSigner(key, ttl)
+	logger.Warn("no SESSION_SIGNING_KEY, sessions are signed with a random key",
+		"public_key", base64.StdEncoding.EncodeToString(signer.PublicKey()))
+
+	return signer, nil
+}
+
+// newVerificationService sends the verification codes through the SMTP server
+// at SMTP_ADDR, from SMTP_FROM and authenticated with SMTP_USER and SMTP_PASS,
+// or logs them when SMTP_ADDR is empty. Codes are hashed with
+// VERIFICATION_SECRET or, when empty, with a random secret the other replicas
+// do not know.
+func newVerificationService(logger *slog.Logger, repo ports.Repository, codes ports.VerificationStore) (*services.VerificationService, error) {
+	var notifier ports.Notifier
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		n, err := email.New(email.Config{
+			Addr:     addr,
+			Username: os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASS"),
+			From:     os.Getenv("SMTP_FROM"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		notifier = n
+	} else {
+		logger.Warn("no SMTP_ADDR, notifications are logged instead of sent")
+		notifier = notifierlocal.New(logger)
+	}
+
+	var opts []services.VerificationOption
+	if secret := os.Getenv("VERIFICATION_SECRET"); secret != "" {
+		opts = append(opts, services.WithVerificationSecret([]byte(secret)))
+	} else {
+		logger.Warn("no VERIFICATION_SECRET, codes are hashed with a random secret and only this replica confirms them")
+	}
+
+	return services.NewVerificationService(repo, codes, notifier, opts...)
+}
+
+// loadPolicy reads the policy at path, the default one when path is empty.
+func loadPolicy(path string) (entities.Policy, error) {
+	if path == "" {
+		return entities.DefaultPolicy(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.ParsePolicy(data)
+}
+
+// migrate runs the "migrate" subcommand: "migrate [up]" applies the pending
+// migrations and "migrate down [steps]" reverts the last ones, one by default.
+func migrate(ctx context.Context, db *postgresql.Repository, args []string) error {
+	if len(args) == 0 || args[0] == "up" {
+		return db.Migrate(ctx)
+	}
+
+	if args[0] != "down" {
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+
+	steps := 1
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of steps %q", args[1])
+		}
+		steps = n
+	}
+
+	return db.Rollback(ctx, steps)
+}